@@ -0,0 +1,582 @@
+// Command merkleweave notarizes files with a Fabula server and verifies the
+// resulting receipts offline.
+//
+// Usage:
+//
+//	merkleweave [-server ADDR] [-tls] [-pin FILE] [-key PUBKEY] notarize [-o RECEIPT] [-wait] FILE
+//	merkleweave [-server ADDR] [-tls] [-pin FILE] [-key PUBKEY] summary [-o SUMMARY]
+//	merkleweave [-server ADDR] [-tls] [-pin FILE] [-key PUBKEY] upgrade [-summary SUMMARY] [-o RECEIPT] RECEIPT
+//	merkleweave [-key PUBKEY] verify [-summary SUMMARY] FILE RECEIPT
+//	merkleweave genkey KEY PUBKEY
+//	merkleweave -key PUBKEY witness [-listen ADDR] [-state FILE] KEY
+//	merkleweave audit [-o SUMMARY] DIR
+//	merkleweave [-tls] [-key PUBKEY] monitor [-history DIR] [-interval DURATION] ADDR...
+//
+// Files are hashed with SHA3-256, as in storage.proto. A receipt contains the
+// hash, its timestamp and positions, proofs of its inclusion, and the summary
+// of the Merkle weave it is proven to be included in. If -pin names a file,
+// the summary in it is pinned before contacting the server, and the latest
+// verified summary is saved to it afterwards, so that successive runs check
+// that the server remains consistent. If -key names a PEM-encoded ed25519
+// public key, summaries must be signed by it.
+//
+// The upgrade command extends the proofs of a receipt to a later summary, by
+// default the latest, with proofs of consistency from the server, so that the
+// receipt can be verified offline against that summary. The upgraded receipt
+// replaces RECEIPT unless -o is set.
+//
+// The verify command checks offline that FILE is included at the positions of
+// RECEIPT in its summary or in the saved summary named by -summary, to which
+// the receipt must first be upgraded if the summary is later. Without -key,
+// nothing shows that the summary and receipt were issued by the server, so
+// verify warns that they may be forged. Timestamps are not committed to by the
+// trees, so unless the receipt is signed by -key, verify reports only that FILE
+// was included no later than the last entries of its trees.
+//
+// The genkey command generates an ed25519 key pair for signing summaries,
+// saving the private key to KEY for use with merkleweaved -signing_key and the
+// public key to PUBKEY.
+//
+// The witness command serves a witness (see package witness) until
+// interrupted, cosigning summaries signed by the operator key named by -key
+// with the private key in KEY. The last cosigned summary is saved in the
+// state file, from which the witness resumes when restarted.
+//
+// The audit command audits the entries stored by merkleweaved in DIR (see
+// package audit) without modifying them, printing a report and saving the
+// summary of the Merkle weave recomputed from them. It exits with status 1 if
+// problems are found.
+//
+// The monitor command follows the Fabula servers at each ADDR until
+// interrupted, logging alerts if any server misbehaves or if servers are
+// inconsistent with each other (see package monitor). Verified summaries are
+// saved in the history directory, from which monitoring resumes when
+// restarted. If -key is set, summaries not signed by it raise alerts.
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/vsekhar/merkleweave/driver/filedriver"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/audit"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/monitor"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/witness"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/witnesspb"
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const usage = `usage:
+	merkleweave [flags] notarize [-o RECEIPT] [-wait] FILE
+	merkleweave [flags] summary [-o SUMMARY]
+	merkleweave [flags] upgrade [-summary SUMMARY] [-o RECEIPT] RECEIPT
+	merkleweave [flags] verify [-summary SUMMARY] FILE RECEIPT
+	merkleweave genkey KEY PUBKEY
+	merkleweave -key PUBKEY witness [-listen ADDR] [-state FILE] KEY
+	merkleweave audit [-o SUMMARY] DIR
+	merkleweave [flags] monitor [-history DIR] [-interval DURATION] ADDR...
+flags:
+`
+
+const timeout = 30 * time.Second
+
+func main() {
+	fs := flag.NewFlagSet("merkleweave", flag.ExitOnError)
+	addr := fs.String("server", "localhost:8080", "address of the Fabula server")
+	useTLS := fs.Bool("tls", false, "connect to the server using TLS")
+	pin := fs.String("pin", "", "file to load the pinned summary from and save it to")
+	key := fs.String("key", "", "PEM ed25519 public key that summaries must be signed by")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[1:])
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	cmd, args := fs.Arg(0), fs.Args()[1:]
+	keys, err := loadKeyRing(*key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "merkleweave: %v\n", err)
+		os.Exit(1)
+	}
+	switch cmd {
+	case "verify":
+		err = verifyCmd(args, keys, os.Stdout)
+	case "genkey":
+		err = genkeyCmd(args, os.Stdout)
+	case "audit":
+		err = auditCmd(args, os.Stdout)
+	case "monitor":
+		err = monitorCmd(args, *useTLS, keys)
+	case "witness":
+		err = witnessCmd(args, keys, interrupted())
+	case "notarize", "summary", "upgrade":
+		err = online(*addr, *useTLS, *pin, keys, func(ctx context.Context, c *client.Client) error {
+			switch cmd {
+			case "notarize":
+				return notarizeCmd(ctx, c, args, os.Stdout)
+			case "upgrade":
+				return upgradeCmd(ctx, c, args, os.Stdout)
+			}
+			return summaryCmd(ctx, c, args, os.Stdout)
+		})
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "merkleweave %s: %v\n", cmd, err)
+		os.Exit(1)
+	}
+}
+
+func dial(addr string, useTLS bool) (*grpc.ClientConn, error) {
+	opt := grpc.WithInsecure()
+	if useTLS {
+		opt = grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, ""))
+	}
+	return grpc.Dial(addr, opt)
+}
+
+// loadKeyRing returns a key ring trusting the public key in the file at path,
+// or nil if path is empty.
+func loadKeyRing(path string) (signing.KeyRing, error) {
+	if path == "" {
+		return nil, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := signing.ParsePublicKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return signing.NewKeyRing(pub), nil
+}
+
+// online connects to the server at addr and calls f with a client, loading
+// and saving the pinned summary from pin if it is not empty. If keys is not
+// nil, summaries must be signed by one of its keys.
+func online(addr string, useTLS bool, pin string, keys signing.KeyRing, f func(context.Context, *client.Client) error) error {
+	conn, err := dial(addr, useTLS)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	fc := servicepb.NewFabulaClient(conn)
+
+	var opts []client.Option
+	if keys != nil {
+		opts = append(opts, client.WithKeyRing(keys))
+	}
+	c := client.New(fc, opts...)
+	if pin != "" {
+		s := new(client.Summary)
+		switch err := readJSON(pin, s); {
+		case err == nil:
+			c = client.NewWithSummary(fc, s, opts...)
+		case !os.IsNotExist(err):
+			return err
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := f(ctx, c); err != nil {
+		return err
+	}
+	if pin != "" {
+		return writeJSON(pin, c.Pinned())
+	}
+	return nil
+}
+
+// hashFile returns the SHA3-256 hash of the file at path.
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h := sha3.New256()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func readJSON(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+func notarizeCmd(ctx context.Context, c *client.Client, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("notarize", flag.ContinueOnError)
+	out := fs.String("o", "", "file to save the receipt to (default FILE.receipt)")
+	wait := fs.Bool("wait", false, "wait until the high water mark passes the timestamp of the receipt")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected FILE")
+	}
+	path := fs.Arg(0)
+	if *out == "" {
+		*out = path + ".receipt"
+	}
+	h, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	r, err := c.Notarize(ctx, h)
+	if err != nil {
+		return err
+	}
+	if *wait {
+		if _, err := c.Summary(ctx, r.Timestamp); err != nil {
+			return err
+		}
+		if r, err = c.VerifyReceipt(ctx, r.Receipt); err != nil {
+			return err
+		}
+	}
+	if err := writeJSON(*out, r); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "%s: notarized at %s, receipt saved to %s\n", path, r.Timestamp.Format(time.RFC3339Nano), *out)
+	return nil
+}
+
+func summaryCmd(ctx context.Context, c *client.Client, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	out := fs.String("o", "summary.json", "file to save the summary to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("unexpected arguments")
+	}
+	s, err := c.Summary(ctx, time.Time{})
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(*out, s); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "summary with high water mark %s saved to %s\n", s.HWM().Format(time.RFC3339Nano), *out)
+	return nil
+}
+
+func upgradeCmd(ctx context.Context, c *client.Client, args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	summary := fs.String("summary", "", "file of a saved summary to upgrade to (default the latest summary)")
+	out := fs.String("o", "", "file to save the upgraded receipt to (default RECEIPT)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected RECEIPT")
+	}
+	if *out == "" {
+		*out = fs.Arg(0)
+	}
+	r := new(client.Receipt)
+	if err := readJSON(fs.Arg(0), r); err != nil {
+		return err
+	}
+	var s *client.Summary
+	if *summary != "" {
+		s = new(client.Summary)
+		if err := readJSON(*summary, s); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if s, err = c.Summary(ctx, time.Time{}); err != nil {
+			return err
+		}
+	}
+	up, err := c.UpgradeReceipt(ctx, r, s)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(*out, up); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "receipt upgraded to summary with high water mark %s, saved to %s\n", s.HWM().Format(time.RFC3339Nano), *out)
+	return nil
+}
+
+// checkSummary returns an error if the trees of the entry of r in s differ
+// from those in the summary of r, so that r must be upgraded to s.
+func checkSummary(r *client.Receipt, s *client.Summary) error {
+	if r.Summary == nil {
+		return nil
+	}
+	for _, pos := range r.Positions {
+		old, _ := r.Summary.Tree(pos.Prefix)
+		new, ok := s.Tree(pos.Prefix)
+		if ok && old.Summary.N != new.Summary.N {
+			return fmt.Errorf("receipt is proven in tree %x of size %d, summary has size %d; upgrade the receipt to the summary first", pos.Prefix, old.Summary.N, new.Summary.N)
+		}
+	}
+	return nil
+}
+
+func verifyCmd(args []string, keys signing.KeyRing, w io.Writer) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	summary := fs.String("summary", "", "file of a saved summary to verify against (default the summary in RECEIPT)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("expected FILE and RECEIPT")
+	}
+	path := fs.Arg(0)
+	h, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	r := new(client.Receipt)
+	if err := readJSON(fs.Arg(1), r); err != nil {
+		return err
+	}
+	if string(h) != string(r.Data) {
+		return fmt.Errorf("%s does not match receipt", path)
+	}
+	s := r.Summary
+	if *summary != "" {
+		s = new(client.Summary)
+		if err := readJSON(*summary, s); err != nil {
+			return err
+		}
+		if err := checkSummary(r, s); err != nil {
+			return err
+		}
+	}
+	if err := r.Verify(s); err != nil {
+		return err
+	}
+	signed := false
+	if keys != nil {
+		if err := s.VerifySignature(keys); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "summary signed by key %x at %s\n", s.Signature.KeyID, s.Signature.Time.Format(time.RFC3339Nano))
+		if r.Signature != nil {
+			if err := r.VerifySignature(keys); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "receipt signed by key %x\n", r.Signature.KeyID)
+			signed = true
+		}
+	} else {
+		fmt.Fprintln(w, "warning: no -key given; the summary and receipt are not authenticated and may be forged")
+	}
+	if signed {
+		fmt.Fprintf(w, "%s: included at %s\n", path, r.Timestamp.Format(time.RFC3339Nano))
+	} else {
+		// The entry precedes the last entry of each of its trees.
+		var last time.Time
+		for i, pos := range r.Positions {
+			if t, ok := s.Tree(pos.Prefix); ok && (i == 0 || t.Last.Before(last)) {
+				last = t.Last
+			}
+		}
+		fmt.Fprintf(w, "%s: included no later than %s; the receipt timestamp %s is not signed\n", path, last.Format(time.RFC3339Nano), r.Timestamp.Format(time.RFC3339Nano))
+	}
+	if hwm := s.HWM(); hwm.After(r.Timestamp) {
+		fmt.Fprintf(w, "high water mark %s is after the entry\n", hwm.Format(time.RFC3339Nano))
+	} else {
+		fmt.Fprintf(w, "warning: high water mark %s is not after the entry; entries with earlier timestamps may still be added\n", hwm.Format(time.RFC3339Nano))
+	}
+	return nil
+}
+
+func genkeyCmd(args []string, w io.Writer) error {
+	if len(args) != 2 {
+		return errors.New("expected KEY and PUBKEY")
+	}
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	kb, err := signing.MarshalPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	pb, err := signing.MarshalPublicKey(pub)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(args[0], kb, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(args[1], pb, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "generated key %x\n", signing.KeyID(pub))
+	return nil
+}
+
+// interrupted returns a channel that is closed on SIGINT or SIGTERM.
+func interrupted() <-chan struct{} {
+	c := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(c)
+	}()
+	return c
+}
+
+func witnessCmd(args []string, operator signing.KeyRing, stop <-chan struct{}) error {
+	fs := flag.NewFlagSet("witness", flag.ContinueOnError)
+	listen := fs.String("listen", ":8081", "address to serve the witness on")
+	state := fs.String("state", "witness.json", "file to save the last cosigned summary in")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected KEY")
+	}
+	if operator == nil {
+		return errors.New("-key is required")
+	}
+	b, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	key, err := signing.ParsePrivateKey(b)
+	if err != nil {
+		return fmt.Errorf("%s: %v", fs.Arg(0), err)
+	}
+	w, err := witness.New(operator, signing.NewSigner(key), witness.FileStore(*state))
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	gs := grpc.NewServer()
+	witnesspb.RegisterWitnessService(gs, w.Service())
+	go func() {
+		<-stop
+		gs.GracefulStop()
+	}()
+	log.Printf("witness %x serving on %s", signing.KeyID(key.Public().(ed25519.PublicKey)), lis.Addr())
+	return gs.Serve(lis)
+}
+
+func auditCmd(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	out := fs.String("o", "", "file to save the recomputed summary to, none if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected DIR")
+	}
+	d, err := filedriver.OpenReadOnly(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	r, err := audit.Audit(d)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(w); err != nil {
+		return err
+	}
+	if !r.OK() {
+		return fmt.Errorf("%d problems found", len(r.Problems))
+	}
+	if *out != "" {
+		return writeJSON(*out, r.Summary)
+	}
+	return nil
+}
+
+func monitorCmd(args []string, useTLS bool, keys signing.KeyRing) error {
+	fs := flag.NewFlagSet("monitor", flag.ContinueOnError)
+	dir := fs.String("history", "history", "directory to save verified summaries in")
+	interval := fs.Duration("interval", time.Minute, "interval between polls")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("expected ADDR")
+	}
+	h, err := monitor.NewFileHistory(*dir)
+	if err != nil {
+		return err
+	}
+	var es []monitor.Endpoint
+	for _, addr := range fs.Args() {
+		conn, err := dial(addr, useTLS)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		es = append(es, monitor.Endpoint{Name: addr, Client: servicepb.NewFabulaClient(conn)})
+	}
+	l := log.New(os.Stderr, "", log.LstdFlags)
+	var opts []monitor.Option
+	if keys != nil {
+		opts = append(opts, monitor.WithKeyRing(keys))
+	}
+	m, err := monitor.New(es, h, monitor.LogNotifier(l), opts...)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		cancel()
+	}()
+	if err := m.Run(ctx, *interval, l); err != context.Canceled {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/driver/filedriver"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func serve(t *testing.T, w *merkleweave.MerkleWeave) servicepb.FabulaClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	servicepb.RegisterFabulaService(s, server.New(w).Service())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return servicepb.NewFabulaClient(conn)
+}
+
+func TestNotarizeVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "merkleweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "file.txt")
+	if err := ioutil.WriteFile(file, []byte("hello, world\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	receipt := filepath.Join(dir, "receipt.json")
+	summary := filepath.Join(dir, "summary.json")
+
+	ctx := context.Background()
+	c := client.New(serve(t, merkleweave.New()))
+	var out bytes.Buffer
+	if err := notarizeCmd(ctx, c, []string{"-o", receipt, "-wait", file}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := summaryCmd(ctx, c, []string{"-o", summary}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	out.Reset()
+	if err := verifyCmd([]string{"-summary", summary, file, receipt}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "is after the entry") {
+		t.Errorf("expected high water mark after entry, got %q", out.String())
+	}
+	if err := verifyCmd([]string{file, receipt}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "may be forged") {
+		t.Errorf("expected warning without -key, got %q", out.String())
+	}
+	if !strings.Contains(out.String(), "included no later than") {
+		t.Errorf("expected only an upper bound on the timestamp without -key, got %q", out.String())
+	}
+
+	// A receipt must be upgraded to verify against a later summary.
+	other := filepath.Join(dir, "other.txt")
+	if err := ioutil.WriteFile(other, []byte("hello, again\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := notarizeCmd(ctx, c, []string{"-o", filepath.Join(dir, "other.json"), "-wait", other}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := summaryCmd(ctx, c, []string{"-o", summary}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyCmd([]string{"-summary", summary, file, receipt}, nil, &out); err == nil || !strings.Contains(err.Error(), "upgrade") {
+		t.Errorf("expected error to upgrade receipt, got %v", err)
+	}
+	if err := upgradeCmd(ctx, c, []string{"-summary", summary, receipt}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyCmd([]string{"-summary", summary, file, receipt}, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("goodbye, world\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyCmd([]string{file, receipt}, nil, &out); err == nil {
+		t.Error("expected error for modified file")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "merkleweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	data := filepath.Join(dir, "data")
+	d, err := filedriver.Open(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := merkleweave.Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		m.Append([]byte{byte(i), 1, 2, 3})
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	summary := filepath.Join(dir, "summary.json")
+	var out bytes.Buffer
+	if err := auditCmd([]string{"-o", summary, data}, &out); err != nil {
+		t.Fatalf("%v: %s", err, out.String())
+	}
+	s := new(client.Summary)
+	if err := readJSON(summary, s); err != nil {
+		t.Fatal(err)
+	}
+	if tr, _ := s.Tree([]byte{1}); tr.Summary.N != 21 {
+		t.Errorf("expected 21 entries in tree 01, got %d", tr.Summary.N)
+	}
+}
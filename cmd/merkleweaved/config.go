@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// duration is a time.Duration that is encoded in JSON as a string, e.g.
+// "1s".
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// config is the configuration of merkleweaved.
+type config struct {
+	// Listen is the address to serve gRPC on.
+	Listen string `json:"listen"`
+
+	// HTTP is the address to serve the HTTP API on, none if empty.
+	HTTP string `json:"http"`
+
+	// Data is the directory to store entries in. Entries are only kept in
+	// memory if empty.
+	Data string `json:"data"`
+
+	// Tick is the interval at which trees are advanced with sentinels if
+	// they have no more recent entries, never if zero (the default). Each
+	// tick stores a sentinel in every idle tree, up to 256 entries per tick,
+	// so storage grows without bound even when no data is notarized.
+	// Clients can instead advance trees on demand with min timestamps.
+	Tick duration `json:"tick"`
+
+	// CallerMetadata is the request metadata (or HTTP header) identifying
+	// callers. If set, requests without it are rejected and quotas are
+	// enforced.
+	CallerMetadata string `json:"callerMetadata"`
+
+	// QuotaWindow is the window over which quotas apply.
+	QuotaWindow duration `json:"quotaWindow"`
+
+	// Per-caller quotas within QuotaWindow, unlimited if zero.
+	QuotaPrefixes      int `json:"quotaPrefixes"`
+	QuotaSentinels     int `json:"quotaSentinels"`
+	QuotaNotarizations int `json:"quotaNotarizations"`
+	QuotaPublications  int `json:"quotaPublications"`
+
+	// SigningKey is a file containing a PEM-encoded ed25519 private key to
+	// sign summaries with. Summaries are not signed if empty.
+	SigningKey string `json:"signingKey"`
+
+	// SummaryLog is the file to keep the log of published summaries in.
+	// Summaries are not logged if empty.
+	SummaryLog string `json:"summaryLog"`
+}
+
+func defaultConfig() *config {
+	return &config{
+		Listen:      ":8080",
+		QuotaWindow: duration{time.Minute},
+	}
+}
+
+// loadConfig returns the configuration specified by args. Settings are read
+// from the file named by -config, if any, and then overridden by any flags that
+// are set.
+func loadConfig(args []string) (*config, error) {
+	fs := flag.NewFlagSet("merkleweaved", flag.ContinueOnError)
+	path := fs.String("config", "", "JSON configuration file")
+	c := defaultConfig()
+	fs.StringVar(&c.Listen, "listen", c.Listen, "address to serve gRPC on")
+	fs.StringVar(&c.HTTP, "http", c.HTTP, "address to serve the HTTP API on, none if empty")
+	fs.StringVar(&c.Data, "data", c.Data, "directory to store entries in, in memory if empty")
+	fs.DurationVar(&c.Tick.Duration, "tick", c.Tick.Duration, "interval at which to advance idle trees, never if zero; each tick stores up to 256 sentinels, even when idle")
+	fs.StringVar(&c.CallerMetadata, "caller_metadata", c.CallerMetadata, "request metadata identifying callers, enables quotas")
+	fs.DurationVar(&c.QuotaWindow.Duration, "quota_window", c.QuotaWindow.Duration, "window over which quotas apply")
+	fs.IntVar(&c.QuotaPrefixes, "quota_prefixes", c.QuotaPrefixes, "prefixes with min timestamps per caller per window")
+	fs.IntVar(&c.QuotaSentinels, "quota_sentinels", c.QuotaSentinels, "sentinels per caller per window")
+	fs.IntVar(&c.QuotaNotarizations, "quota_notarizations", c.QuotaNotarizations, "notarizations per caller per window")
+	fs.IntVar(&c.QuotaPublications, "quota_publications", c.QuotaPublications, "summaries published to the summary log per caller per window")
+	fs.StringVar(&c.SigningKey, "signing_key", c.SigningKey, "PEM ed25519 private key to sign summaries with")
+	fs.StringVar(&c.SummaryLog, "summary_log", c.SummaryLog, "file to keep the log of published summaries in, none if empty")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 0 {
+		return nil, fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if *path == "" {
+		return c, c.validate()
+	}
+
+	// Apply the configuration file, then re-apply flags that were set.
+	b, err := ioutil.ReadFile(*path)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = f.Value.String() })
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("%s: %v", *path, err)
+	}
+	for name, v := range set {
+		if err := fs.Set(name, v); err != nil {
+			return nil, err
+		}
+	}
+	return c, c.validate()
+}
+
+// validate returns an error if c is not a valid configuration.
+func (c *config) validate() error {
+	if c.Tick.Duration < 0 {
+		return fmt.Errorf("tick: negative interval %s", c.Tick.Duration)
+	}
+	if c.QuotaWindow.Duration < 0 {
+		return fmt.Errorf("quota_window: negative window %s", c.QuotaWindow.Duration)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Command merkleweaved serves a Merkle weave over gRPC and HTTP.
+//
+// Usage:
+//
+//	merkleweaved [-config FILE] [-listen ADDR] [-http ADDR] [-data DIR] [-tick DURATION] ...
+//
+// Settings in the JSON configuration file named by -config are overridden by
+// flags. Entries are flushed to storage before they are acknowledged. On
+// SIGINT or SIGTERM, merkleweaved stops serving and closes storage before
+// exiting.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/vsekhar/merkleweave/driver"
+	"github.com/vsekhar/merkleweave/driver/filedriver"
+	"github.com/vsekhar/merkleweave/driver/memdriver"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/httpapi"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"google.golang.org/grpc"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func main() {
+	c, err := loadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	if err := run(c, stop); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func openDriver(c *config) (driver.Interface, error) {
+	if c.Data == "" {
+		log.Print("storing entries in memory")
+		return memdriver.New(), nil
+	}
+	log.Printf("storing entries in %s", c.Data)
+	return filedriver.Open(c.Data)
+}
+
+// every calls f every d until done is closed. If d is zero, f is never called.
+func every(d time.Duration, done <-chan struct{}, f func()) {
+	if d == 0 {
+		return
+	}
+	t := time.NewTicker(d)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			f()
+		case <-done:
+			return
+		}
+	}
+}
+
+// run serves until stop receives a value.
+func run(c *config, stop <-chan os.Signal) error {
+	d, err := openDriver(c)
+	if err != nil {
+		return err
+	}
+	w, err := merkleweave.Open(d)
+	if err != nil {
+		d.Close()
+		return err
+	}
+	log.Printf("loaded %d entries", w.Len())
+
+	var srvOpts []server.Option
+	if c.SigningKey != "" {
+		b, err := ioutil.ReadFile(c.SigningKey)
+		if err != nil {
+			d.Close()
+			return err
+		}
+		key, err := signing.ParsePrivateKey(b)
+		if err != nil {
+			d.Close()
+			return fmt.Errorf("%s: %v", c.SigningKey, err)
+		}
+		signer := signing.NewSigner(key)
+		log.Printf("signing summaries with key %x", signer.KeyID())
+		srvOpts = append(srvOpts, server.WithSigner(signer))
+	}
+	if c.SummaryLog != "" {
+		sl, err := merkleweave.OpenSummaryLog(c.SummaryLog)
+		if err != nil {
+			d.Close()
+			return err
+		}
+		defer sl.Close()
+		log.Printf("logging summaries in %s (%d summaries)", c.SummaryLog, sl.Len())
+		srvOpts = append(srvOpts, server.WithSummaryLog(sl))
+	}
+	s := server.New(w, srvOpts...)
+	var grpcOpts []grpc.ServerOption
+	var httpOpts []httpapi.Option
+	if c.CallerMetadata != "" {
+		a := server.NewQuotaAccountant(server.Quota{
+			Window: c.QuotaWindow.Duration,
+			Limit: server.Cost{
+				PrefixesWithMinTimestamp: c.QuotaPrefixes,
+				Sentinels:                c.QuotaSentinels,
+				Notarizations:            c.QuotaNotarizations,
+				Publications:             c.QuotaPublications,
+			},
+		})
+		i := server.UnaryServerInterceptor(a, server.MetadataCaller(c.CallerMetadata))
+		grpcOpts = append(grpcOpts, grpc.UnaryInterceptor(i))
+		httpOpts = append(httpOpts, httpapi.WithInterceptor(i))
+	}
+	gs := grpc.NewServer(grpcOpts...)
+	servicepb.RegisterFabulaService(gs, s.Service())
+
+	lis, err := net.Listen("tcp", c.Listen)
+	if err != nil {
+		d.Close()
+		return err
+	}
+	errc := make(chan error, 2)
+	go func() {
+		log.Printf("serving gRPC on %s", lis.Addr())
+		errc <- gs.Serve(lis)
+	}()
+	var hs *http.Server
+	if c.HTTP != "" {
+		hs = &http.Server{Addr: c.HTTP, Handler: httpapi.NewHandler(s, httpOpts...)}
+		go func() {
+			log.Printf("serving HTTP on %s", c.HTTP)
+			if err := hs.ListenAndServe(); err != http.ErrServerClosed {
+				errc <- err
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		every(c.Tick.Duration, done, func() {
+			ts := time.Now().Add(-c.Tick.Duration)
+			for _, p := range merkleweave.Prefixes() {
+				if _, err := w.Advance(p, ts); err != nil {
+					log.Printf("advancing tree %x: %v", p, err)
+				}
+			}
+		})
+	}()
+
+	select {
+	case sig := <-stop:
+		log.Printf("received %s, shutting down", sig)
+	case err = <-errc:
+		log.Printf("serving: %v", err)
+	}
+	close(done)
+	if hs != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := hs.Shutdown(ctx); err != nil {
+			log.Printf("shutting down HTTP: %v", err)
+		}
+	}
+	stopped := make(chan struct{})
+	go func() {
+		gs.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		log.Printf("gRPC requests still pending after %s, stopping", shutdownTimeout)
+		gs.Stop()
+		<-stopped
+	}
+	wg.Wait()
+	if cerr := d.Close(); cerr != nil {
+		return cerr
+	}
+	log.Print("closed storage")
+	return err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/driver/filedriver"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "merkleweaved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"listen": ":9000", "data": "/var/lib/mw", "tick": "5s"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := loadConfig([]string{"-config", path, "-listen", ":9001"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Listen != ":9001" {
+		t.Errorf("expected flag to override file, got %q", c.Listen)
+	}
+	if c.Data != "/var/lib/mw" || c.Tick.Duration != 5*time.Second {
+		t.Errorf("expected settings from file, got %+v", c)
+	}
+
+	// Trees are not advanced by default.
+	if c, err := loadConfig(nil); err != nil || c.Tick.Duration != 0 {
+		t.Errorf("expected no tick by default, got %+v, %v", c, err)
+	}
+
+	if _, err := loadConfig([]string{"extra"}); err == nil {
+		t.Error("expected error for extra arguments")
+	}
+	if _, err := loadConfig([]string{"-tick", "-1s"}); err == nil {
+		t.Error("expected error for negative tick")
+	}
+	if err := ioutil.WriteFile(path, []byte(`{"tick": "-5s"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig([]string{"-config", path}); err == nil {
+		t.Error("expected error for negative tick in file")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "merkleweaved")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := defaultConfig()
+	c.Listen = "127.0.0.1:0"
+	c.Data = dir
+	c.Tick.Duration = 10 * time.Millisecond
+
+	stop := make(chan os.Signal, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		stop <- syscall.SIGTERM
+	}()
+	if err := run(c, stop); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ticks were stored.
+	d, err := filedriver.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if n, err := d.Len([]byte{0}); err != nil || n == 0 {
+		t.Errorf("expected stored sentinels, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,358 @@
+// Package filedriver provides a storage driver for a Merkle weave that stores
+// the entries of each tree in its own file in a directory.
+//
+// Each file is a sequence of records, each of which is a StorageEntry
+// preceded by its length as a uvarint. A partial record at the end of a file,
+// left by an interrupted write, is discarded when the directory is opened.
+//
+// Writes are buffered until FlushTree or Flush, which write and sync only the
+// files with pending writes, so that a Merkle weave can flush the trees of
+// each entry after storing it.
+package filedriver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/vsekhar/merkleweave/driver"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/storagepb"
+	"google.golang.org/protobuf/proto"
+)
+
+const ext = ".log"
+
+// extent is the location of a record in a file.
+type extent struct {
+	off int64
+	n   int
+}
+
+// log is the file of a tree.
+type log struct {
+	mu      sync.Mutex
+	f       *os.File
+	w       *bufio.Writer
+	extents []extent
+	size    int64 // including buffered writes
+	flushed int64
+	synced  int64
+}
+
+// Driver stores entries in files in a directory.
+type Driver struct {
+	dir      string
+	readOnly bool
+
+	mu   sync.Mutex
+	logs map[string]*log
+}
+
+var _ driver.Interface = (*Driver)(nil)
+
+// ErrReadOnly is returned when writing to a Driver opened with OpenReadOnly.
+var ErrReadOnly = errors.New("driver is read-only")
+
+// Open opens the directory dir, creating it if necessary.
+func Open(dir string) (*Driver, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return open(dir, false)
+}
+
+// OpenReadOnly opens the existing directory dir without modifying it, e.g. to
+// audit a backup. Partial records at the end of files are ignored rather than
+// discarded.
+func OpenReadOnly(dir string) (*Driver, error) {
+	return open(dir, true)
+}
+
+func open(dir string, readOnly bool) (*Driver, error) {
+	d := &Driver{dir: dir, readOnly: readOnly, logs: make(map[string]*log)}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		prefix, err := hex.DecodeString(strings.TrimSuffix(name, ext))
+		if err != nil {
+			continue
+		}
+		l, err := openLog(filepath.Join(dir, name), readOnly)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.logs[string(prefix)] = l
+	}
+	return d, nil
+}
+
+// openLog opens the file at path, reading the extents of its records.
+func openLog(path string, readOnly bool) (*log, error) {
+	flag := os.O_RDWR | os.O_CREATE
+	if readOnly {
+		flag = os.O_RDONLY
+	}
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l := &log{f: f}
+	r := bufio.NewReader(f)
+	for {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			break
+		}
+		hdr := int64(uvarintLen(n))
+		if _, err := r.Discard(int(n)); err != nil {
+			break
+		}
+		l.extents = append(l.extents, extent{off: l.size + hdr, n: int(n)})
+		l.size += hdr + int64(n)
+	}
+	l.flushed, l.synced = l.size, l.size
+	if readOnly {
+		return l, nil
+	}
+	// Discard any partial record.
+	if err := f.Truncate(l.size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(l.size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	l.w = bufio.NewWriter(f)
+	return l, nil
+}
+
+func uvarintLen(x uint64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(buf[:], x)
+}
+
+// log returns the log of the tree with prefix, creating it if create is true.
+func (d *Driver) log(prefix []byte, create bool) (*log, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if l, ok := d.logs[string(prefix)]; ok || !create {
+		return l, nil
+	}
+	l, err := openLog(filepath.Join(d.dir, hex.EncodeToString(prefix)+ext), false)
+	if err != nil {
+		return nil, err
+	}
+	d.logs[string(prefix)] = l
+	return l, nil
+}
+
+// Len returns the number of entries stored for the tree with prefix.
+func (d *Driver) Len(prefix []byte) (int64, error) {
+	l, err := d.log(prefix, false)
+	if err != nil || l == nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int64(len(l.extents)), nil
+}
+
+// Get returns the entry at index n of the tree with prefix.
+func (d *Driver) Get(prefix []byte, n int64) (*storagepb.StorageEntry, error) {
+	l, err := d.log(prefix, false)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, fmt.Errorf("entry %x:%d not found", prefix, n)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n < 0 || n >= int64(len(l.extents)) {
+		return nil, fmt.Errorf("entry %x:%d not found", prefix, n)
+	}
+	e := l.extents[n]
+	if e.off+int64(e.n) > l.flushed {
+		if err := l.flush(); err != nil {
+			return nil, err
+		}
+	}
+	b := make([]byte, e.n)
+	if _, err := l.f.ReadAt(b, e.off); err != nil {
+		return nil, err
+	}
+	r := &storagepb.StorageEntry{}
+	if err := proto.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("entry %x:%d: %v", prefix, n, err)
+	}
+	return r, nil
+}
+
+// WriteNext appends e to the file of the tree with prefix. The write is
+// buffered until the next call to Flush.
+func (d *Driver) WriteNext(prefix []byte, e *storagepb.StorageEntry) (int64, error) {
+	if d.readOnly {
+		return 0, ErrReadOnly
+	}
+	b, err := proto.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	l, err := d.log(prefix, true)
+	if err != nil {
+		return 0, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var hdr [binary.MaxVarintLen64]byte
+	h := binary.PutUvarint(hdr[:], uint64(len(b)))
+	if _, err := l.w.Write(hdr[:h]); err != nil {
+		return 0, err
+	}
+	if _, err := l.w.Write(b); err != nil {
+		return 0, err
+	}
+	l.extents = append(l.extents, extent{off: l.size + int64(h), n: len(b)})
+	l.size += int64(h + len(b))
+	return int64(len(l.extents) - 1), nil
+}
+
+// Truncate discards the records of the tree with prefix at index n and after,
+// whether buffered or written, and syncs the file.
+func (d *Driver) Truncate(prefix []byte, n int64) error {
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	l, err := d.log(prefix, false)
+	if err != nil {
+		return err
+	}
+	if l == nil && n == 0 {
+		return nil
+	}
+	if l == nil {
+		return fmt.Errorf("cannot truncate empty tree %x to %d", prefix, n)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n < 0 || n > int64(len(l.extents)) {
+		return fmt.Errorf("cannot truncate tree %x of %d entries to %d", prefix, len(l.extents), n)
+	}
+	var size int64
+	if n > 0 {
+		e := l.extents[n-1]
+		size = e.off + int64(e.n)
+	}
+	// Write buffered records before n; the rest of the buffer is discarded.
+	if size > l.flushed {
+		if err := l.flush(); err != nil {
+			return err
+		}
+	}
+	if err := l.f.Truncate(size); err != nil {
+		return err
+	}
+	if _, err := l.f.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	l.w.Reset(l.f)
+	l.extents = l.extents[:n]
+	l.size, l.flushed, l.synced = size, size, size
+	return nil
+}
+
+// flush writes buffered records to the file. It must be called with l.mu
+// held.
+func (l *log) flush() error {
+	if l.w == nil {
+		return nil
+	}
+	if err := l.w.Flush(); err != nil {
+		return err
+	}
+	l.flushed = l.size
+	return nil
+}
+
+// sync writes buffered records to the file and syncs it if it was written to
+// since it was last synced. It must be called with l.mu held.
+func (l *log) sync() error {
+	if l.w == nil || l.synced == l.size {
+		return nil
+	}
+	if err := l.flush(); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+	l.synced = l.size
+	return nil
+}
+
+// each calls f with each log locked in turn. d.mu is not held while f is
+// called, so that other logs can be used meanwhile.
+func (d *Driver) each(f func(l *log) error) error {
+	d.mu.Lock()
+	logs := make([]*log, 0, len(d.logs))
+	for _, l := range d.logs {
+		logs = append(logs, l)
+	}
+	d.mu.Unlock()
+	var first error
+	for _, l := range logs {
+		l.mu.Lock()
+		if err := f(l); err != nil && first == nil {
+			first = err
+		}
+		l.mu.Unlock()
+	}
+	return first
+}
+
+// FlushTree writes the buffered records of the tree with prefix to its file
+// and syncs the file if it was written to since it was last synced. Only the
+// log of the tree is locked.
+func (d *Driver) FlushTree(prefix []byte) error {
+	l, err := d.log(prefix, false)
+	if err != nil || l == nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sync()
+}
+
+// Flush writes buffered records to their files and syncs the files written to
+// since they were last synced.
+func (d *Driver) Flush() error {
+	return d.each(func(l *log) error { return l.sync() })
+}
+
+// Close flushes buffered records and closes all files.
+func (d *Driver) Close() error {
+	err := d.Flush()
+	if cerr := d.each(func(l *log) error { return l.f.Close() }); err == nil {
+		err = cerr
+	}
+	return err
+}
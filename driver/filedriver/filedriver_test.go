@@ -0,0 +1,172 @@
+package filedriver_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/driver/filedriver"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/storagepb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func entry(i int) *storagepb.StorageEntry {
+	return &storagepb.StorageEntry{
+		Timestamp:   timestamppb.Now(),
+		DataSha3256: []byte{byte(i), 1, 2, 3},
+		NodeSha3256: []byte{byte(i), 4, 5, 6},
+	}
+}
+
+func TestDriver(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filedriver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, err := filedriver.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := []byte{0xab}
+	var written []*storagepb.StorageEntry
+	for i := 0; i < 10; i++ {
+		e := entry(i)
+		n, err := d.WriteNext(p, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(i) {
+			t.Errorf("expected index %d, got %d", i, n)
+		}
+		written = append(written, e)
+	}
+	// Reads see buffered writes.
+	e, err := d.Get(p, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(e, written[9]) {
+		t.Errorf("expected %v, got %v", written[9], e)
+	}
+	if n, err := d.Len([]byte{0xcd}); err != nil || n != 0 {
+		t.Errorf("expected empty tree, got %d, %v", n, err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a torn write.
+	f, err := os.OpenFile(filepath.Join(dir, "ab.log"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{50, 1, 2})
+	f.Close()
+	info, err := os.Stat(filepath.Join(dir, "ab.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read-only opens ignore the torn write without discarding it.
+	ro, err := filedriver.OpenReadOnly(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ro.Len(p); err != nil || n != 10 {
+		t.Errorf("expected 10 entries, got %d, %v", n, err)
+	}
+	if _, err := ro.WriteNext(p, entry(10)); err != filedriver.ErrReadOnly {
+		t.Errorf("expected ErrReadOnly, got %v", err)
+	}
+	if err := ro.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if info2, err := os.Stat(filepath.Join(dir, "ab.log")); err != nil || info2.Size() != info.Size() {
+		t.Errorf("expected read-only open to leave file unchanged")
+	}
+
+	d, err = filedriver.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if n, err := d.Len(p); err != nil || n != 10 {
+		t.Fatalf("expected 10 entries, got %d, %v", n, err)
+	}
+	for i, w := range written {
+		e, err := d.Get(p, int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !proto.Equal(e, w) {
+			t.Errorf("entry %d: expected %v, got %v", i, w, e)
+		}
+	}
+	if n, err := d.WriteNext(p, entry(10)); err != nil || n != 10 {
+		t.Errorf("expected index 10, got %d, %v", n, err)
+	}
+	if _, err := d.Get(p, 11); err == nil {
+		t.Error("expected error reading past end")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filedriver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d, err := filedriver.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := []byte{0xab}
+	for i := 0; i < 3; i++ {
+		if _, err := d.WriteNext(p, entry(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	// Truncate a flushed and a buffered record.
+	if _, err := d.WriteNext(p, entry(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Truncate(p, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Truncate(p, 3); err == nil {
+		t.Error("expected error truncating past end")
+	}
+	if n, err := d.WriteNext(p, entry(4)); err != nil || n != 2 {
+		t.Errorf("expected index 2, got %d, %v", n, err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err = filedriver.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if n, err := d.Len(p); err != nil || n != 3 {
+		t.Fatalf("expected 3 entries, got %d, %v", n, err)
+	}
+	for i, w := range []*storagepb.StorageEntry{entry(0), entry(1), entry(4)} {
+		e, err := d.Get(p, int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(e.DataSha3256, w.DataSha3256) {
+			t.Errorf("entry %d: expected %x, got %x", i, w.DataSha3256, e.DataSha3256)
+		}
+	}
+}
@@ -1,11 +1,42 @@
 // Package driver specifies the interface for storage drivers of a Merkle
 // weave.
+//
+// Drivers store the entries of each tree of a Merkle weave in order, indexed
+// by the prefix of the tree and the index of the entry in the tree.
 package driver
 
-import "time"
+import "github.com/vsekhar/merkleweave/pkg/merkleweave/storagepb"
+
+// Get is the interface for reading entries from storage.
+type Get interface {
+	// Len returns the number of entries stored for the tree with prefix.
+	Len(prefix []byte) (int64, error)
+
+	// Get returns the entry at index n of the tree with prefix.
+	Get(prefix []byte, n int64) (*storagepb.StorageEntry, error)
+}
 
 // Interface is the interface a Merkle weave storage driver must satisfy.
 type Interface interface {
 	Get
-	WriteNext(data []byte) (node []byte, n int64, timestamp time.Time, err error)
+
+	// WriteNext stores e as the next entry of the tree with prefix and
+	// returns its index.
+	WriteNext(prefix []byte, e *storagepb.StorageEntry) (n int64, err error)
+
+	// Truncate discards the entries of the tree with prefix at index n and
+	// after, including pending writes, and commits the change to stable
+	// storage. It is used to undo the writes of an entry that could not be
+	// stored in all of its trees.
+	Truncate(prefix []byte, n int64) error
+
+	// FlushTree commits pending writes of the tree with prefix to stable
+	// storage.
+	FlushTree(prefix []byte) error
+
+	// Flush commits pending writes of all trees to stable storage.
+	Flush() error
+
+	// Close flushes pending writes and releases resources.
+	Close() error
 }
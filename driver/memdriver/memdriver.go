@@ -0,0 +1,72 @@
+// Package memdriver provides an in-memory storage driver for a Merkle weave.
+package memdriver
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/vsekhar/merkleweave/driver"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/storagepb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Driver stores entries in memory.
+type Driver struct {
+	mu      sync.Mutex
+	entries map[string][]*storagepb.StorageEntry
+}
+
+var _ driver.Interface = (*Driver)(nil)
+
+// New returns a new empty Driver.
+func New() *Driver {
+	return &Driver{entries: make(map[string][]*storagepb.StorageEntry)}
+}
+
+// Len returns the number of entries stored for the tree with prefix.
+func (d *Driver) Len(prefix []byte) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return int64(len(d.entries[string(prefix)])), nil
+}
+
+// Get returns the entry at index n of the tree with prefix.
+func (d *Driver) Get(prefix []byte, n int64) (*storagepb.StorageEntry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	es := d.entries[string(prefix)]
+	if n < 0 || n >= int64(len(es)) {
+		return nil, fmt.Errorf("entry %x:%d not found", prefix, n)
+	}
+	return proto.Clone(es[n]).(*storagepb.StorageEntry), nil
+}
+
+// WriteNext stores e as the next entry of the tree with prefix.
+func (d *Driver) WriteNext(prefix []byte, e *storagepb.StorageEntry) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	k := string(prefix)
+	d.entries[k] = append(d.entries[k], proto.Clone(e).(*storagepb.StorageEntry))
+	return int64(len(d.entries[k]) - 1), nil
+}
+
+// Truncate discards the entries of the tree with prefix at index n and after.
+func (d *Driver) Truncate(prefix []byte, n int64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	k := string(prefix)
+	if n < 0 || n > int64(len(d.entries[k])) {
+		return fmt.Errorf("cannot truncate tree %x of %d entries to %d", prefix, len(d.entries[k]), n)
+	}
+	d.entries[k] = d.entries[k][:n]
+	return nil
+}
+
+// FlushTree does nothing.
+func (d *Driver) FlushTree(prefix []byte) error { return nil }
+
+// Flush does nothing.
+func (d *Driver) Flush() error { return nil }
+
+// Close does nothing.
+func (d *Driver) Close() error { return nil }
@@ -0,0 +1,7 @@
+package merkletree
+
+// NewLegacyForTest returns a new empty MerkleTree using legacy algorithm a,
+// which NewWithAlgorithm refuses, to check the legacy scheme.
+func NewLegacyForTest(a Algorithm) *MerkleTree {
+	return newTree(a)
+}
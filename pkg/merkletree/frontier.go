@@ -0,0 +1,72 @@
+package merkletree
+
+import (
+	"fmt"
+)
+
+// Frontier is an append-only Merkle tree that keeps only the hashes of its
+// peaks.
+//
+// A Frontier produces the same summaries as a MerkleTree with the same
+// entries, using memory logarithmic in its length. It cannot return entries
+// or prove inclusion or consistency; those need the nodes of a MerkleTree.
+type Frontier struct {
+	alg   Algorithm
+	n     int
+	peaks [][HashLength]byte // in position order
+}
+
+// NewFrontier returns a Frontier that continues the tree summarized by p. It
+// returns an error if p uses a legacy algorithm.
+func NewFrontier(p PeakSummary) (*Frontier, error) {
+	if err := checkNew(p.Alg); err != nil {
+		return nil, err
+	}
+	if _, err := p.Summary(); err != nil {
+		return nil, err
+	}
+	return &Frontier{
+		alg:   p.Alg,
+		n:     p.N,
+		peaks: append([][HashLength]byte(nil), p.Peaks...),
+	}, nil
+}
+
+// Algorithm returns the hash algorithm of the Frontier.
+func (f *Frontier) Algorithm() Algorithm {
+	return f.alg
+}
+
+// Len returns the number of entries in the Frontier.
+func (f *Frontier) Len() int {
+	return f.n
+}
+
+// Append adds an entry to the Frontier.
+func (f *Frontier) Append(b []byte) {
+	var node [HashLength]byte
+	if height(f.n) > 0 {
+		// The children of a node that is not a leaf are the last two peaks,
+		// which it replaces.
+		k := len(f.peaks)
+		if k < 2 {
+			panic(fmt.Sprintf("frontier of size %d has %d peaks", f.n, k))
+		}
+		node = hashNode(f.alg, &f.peaks[k-2], &f.peaks[k-1], f.alg.commitment(b))
+		f.peaks = f.peaks[:k-2]
+	} else {
+		node = hashNode(f.alg, nil, nil, f.alg.commitment(b))
+	}
+	f.peaks = append(f.peaks, node)
+	f.n++
+}
+
+// Summary returns the length and hash of the Frontier.
+func (f *Frontier) Summary() Summary {
+	return Summary{N: f.n, Summary: bag(f.alg, f.n, f.peaks), Alg: f.alg}
+}
+
+// PeakSummary returns the peak summary of the Frontier.
+func (f *Frontier) PeakSummary() PeakSummary {
+	return PeakSummary{N: f.n, Peaks: append([][HashLength]byte(nil), f.peaks...), Alg: f.alg}
+}
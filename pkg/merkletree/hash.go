@@ -0,0 +1,221 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Algorithm identifies the hash function and the hashing scheme of a Merkle
+// tree.
+//
+// Hashes are stored in arrays of HashLength bytes regardless of algorithm.
+// Hashes of algorithms with shorter outputs occupy the first Size bytes, and
+// the rest are zero.
+//
+// The hashing scheme separates the domains of leaves, interior nodes and
+// summaries with a tag byte, and prefixes data with its length as a big-endian
+// uint64:
+//
+//	leaf    = hash(0x00 || len(data) || data)
+//	node    = hash(0x01 || left || right || len(data) || data)
+//	summary = hash(0x02 || size || peaks...)
+//
+// The legacy variant of each algorithm (see Legacy) hashes without tags or
+// lengths, as Merkle trees did before the scheme was versioned. It is
+// supported to verify summaries and proofs made before then, and should not be
+// used for new trees.
+//
+// The data hashed variant of each algorithm (see DataHashed) hashes the data
+// hash of each entry into its node instead of its data:
+//
+//	data = hash(0x03 || len(data) || data)
+//
+// Trees using it can prove inclusion and consistency without keeping data.
+type Algorithm uint8
+
+const (
+	// SHAKE256 is SHAKE256 with HashLength bytes of output, the default.
+	SHAKE256 Algorithm = iota
+	SHA3_256
+	SHA256
+	SHA512_256
+)
+
+// Variant flags.
+const (
+	legacy     Algorithm = 0x80
+	dataHashed Algorithm = 0x40
+)
+
+// Domain separation tags.
+const (
+	leafTag    = 0x00
+	nodeTag    = 0x01
+	summaryTag = 0x02
+	dataTag    = 0x03
+)
+
+// Legacy returns the legacy variant of a.
+func (a Algorithm) Legacy() Algorithm {
+	return a | legacy
+}
+
+// IsLegacy returns true if a is the legacy variant of an algorithm.
+func (a Algorithm) IsLegacy() bool {
+	return a&legacy != 0
+}
+
+// DataHashed returns the data hashed variant of a.
+func (a Algorithm) DataHashed() Algorithm {
+	return a | dataHashed
+}
+
+// IsDataHashed returns true if a is the data hashed variant of an algorithm.
+func (a Algorithm) IsDataHashed() bool {
+	return a&dataHashed != 0
+}
+
+// function returns the algorithm identifying only the hash function of a.
+func (a Algorithm) function() Algorithm {
+	return a &^ (legacy | dataHashed)
+}
+
+// Size returns the number of bytes of hashes of a.
+func (a Algorithm) Size() int {
+	switch a.function() {
+	case SHAKE256:
+		return HashLength
+	default:
+		return 32
+	}
+}
+
+func (a Algorithm) String() string {
+	var s string
+	switch a.function() {
+	case SHAKE256:
+		s = "SHAKE256"
+	case SHA3_256:
+		s = "SHA3-256"
+	case SHA256:
+		s = "SHA-256"
+	case SHA512_256:
+		s = "SHA-512/256"
+	default:
+		return fmt.Sprintf("Algorithm(%d)", a)
+	}
+	if a.IsLegacy() {
+		s += " (legacy)"
+	}
+	if a.IsDataHashed() {
+		s += " (data hashed)"
+	}
+	return s
+}
+
+// Valid returns true if a is a known algorithm.
+func (a Algorithm) Valid() bool {
+	return a.function() <= SHA512_256
+}
+
+func (a Algorithm) hash() hash.Hash {
+	switch a.function() {
+	case SHA3_256:
+		return sha3.New256()
+	case SHA256:
+		return sha256.New()
+	case SHA512_256:
+		return sha512.New512_256()
+	default:
+		panic(fmt.Sprintf("unknown hash algorithm %s", a))
+	}
+}
+
+// sum returns the hash of the concatenation of parts.
+func (a Algorithm) sum(parts ...[]byte) (h [HashLength]byte) {
+	if a.function() == SHAKE256 {
+		shaker := sha3.NewShake256()
+		for _, p := range parts {
+			if _, err := shaker.Write(p); err != nil {
+				panic(err)
+			}
+		}
+		if _, err := shaker.Read(h[:]); err != nil {
+			panic(err)
+		}
+		return h
+	}
+	hh := a.hash()
+	for _, p := range parts {
+		hh.Write(p)
+	}
+	copy(h[:], hh.Sum(nil))
+	return h
+}
+
+// DataHash returns the data hash of data using a, of Size bytes.
+func (a Algorithm) DataHash(data []byte) []byte {
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(data)))
+	h := a.sum([]byte{dataTag}, n[:], data)
+	return h[:a.Size()]
+}
+
+// commitment returns what nodes using a hash of an entry with data: its data
+// hash if a is data hashed, otherwise data itself.
+func (a Algorithm) commitment(data []byte) []byte {
+	if a.IsDataHashed() {
+		return a.DataHash(data)
+	}
+	return data
+}
+
+// hashNode returns the hash using a of a node with the given children and
+// data, which must already be the commitment to the data of the entry. Leaves
+// have nil children.
+func hashNode(a Algorithm, left, right *[HashLength]byte, data []byte) [HashLength]byte {
+	if a.IsLegacy() {
+		if left != nil {
+			return a.sum(left[:a.Size()], right[:a.Size()], data)
+		}
+		return a.sum(data)
+	}
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(data)))
+	if left != nil {
+		return a.sum([]byte{nodeTag}, left[:a.Size()], right[:a.Size()], n[:], data)
+	}
+	return a.sum([]byte{leafTag}, n[:], data)
+}
+
+// bag returns the summary hash using a of a tree of size n with the given
+// peaks.
+func bag(a Algorithm, n int, peaks [][HashLength]byte) [HashLength]byte {
+	parts := make([][]byte, 0, len(peaks)+2)
+	if !a.IsLegacy() {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(n))
+		parts = append(parts, []byte{summaryTag}, size[:])
+	}
+	for i := range peaks {
+		parts = append(parts, peaks[i][:a.Size()])
+	}
+	return a.sum(parts...)
+}
+
+// checkAlgorithm returns an error if a proof using algorithm p cannot be
+// verified against a summary using algorithm s.
+func checkAlgorithm(s, p Algorithm) error {
+	if !s.Valid() {
+		return fmt.Errorf("%w: unknown hash algorithm %s", ErrInvalidProof, s)
+	}
+	if p != s {
+		return fmt.Errorf("%w: proof using %s, summary using %s", ErrInvalidProof, p, s)
+	}
+	return nil
+}
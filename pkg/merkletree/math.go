@@ -31,6 +31,17 @@ func children(pos, h int) []int {
 	return []int{leftChild(pos, h), rightChild(pos, h)}
 }
 
+// LeftChild returns the index of the left child of the node at pos, or -1 if
+// the node is a leaf. The right child of a node that is not a leaf is at
+// pos-1.
+func LeftChild(pos int) int {
+	h := height(pos)
+	if h == 0 {
+		return -1
+	}
+	return leftChild(pos, h)
+}
+
 // peaks returns the index of peaks in an MMR of size n.
 //
 // Source: https://github.com/mimblewimble/grin/blob/78220febeda94595159ece675e77e26986a3c11d/core/src/core/pmmr/pmmr.rs#L402
@@ -44,6 +55,11 @@ func peaks(n int) (peaks []int) {
 	return peaks
 }
 
+// PeakPositions returns the positions of the peaks of an MMR of size n.
+func PeakPositions(n int) []int {
+	return peaks(n)
+}
+
 // height returns the height (counting from 0) of the node at index n.
 func height(pos int) int {
 	switch {
@@ -67,56 +83,29 @@ func height(pos int) int {
 	}
 }
 
-type pathEntry struct {
-	pre  []int
-	post []int
-}
-
-// path returns a sequence of nodes whose hashes comprise a proof of the node
-// at pos.
-func path(pos int) (p []pathEntry) {
+// parent returns the index of the parent of the node at pos, as well as the
+// index of its sibling.
+func parent(pos int) (parent, sibling int) {
 	h := height(pos)
-	cs := children(pos, h)
-	if cs != nil {
-		cpe := pathEntry{pre: make([]int, 0, len(cs))}
-		for _, c := range cs {
-			cpe.pre = append(cpe.pre, c)
-		}
-		p = append(p, cpe)
+	if height(pos+1) > h {
+		// pos is a right child
+		return pos + 1, pos - pow2(h+1) + 1
 	}
-	panic("not fully implemented")
+	sibling = pos + pow2(h+1) - 1
+	return sibling + 1, sibling
 }
 
-// proof returns a set of node indexes needed to prove the inclusion of a
-// summary of MMR of size from to a summary of MMR of size to.
-func proof(from, to int) (nodes []int) {
-	if to < from {
-		panic("reverse proof requested")
-	}
-	fromPeaks := peaks(from)
-	toPeaks := peaks(to)
-
-	// skip matching peaks
-	for len(fromPeaks) != 0 && len(toPeaks) != 0 {
-		if fromPeaks[0] == toPeaks[0] {
-			fromPeaks, toPeaks = fromPeaks[1:], toPeaks[1:]
+// path returns the index of siblings of nodes on the path from pos up to its
+// peak in an MMR of size n.
+func path(pos, n int) (siblings []int) {
+	for {
+		p, s := parent(pos)
+		if p >= n {
+			return siblings
 		}
+		siblings = append(siblings, s)
+		pos = p
 	}
-
-	if len(fromPeaks) == 0 {
-		// nothing to prove, that is fromPeaks is a subset of toPeaks
-		return
-	}
-
-	// remaining fromPeaks should be fully contained under the first remaining
-	// toPeak.
-	toPeak := toPeaks[0]
-	// c := toPeak
-	for fromPeaks[0] != toPeak {
-		// walk down left children until we find one, then walk down right children
-		// i think...
-	}
-	return
 }
 
 func intSliceEqual(a, b []int) bool {
@@ -87,6 +87,25 @@ func TestLeftChild(t *testing.T) {
 	}
 }
 
+func TestExportedLeftChild(t *testing.T) {
+	// pos, left child
+	table := [][]int{
+		{0, -1},
+		{1, -1},
+		{2, 0},
+		{3, -1},
+		{5, 3},
+		{6, 2},
+		{14, 6},
+	}
+	for _, vals := range table {
+		pos, lc := vals[0], vals[1]
+		if out := LeftChild(pos); out != lc {
+			t.Errorf("LeftChild(%d) is %d, expected %d", pos, out, lc)
+		}
+	}
+}
+
 func TestChildren(t *testing.T) {
 	// pos, height, children...
 	table := [][]int{
@@ -112,22 +131,50 @@ func TestChildren(t *testing.T) {
 
 }
 
-func TestPath(t *testing.T) {
-	// pos, pathEntry's...
-	_ = map[int][]pathEntry{
-		0: {},
-		1: {{}, {}},
+func TestParent(t *testing.T) {
+	// pos, parent, sibling
+	table := [][]int{
+		{0, 2, 1},
+		{1, 2, 0},
+		{2, 6, 5},
+		{5, 6, 2},
+		{6, 14, 13},
+		{9, 13, 12},
+		{13, 14, 6},
+		{14, 30, 29},
+	}
+	for _, vals := range table {
+		pos, p, s := vals[0], vals[1], vals[2]
+		if op, os := parent(pos); op != p || os != s {
+			t.Errorf("parent(%d): (%d, %d), expected (%d, %d)", pos, op, os, p, s)
+		}
 	}
-	// compare arrays of pathEntry's: p, expected
 }
 
-func TestProof(t *testing.T) {
-	t.Errorf("%v", proof(3, 19))
+func TestPath(t *testing.T) {
+	// pos, size, siblings...
+	table := [][]int{
+		{0, 1},
+		{0, 3, 1},
+		{0, 15, 1, 5, 13},
+		{3, 15, 4, 2, 13},
+		{14, 15},
+		{7, 10, 8},
+		{6, 10},
+	}
+	for _, vals := range table {
+		pos, n, expected := vals[0], vals[1], vals[2:]
+		if out := path(pos, n); !intSliceEqual(expected, out) {
+			t.Errorf("path(%d, %d): expected '%v', got '%v'", pos, n, expected, out)
+		}
+	}
 }
 
 func TestSize(t *testing.T) {
 	var i uint64
-	t.Errorf("%d", binary.Size(i))
+	if s := binary.Size(i); s != 8 {
+		t.Errorf("binary.Size(uint64): %d, expected 8", s)
+	}
 }
 
 type testcase struct {
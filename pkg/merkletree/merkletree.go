@@ -0,0 +1,217 @@
+// Package merkletree provides a simple Merkle tree implementation.
+//
+// Under the hood it uses a Merkle Mountain Range (MMR).
+package merkletree
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+)
+
+// HashLength is the number of bytes to read from the Shake hash, and the
+// maximum number of bytes of a hash of any Algorithm.
+const HashLength = 64
+
+// MerkleTree is a simple Merkle Tree data structure.
+type MerkleTree struct {
+	alg      Algorithm
+	hashOnly bool
+	data     [][]byte           // from users, or their data hashes if hashOnly or redacted
+	nodes    [][HashLength]byte // hashes of data and children
+	redacted map[int]bool
+}
+
+// New returns a new empty MerkleTree using SHAKE256.
+//
+// SHAKE256 is not data hashed, so entries of the MerkleTree cannot be redacted
+// (see Redact). It stays the default so that summaries of existing trees do
+// not change.
+func New() *MerkleTree {
+	return newTree(SHAKE256)
+}
+
+// NewWithAlgorithm returns a new empty MerkleTree using hash algorithm a. It
+// returns an error if a is not valid or is a legacy algorithm, which can only
+// be used to verify summaries and proofs.
+func NewWithAlgorithm(a Algorithm) (*MerkleTree, error) {
+	if err := checkNew(a); err != nil {
+		return nil, err
+	}
+	return newTree(a), nil
+}
+
+// checkNew returns an error if new trees cannot use a.
+func checkNew(a Algorithm) error {
+	if !a.Valid() {
+		return fmt.Errorf("unknown hash algorithm %s", a)
+	}
+	if a.IsLegacy() {
+		return fmt.Errorf("legacy hash algorithm %s can only be used for verification", a)
+	}
+	return nil
+}
+
+func newTree(a Algorithm) *MerkleTree {
+	return &MerkleTree{
+		alg:   a,
+		data:  make([][]byte, 0),
+		nodes: make([][HashLength]byte, 0),
+	}
+}
+
+// NewHashOnly returns a new empty MerkleTree using the data hashed variant of
+// hash algorithm a that keeps only the data hash of each entry. It returns an
+// error if a is not valid or is a legacy algorithm.
+//
+// Its summaries and proofs are those of a MerkleTree using the same algorithm
+// that keeps data, but At returns data hashes.
+func NewHashOnly(a Algorithm) (*MerkleTree, error) {
+	m, err := NewWithAlgorithm(a.DataHashed())
+	if err != nil {
+		return nil, err
+	}
+	m.hashOnly = true
+	return m, nil
+}
+
+// HashOnly returns true if the MerkleTree keeps only data hashes.
+func (m *MerkleTree) HashOnly() bool {
+	return m.hashOnly
+}
+
+// Algorithm returns the hash algorithm of the MerkleTree.
+func (m *MerkleTree) Algorithm() Algorithm {
+	return m.alg
+}
+
+// Len returns the number of entries in the MerkleTree.
+func (m *MerkleTree) Len() int {
+	return len(m.data)
+}
+
+// At returns the entry at pos in the MerkleTree, or its data hash if the
+// MerkleTree is hash only or the entry was redacted. If pos does not exist in
+// the MerkleTree, At panics.
+func (m *MerkleTree) At(pos int) []byte {
+	return m.data[pos]
+}
+
+// commitment returns what the node at pos hashes of its entry.
+func (m *MerkleTree) commitment(pos int) []byte {
+	if m.hashOnly || m.redacted[pos] {
+		return m.data[pos]
+	}
+	return m.alg.commitment(m.data[pos])
+}
+
+// Append adds an entry to the MerkleTree.
+func (m *MerkleTree) Append(b []byte) {
+	c := m.alg.commitment(b)
+	if m.hashOnly {
+		b = c
+	}
+	m.append(b, c)
+}
+
+// append stores entry b, whose node hashes c.
+func (m *MerkleTree) append(b, c []byte) {
+	pos := len(m.data)
+	h := height(pos)
+
+	// Hash left child and write child (if not a leaf).
+	var left, right *[HashLength]byte
+	if cs := children(pos, h); cs != nil {
+		left, right = &m.nodes[cs[0]], &m.nodes[cs[1]]
+	}
+	node := hashNode(m.alg, left, right, c)
+
+	// Store.
+	m.data = append(m.data, b)
+	m.nodes = append(m.nodes, node)
+}
+
+// Summary is a summary of a tree.
+type Summary struct {
+	N       int
+	Summary [HashLength]byte
+	Alg     Algorithm
+}
+
+// Hash returns the summary hash, truncated to the size of its algorithm.
+func (s Summary) Hash() []byte {
+	return s.Summary[:s.Alg.Size()]
+}
+
+// Equals returns true if the summaries are equal.
+func (s Summary) Equals(s2 Summary) bool {
+	if s.N != s2.N || s.Alg != s2.Alg {
+		return false
+	}
+	if bytes.Compare(s.Summary[:], s2.Summary[:]) != 0 {
+		return false
+	}
+	return true
+}
+
+// String returns a string representation of a Summary with its size, its
+// base64-encoded hash and its algorithm.
+func (s Summary) String() string {
+	hash := base64.RawURLEncoding.EncodeToString(s.Hash())
+	return fmt.Sprintf("%d:%s (%s)", s.N, hash, s.Alg)
+}
+
+// Summary returns the length and hash of the Merkle tree.
+func (m *MerkleTree) Summary() Summary {
+	return m.SummaryAt(m.Len())
+}
+
+// SummaryAt returns the summary of the Merkle tree when it had n entries. If n
+// is larger than the length of the MerkleTree, SummaryAt panics.
+func (m *MerkleTree) SummaryAt(n int) Summary {
+	return Summary{N: n, Summary: bag(m.alg, n, m.Peaks(n)), Alg: m.alg}
+}
+
+// Peaks returns the hashes of the peaks of the Merkle tree when it had n
+// entries. If n is larger than the length of the MerkleTree, Peaks panics.
+func (m *MerkleTree) Peaks(n int) [][HashLength]byte {
+	if n > m.Len() {
+		panic(fmt.Sprintf("size %d exceeds length %d", n, m.Len()))
+	}
+	ps := peaks(n)
+	r := make([][HashLength]byte, len(ps))
+	for i, pos := range ps {
+		r[i] = m.nodes[pos]
+	}
+	return r
+}
+
+// NewSummary returns the summary of a Merkle tree using SHAKE256 of size n with
+// the given peaks.
+func NewSummary(n int, peakHashes [][HashLength]byte) (Summary, error) {
+	return SHAKE256.NewSummary(n, peakHashes)
+}
+
+// NewSummary returns the summary of a Merkle tree using a of size n with the
+// given peaks.
+func (a Algorithm) NewSummary(n int, peakHashes [][HashLength]byte) (Summary, error) {
+	if !a.Valid() {
+		return Summary{}, fmt.Errorf("unknown hash algorithm %s", a)
+	}
+	if n < 0 {
+		return Summary{}, fmt.Errorf("negative size %d", n)
+	}
+	if len(peakHashes) != len(peaks(n)) {
+		return Summary{}, fmt.Errorf("expected %d peaks for size %d, got %d", len(peaks(n)), n, len(peakHashes))
+	}
+	return Summary{N: n, Summary: bag(a, n, peakHashes), Alg: a}, nil
+}
+
+// EmptySummary returns the summary of an empty Merkle tree using a.
+func (a Algorithm) EmptySummary() Summary {
+	return Summary{N: 0, Summary: bag(a, 0, nil), Alg: a}
+}
+
+// EmptyTreeSummary is the fixed summary of an empty Merkle tree using
+// SHAKE256.
+var EmptyTreeSummary Summary = SHAKE256.EmptySummary()
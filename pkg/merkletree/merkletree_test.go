@@ -0,0 +1,430 @@
+package merkletree_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/rfc6962"
+)
+
+// Keep this in sync with merkletree.hashLength (though we don't want to export
+// that one and we don't want to do in-package tests here).
+const hashLength = 64
+
+// newTree returns a new empty MerkleTree using a, including legacy algorithms.
+func newTree(t *testing.T, a merkletree.Algorithm) *merkletree.MerkleTree {
+	if a.IsLegacy() {
+		return merkletree.NewLegacyForTest(a)
+	}
+	m, err := merkletree.NewWithAlgorithm(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func newHashOnly(t *testing.T, a merkletree.Algorithm) *merkletree.MerkleTree {
+	m, err := merkletree.NewHashOnly(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestAppend(t *testing.T) {
+	m := merkletree.New()
+	if m.Len() != 0 {
+		t.Fatal("expected empty merkletree")
+	}
+	b1 := []byte{1, 2, 3}
+	m.Append(b1)
+	if m.Len() != 1 {
+		t.Error("expected len 1")
+	}
+}
+
+func TestSummary(t *testing.T) {
+	m := merkletree.New()
+	if !m.Summary().Equals(merkletree.EmptyTreeSummary) {
+		t.Errorf("unexpected empty tree summary")
+	}
+	b1 := []byte{1, 2, 3}
+	m.Append(b1)
+	s := m.Summary()
+	good1 := merkletree.Summary{
+		N:       1,
+		Summary: [merkletree.HashLength]byte{0x2a, 0x46, 0xc7, 0x9f, 0x4b, 0xd1, 0xe1, 0xdb, 0xe0, 0x86, 0xed, 0x9f, 0xbb, 0x8c, 0x6d, 0xb6, 0x38, 0x95, 0x15, 0xdd, 0x28, 0x7e, 0x48, 0xa8, 0xa7, 0x76, 0xc1, 0xab, 0x4f, 0x5b, 0x5b, 0x38, 0x9e, 0x55, 0xf6, 0xd, 0xd7, 0x4, 0x58, 0x86, 0xf0, 0x25, 0x3d, 0x5a, 0xe8, 0xa0, 0x13, 0xa5, 0x91, 0x14, 0x2c, 0x9f, 0x3, 0x83, 0x51, 0xce, 0x60, 0x44, 0xe2, 0x70, 0xc5, 0xc5, 0xb9, 0xa7},
+	}
+	if !s.Equals(good1) {
+		t.Errorf("unexpected summary %#v", s)
+	}
+
+	times := 100
+	for i := 0; i < times; i++ {
+		m.Append(b1)
+	}
+	s = m.Summary()
+	good101 := merkletree.Summary{
+		N:       101,
+		Summary: [merkletree.HashLength]byte{0x83, 0x25, 0x66, 0x8c, 0xd1, 0xd, 0x4b, 0x7e, 0x5f, 0x7f, 0x81, 0xdb, 0xd, 0x7a, 0x85, 0x82, 0x39, 0x8d, 0x5d, 0xc9, 0x72, 0xcb, 0x4e, 0xb4, 0xe1, 0xdd, 0x8e, 0xa6, 0x1a, 0xd2, 0xb3, 0xbd, 0x4c, 0xe7, 0x90, 0xac, 0x4b, 0x41, 0x33, 0x81, 0x3d, 0x1e, 0xa5, 0xf7, 0x9c, 0x60, 0x21, 0x55, 0x10, 0xd6, 0x9f, 0x4a, 0x49, 0xd5, 0x98, 0x6, 0x25, 0x5d, 0xa, 0x9c, 0x34, 0xb2, 0x66, 0xd4},
+	}
+	if !s.Equals(good101) {
+		t.Errorf("unexpected summary %#v", s)
+	}
+}
+
+func TestSummaryString(t *testing.T) {
+	m := newTree(t, merkletree.SHA256)
+	m.Append([]byte{1, 2, 3})
+	s := m.Summary()
+	want := fmt.Sprintf("1:%s (SHA-256)", base64.RawURLEncoding.EncodeToString(s.Summary[:32]))
+	if got := s.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+// TestLegacySummary checks summaries of the legacy SHAKE256 scheme, which
+// hashed nodes without domain separation.
+func TestLegacySummary(t *testing.T) {
+	a := merkletree.SHAKE256.Legacy()
+	m := newTree(t, a)
+	empty := [merkletree.HashLength]byte{0x46, 0xb9, 0xdd, 0x2b, 0xb, 0xa8, 0x8d, 0x13, 0x23, 0x3b, 0x3f, 0xeb, 0x74, 0x3e, 0xeb, 0x24, 0x3f, 0xcd, 0x52, 0xea, 0x62, 0xb8, 0x1b, 0x82, 0xb5, 0xc, 0x27, 0x64, 0x6e, 0xd5, 0x76, 0x2f, 0xd7, 0x5d, 0xc4, 0xdd, 0xd8, 0xc0, 0xf2, 0x0, 0xcb, 0x5, 0x1, 0x9d, 0x67, 0xb5, 0x92, 0xf6, 0xfc, 0x82, 0x1c, 0x49, 0x47, 0x9a, 0xb4, 0x86, 0x40, 0x29, 0x2e, 0xac, 0xb3, 0xb7, 0xc4, 0xbe}
+	if !m.Summary().Equals(merkletree.Summary{N: 0, Summary: empty, Alg: a}) || !a.EmptySummary().Equals(m.Summary()) {
+		t.Errorf("unexpected empty tree summary")
+	}
+	b1 := []byte{1, 2, 3}
+	m.Append(b1)
+	s := m.Summary()
+	good1 := merkletree.Summary{
+		N:       1,
+		Summary: [merkletree.HashLength]byte{0xc2, 0x2c, 0x63, 0xf2, 0x54, 0x8b, 0x15, 0xd9, 0xa7, 0x6, 0x16, 0xc6, 0x94, 0x9c, 0x58, 0x68, 0x28, 0x8d, 0xd5, 0x2c, 0xc, 0x36, 0x65, 0x7e, 0x1b, 0xb8, 0x77, 0x83, 0x76, 0x28, 0xc7, 0xa6, 0x55, 0xf2, 0xf1, 0x47, 0x96, 0x4, 0x65, 0xeb, 0x5a, 0xdf, 0xd7, 0x2f, 0xf6, 0x5d, 0x5c, 0x7b, 0x60, 0xf4, 0xd8, 0x81, 0x26, 0x90, 0x68, 0xe7, 0x35, 0xb8, 0x9, 0x38, 0xf8, 0xfe, 0xdb, 0xe0},
+		Alg:     a,
+	}
+	if !s.Equals(good1) {
+		t.Errorf("unexpected summary %#v", s)
+	}
+
+	times := 100
+	for i := 0; i < times; i++ {
+		m.Append(b1)
+	}
+	s = m.Summary()
+	good101 := merkletree.Summary{
+		N:       101,
+		Summary: [merkletree.HashLength]byte{0x7d, 0xe2, 0x71, 0xc2, 0x92, 0xec, 0xb7, 0x86, 0xe1, 0xfb, 0x8a, 0x6b, 0x10, 0xc6, 0xbe, 0x5d, 0xcc, 0xba, 0x47, 0xa4, 0xdd, 0x93, 0xdc, 0x3b, 0xb1, 0xb9, 0xac, 0x7a, 0xaf, 0xa0, 0xa2, 0xac, 0xef, 0x27, 0xfd, 0x4f, 0x92, 0x61, 0x39, 0x12, 0x36, 0x9d, 0xbc, 0xe0, 0xf5, 0x2f, 0x84, 0xab, 0x63, 0x4e, 0x27, 0x63, 0x23, 0xcc, 0xef, 0x2c, 0x96, 0x31, 0x19, 0x19, 0x92, 0xe9, 0x94, 0x5b},
+		Alg:     a,
+	}
+	if !s.Equals(good101) {
+		t.Errorf("unexpected summary %#v", s)
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	m := merkletree.New()
+	for i := 0; i < 20; i++ {
+		s, err := merkletree.NewSummary(m.Len(), m.Peaks(m.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.Equals(m.Summary()) {
+			t.Errorf("NewSummary(%d): expected %s, got %s", m.Len(), m.Summary(), s)
+		}
+		m.Append([]byte{byte(i)})
+	}
+	if _, err := merkletree.NewSummary(3, nil); err == nil {
+		t.Error("expected error for missing peaks")
+	}
+}
+
+func TestPeakSummary(t *testing.T) {
+	m := merkletree.New()
+	for i := 0; i < 20; i++ {
+		p := m.PeakSummary()
+		if len(p.Peaks) != len(p.Positions()) {
+			t.Fatalf("size %d: %d peaks at %d positions", p.N, len(p.Peaks), len(p.Positions()))
+		}
+		s, err := p.Summary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.Equals(m.Summary()) {
+			t.Errorf("size %d: expected %s, got %s", p.N, m.Summary(), s)
+		}
+		if _, err := s.WithPeaks(p.Peaks); err != nil {
+			t.Errorf("size %d: %v", p.N, err)
+		}
+		if len(p.Peaks) > 0 {
+			bad := append([][merkletree.HashLength]byte(nil), p.Peaks...)
+			bad[0][0]++
+			if _, err := s.WithPeaks(bad); err == nil {
+				t.Errorf("size %d: expected error for bad peaks", p.N)
+			}
+		}
+		m.Append([]byte{byte(i)})
+	}
+	if _, err := merkletree.SHAKE256.NewPeakSummary(3, nil); err == nil {
+		t.Error("expected error for missing peaks")
+	}
+}
+
+func TestFrontier(t *testing.T) {
+	for _, a := range algorithms {
+		m := newTree(t, a)
+		f, err := merkletree.NewFrontier(merkletree.PeakSummary{Alg: a})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resumed *merkletree.Frontier
+		for i := 0; i < 100; i++ {
+			if i == 37 {
+				if resumed, err = merkletree.NewFrontier(m.PeakSummary()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			b := []byte{byte(i), 1, 2}
+			m.Append(b)
+			f.Append(b)
+			if resumed != nil {
+				resumed.Append(b)
+			}
+			if !f.Summary().Equals(m.Summary()) {
+				t.Fatalf("%s: size %d: expected %s, got %s", a, m.Len(), m.Summary(), f.Summary())
+			}
+			if resumed != nil && !resumed.Summary().Equals(m.Summary()) {
+				t.Fatalf("%s: resumed at size %d: expected %s, got %s", a, m.Len(), m.Summary(), resumed.Summary())
+			}
+			if got := len(f.PeakSummary().Peaks); got != len(merkletree.PeakPositions(f.Len())) {
+				t.Fatalf("%s: size %d: unexpected %d peaks", a, f.Len(), got)
+			}
+		}
+	}
+	if _, err := merkletree.NewFrontier(merkletree.PeakSummary{N: 3}); err == nil {
+		t.Error("expected error for missing peaks")
+	}
+	if _, err := merkletree.NewFrontier(merkletree.PeakSummary{Alg: merkletree.SHAKE256.Legacy()}); err == nil {
+		t.Error("expected error for legacy algorithm")
+	}
+}
+
+func TestHashOnly(t *testing.T) {
+	for _, a := range algorithms {
+		full := newTree(t, a.DataHashed())
+		m := newHashOnly(t, a)
+		f, err := merkletree.NewFrontier(merkletree.PeakSummary{Alg: a.DataHashed()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data [][]byte
+		for i := 0; i < 30; i++ {
+			b := bytes.Repeat([]byte{byte(i)}, 100)
+			data = append(data, b)
+			full.Append(b)
+			m.Append(b)
+			f.Append(b)
+		}
+		if !m.HashOnly() || full.HashOnly() || m.Algorithm() != a.DataHashed() {
+			t.Fatalf("%s: unexpected tree %s", a, m.Algorithm())
+		}
+		if !m.Summary().Equals(full.Summary()) || !f.Summary().Equals(full.Summary()) {
+			t.Fatalf("%s: expected %s, got %s and %s", a, full.Summary(), m.Summary(), f.Summary())
+		}
+		if m.Summary().Equals(newTree(t, a).Summary()) {
+			t.Errorf("%s: data hashed summary matches", a)
+		}
+		for i, b := range data {
+			if !bytes.Equal(m.At(i), a.DataHash(b)) || len(m.At(i)) != a.Size() {
+				t.Errorf("%s: At(%d): unexpected %x", a, i, m.At(i))
+			}
+			if !bytes.Equal(full.At(i), b) {
+				t.Errorf("%s: full At(%d): unexpected %x", a, i, full.At(i))
+			}
+			p, err := m.ProveInclusion(i, m.Len())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := merkletree.VerifyInclusion(full.Summary(), b, p); err != nil {
+				t.Errorf("%s: VerifyInclusion(%d): %v", a, i, err)
+			}
+		}
+		c, err := m.ProveConsistency(7, m.Len())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := merkletree.VerifyConsistency(full.SummaryAt(7), full.Summary(), c); err != nil {
+			t.Errorf("%s: VerifyConsistency: %v", a, err)
+		}
+	}
+}
+
+var algorithms = []merkletree.Algorithm{merkletree.SHAKE256, merkletree.SHA3_256, merkletree.SHA256, merkletree.SHA512_256}
+
+func TestAlgorithms(t *testing.T) {
+	// Legacy SHA-256 summaries are hashes of the concatenated peaks, so the
+	// empty summary is the SHA-256 of the empty string.
+	empty := merkletree.SHA256.Legacy().EmptySummary()
+	if got := hex.EncodeToString(empty.Hash()); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("unexpected empty legacy SHA-256 summary %s", got)
+	}
+	m := newTree(t, merkletree.SHA256.Legacy())
+	m.Append([]byte{1, 2, 3})
+	leaf := sha256.Sum256([]byte{1, 2, 3})
+	root := sha256.Sum256(leaf[:])
+	if s := m.Summary(); !bytes.Equal(s.Hash(), root[:]) {
+		t.Errorf("unexpected legacy SHA-256 summary %x", s.Hash())
+	}
+
+	// Summaries hash tagged, length-prefixed inputs.
+	empty = merkletree.SHA256.EmptySummary()
+	if want := sha256.Sum256([]byte{2, 0, 0, 0, 0, 0, 0, 0, 0}); !bytes.Equal(empty.Hash(), want[:]) {
+		t.Errorf("unexpected empty SHA-256 summary %x", empty.Hash())
+	}
+	m = newTree(t, merkletree.SHA256)
+	m.Append([]byte{1, 2, 3})
+	leaf = sha256.Sum256([]byte{0, 0, 0, 0, 0, 0, 0, 0, 3, 1, 2, 3})
+	root = sha256.Sum256(append([]byte{2, 0, 0, 0, 0, 0, 0, 0, 1}, leaf[:]...))
+	if s := m.Summary(); !bytes.Equal(s.Hash(), root[:]) || s.Alg != merkletree.SHA256 {
+		t.Errorf("unexpected SHA-256 summary %x", s.Hash())
+	}
+
+	for _, a := range append(algorithms, merkletree.SHAKE256.Legacy()) {
+		if len(a.EmptySummary().Hash()) != a.Size() {
+			t.Errorf("%s: unexpected hash size %d", a, len(a.EmptySummary().Hash()))
+		}
+		m := newTree(t, a)
+		if !m.Summary().Equals(a.EmptySummary()) {
+			t.Errorf("%s: unexpected empty summary", a)
+		}
+		for i := 0; i < 20; i++ {
+			m.Append([]byte{byte(i)})
+		}
+		s, err := a.NewSummary(m.Len(), m.Peaks(m.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s.Equals(m.Summary()) {
+			t.Errorf("%s: NewSummary: expected %s, got %s", a, m.Summary(), s)
+		}
+		ip, err := m.ProveInclusion(7, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := merkletree.VerifyInclusion(s, m.At(7), ip); err != nil {
+			t.Errorf("%s: %v", a, err)
+		}
+		cp, err := m.ProveConsistency(9, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := merkletree.VerifyConsistency(m.SummaryAt(9), s, cp); err != nil {
+			t.Errorf("%s: %v", a, err)
+		}
+
+		// Proofs do not verify against summaries using other algorithms.
+		other := newTree(t, merkletree.SHA3_256)
+		if a == merkletree.SHA3_256 {
+			other = newTree(t, merkletree.SHA3_256.Legacy())
+		}
+		for i := 0; i < 20; i++ {
+			other.Append([]byte{byte(i)})
+		}
+		if err := merkletree.VerifyInclusion(other.Summary(), m.At(7), ip); !errors.Is(err, merkletree.ErrInvalidProof) {
+			t.Errorf("%s: expected invalid proof against %s, got %v", a, other.Algorithm(), err)
+		}
+		if other.Summary().Equals(s) {
+			t.Errorf("%s: expected summaries using different algorithms to differ", a)
+		}
+	}
+}
+
+func TestNewWithAlgorithm(t *testing.T) {
+	for _, a := range []merkletree.Algorithm{merkletree.SHAKE256.Legacy(), merkletree.SHA256.Legacy().DataHashed(), merkletree.Algorithm(0x10)} {
+		if _, err := merkletree.NewWithAlgorithm(a); err == nil {
+			t.Errorf("%s: expected error", a)
+		}
+		if _, err := merkletree.NewHashOnly(a); err == nil {
+			t.Errorf("%s: expected error for hash only tree", a)
+		}
+	}
+}
+
+// TestDomainSeparation checks that a leaf cannot be passed off as an interior
+// node, as it can with the legacy scheme.
+func TestDomainSeparation(t *testing.T) {
+	for _, a := range []merkletree.Algorithm{merkletree.SHAKE256.Legacy(), merkletree.SHAKE256} {
+		m := newTree(t, a)
+		for i := 0; i < 3; i++ {
+			m.Append([]byte{byte(i)})
+		}
+		// The entry at position 2 has the entries at 0 and 1 as children.
+		children := m.Peaks(2)
+		forged := append(append(children[0][:], children[1][:]...), m.At(2)...)
+		f := newTree(t, a)
+		f.Append(forged)
+		collides := m.Summary().Summary == f.Summary().Summary
+		if collides != a.IsLegacy() {
+			t.Errorf("%s: leaf and interior node collide: %v", a, collides)
+		}
+	}
+}
+
+// Test vectors from the Certificate Transparency reference implementation, for
+// RFC 6962 trees of the same entries as a Merkle tree.
+var (
+	rfc6962Leaves = []string{"", "00", "10", "2021", "3031", "40414243", "5051525354555657", "606162636465666768696a6b6c6d6e6f"}
+	rfc6962Roots  = []string{
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+)
+
+// TestRFC6962 checks that an RFC 6962 tree of the entries of a Merkle tree has
+// the reference roots and proves the same entries.
+func TestRFC6962(t *testing.T) {
+	m := newTree(t, merkletree.SHA256)
+	r := rfc6962.New()
+	for i, l := range rfc6962Leaves {
+		b, err := hex.DecodeString(l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Append(b)
+		r.Append(b)
+		root, err := r.Root(r.Len())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(root[:]); got != rfc6962Roots[i] {
+			t.Errorf("root of size %d: expected %s, got %s", r.Len(), rfc6962Roots[i], got)
+		}
+	}
+	n := m.Len()
+	root, _ := r.Root(n)
+	for i := 0; i < n; i++ {
+		ip, err := m.ProveInclusion(i, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := merkletree.VerifyInclusion(m.Summary(), m.At(i), ip); err != nil {
+			t.Errorf("entry %d: %v", i, err)
+		}
+		rp, err := r.ProveInclusion(i, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rfc6962.VerifyInclusion(i, n, rfc6962.LeafHash(m.At(i)), root, rp); err != nil {
+			t.Errorf("entry %d: %v", i, err)
+		}
+	}
+}
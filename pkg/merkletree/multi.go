@@ -0,0 +1,97 @@
+package merkletree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MultiProof is a proof that the entries at a set of positions are included
+// in a Merkle tree of a given size.
+//
+// Like a RangeProof, a MultiProof includes each node that the paths of its
+// entries share once, and omits nodes that the verifier hashes from the
+// entries.
+type MultiProof struct {
+	N         int       // size of the tree
+	Positions []int     // positions of the entries, distinct and in order
+	Alg       Algorithm // hash algorithm of the tree
+
+	// Nodes are the hashes of the children of the entries or their ancestors
+	// that are neither entries nor ancestors of them, in position order.
+	Nodes [][HashLength]byte
+
+	// Data are the data of ancestors of the entries that are not entries, in
+	// position order, or their data hashes if the algorithm is data hashed.
+	Data [][]byte
+
+	// Peaks are the hashes of all peaks of the tree.
+	Peaks [][HashLength]byte
+
+	// DataHashes are the data hashes of the entries that were redacted, in
+	// position order, and nil for the others. It is nil if no entry was
+	// redacted.
+	DataHashes [][]byte
+}
+
+// ProveMulti returns a proof that the entries at positions are included in
+// the Merkle tree when it had n entries. The positions of the proof are
+// positions in order, without duplicates.
+func (m *MerkleTree) ProveMulti(positions []int, n int) (*MultiProof, error) {
+	if len(positions) == 0 || n > m.Len() {
+		return nil, fmt.Errorf("cannot prove %d entries in tree of size %d (length %d)", len(positions), n, m.Len())
+	}
+	ps := append([]int(nil), positions...)
+	sort.Ints(ps)
+	j := 0
+	for i, pos := range ps {
+		if pos < 0 || pos >= n {
+			return nil, fmt.Errorf("cannot prove entry %d in tree of size %d (length %d)", pos, n, m.Len())
+		}
+		if i == 0 || pos != ps[j-1] {
+			ps[j] = pos
+			j++
+		}
+	}
+	ps = ps[:j]
+	b := m.proveBatch(ps, n)
+	return &MultiProof{
+		N:          n,
+		Positions:  ps,
+		Alg:        m.alg,
+		Nodes:      b.hashes,
+		Data:       b.data,
+		Peaks:      m.Peaks(n),
+		DataHashes: b.dataHashes,
+	}, nil
+}
+
+// VerifyMulti verifies that p proves that the entries with the given data, at
+// p.Positions in order, are included in the Merkle tree summarized by s. The
+// data of entries that were redacted may be nil, in which case their data
+// hashes in p are verified instead.
+func VerifyMulti(s Summary, data [][]byte, p *MultiProof) error {
+	if p.N != s.N {
+		return fmt.Errorf("%w: proof for size %d, summary of size %d", ErrInvalidProof, p.N, s.N)
+	}
+	if len(p.Positions) == 0 {
+		return fmt.Errorf("%w: no entries", ErrInvalidProof)
+	}
+	for i, pos := range p.Positions {
+		if pos < 0 || pos >= p.N {
+			return fmt.Errorf("%w: position %d out of range", ErrInvalidProof, pos)
+		}
+		if i > 0 && pos <= p.Positions[i-1] {
+			return fmt.Errorf("%w: positions out of order", ErrInvalidProof)
+		}
+	}
+	if len(data) != len(p.Positions) {
+		return fmt.Errorf("%w: proof of %d entries, got %d", ErrInvalidProof, len(p.Positions), len(data))
+	}
+	if err := checkAlgorithm(s.Alg, p.Alg); err != nil {
+		return err
+	}
+	if err := checkPeaks(s, p.Peaks); err != nil {
+		return err
+	}
+	return verifyBatch(p.Alg, p.N, p.Positions, data, batch{hashes: p.Nodes, data: p.Data, dataHashes: p.DataHashes}, p.Peaks)
+}
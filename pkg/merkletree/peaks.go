@@ -0,0 +1,60 @@
+package merkletree
+
+import (
+	"fmt"
+)
+
+// PeakSummary is a summary of a tree that includes the hashes of its peaks.
+//
+// A Summary commits to the peaks of a tree by bagging them into one hash. A
+// PeakSummary keeps the peaks themselves, so that it can be extended by
+// consistency proofs and appended to.
+type PeakSummary struct {
+	N     int
+	Peaks [][HashLength]byte // in position order
+	Alg   Algorithm
+}
+
+// NewPeakSummary returns the peak summary of a Merkle tree using a of size n
+// with the given peaks.
+func (a Algorithm) NewPeakSummary(n int, peakHashes [][HashLength]byte) (PeakSummary, error) {
+	if _, err := a.NewSummary(n, peakHashes); err != nil {
+		return PeakSummary{}, err
+	}
+	return PeakSummary{N: n, Peaks: peakHashes, Alg: a}, nil
+}
+
+// PeakSummary returns the peak summary of the Merkle tree.
+func (m *MerkleTree) PeakSummary() PeakSummary {
+	return m.PeakSummaryAt(m.Len())
+}
+
+// PeakSummaryAt returns the peak summary of the Merkle tree when it had n
+// entries. If n is larger than the length of the MerkleTree, PeakSummaryAt
+// panics.
+func (m *MerkleTree) PeakSummaryAt(n int) PeakSummary {
+	return PeakSummary{N: n, Peaks: m.Peaks(n), Alg: m.alg}
+}
+
+// WithPeaks returns the peak summary of s with the given peaks, or an error if
+// the peaks do not bag to s.
+func (s Summary) WithPeaks(peakHashes [][HashLength]byte) (PeakSummary, error) {
+	p, err := s.Alg.NewPeakSummary(s.N, peakHashes)
+	if err != nil {
+		return PeakSummary{}, err
+	}
+	if ps, _ := p.Summary(); !ps.Equals(s) {
+		return PeakSummary{}, fmt.Errorf("peaks do not match summary %s", s)
+	}
+	return p, nil
+}
+
+// Summary returns the summary of p, bagging its peaks.
+func (p PeakSummary) Summary() (Summary, error) {
+	return p.Alg.NewSummary(p.N, p.Peaks)
+}
+
+// Positions returns the positions of the peaks of p (see PeakPositions).
+func (p PeakSummary) Positions() []int {
+	return PeakPositions(p.N)
+}
@@ -0,0 +1,293 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidProof is returned (possibly wrapped) when a proof fails to verify.
+var ErrInvalidProof = errors.New("invalid proof")
+
+// Step is a step in a proof from a node up to its parent.
+type Step struct {
+	// Sibling is the hash of the sibling of the node.
+	Sibling [HashLength]byte
+
+	// Data is the data of the parent of the node, or its data hash if the
+	// algorithm is data hashed.
+	Data []byte
+}
+
+// InclusionProof is a proof that an entry is included in a Merkle tree of a
+// given size.
+type InclusionProof struct {
+	N   int       // size of the tree
+	Pos int       // position of the entry
+	Alg Algorithm // hash algorithm of the tree
+
+	// Children are the hashes of the children of the entry, empty for leaves.
+	Children [][HashLength]byte
+
+	// Path is the path from the entry up to its peak.
+	Path []Step
+
+	// Peaks are the hashes of all peaks of the tree.
+	Peaks [][HashLength]byte
+
+	// DataHash is the data hash of the entry if it was redacted, and nil
+	// otherwise.
+	DataHash []byte
+}
+
+// ConsistencyProof is a proof that a Merkle tree of one size is a prefix of
+// a Merkle tree of a larger size.
+type ConsistencyProof struct {
+	From, To int
+	Alg      Algorithm // hash algorithm of the tree
+
+	// OldPeaks are the hashes of all peaks of the tree of size From.
+	OldPeaks [][HashLength]byte
+
+	// Paths are the paths from each of OldPeaks up to a peak of the tree of
+	// size To.
+	Paths [][]Step
+
+	// NewPeaks are the hashes of all peaks of the tree of size To.
+	NewPeaks [][HashLength]byte
+}
+
+func (m *MerkleTree) steps(pos, n int) []Step {
+	var r []Step
+	for _, s := range path(pos, n) {
+		p, _ := parent(pos)
+		r = append(r, Step{Sibling: m.nodes[s], Data: m.commitment(p)})
+		pos = p
+	}
+	return r
+}
+
+// ProveInclusion returns a proof that the entry at pos is included in the
+// Merkle tree when it had n entries.
+func (m *MerkleTree) ProveInclusion(pos, n int) (*InclusionProof, error) {
+	if pos < 0 || pos >= n || n > m.Len() {
+		return nil, fmt.Errorf("cannot prove entry %d in tree of size %d (length %d)", pos, n, m.Len())
+	}
+	p := &InclusionProof{
+		N:     n,
+		Pos:   pos,
+		Alg:   m.alg,
+		Path:  m.steps(pos, n),
+		Peaks: m.Peaks(n),
+	}
+	for _, c := range children(pos, height(pos)) {
+		p.Children = append(p.Children, m.nodes[c])
+	}
+	if m.redacted[pos] {
+		p.DataHash = append([]byte(nil), m.data[pos]...)
+	}
+	return p, nil
+}
+
+// ProveConsistency returns a proof that the Merkle tree when it had from
+// entries is a prefix of the Merkle tree when it had to entries.
+func (m *MerkleTree) ProveConsistency(from, to int) (*ConsistencyProof, error) {
+	if from < 0 || from > to || to > m.Len() {
+		return nil, fmt.Errorf("cannot prove size %d consistent with size %d (length %d)", from, to, m.Len())
+	}
+	p := &ConsistencyProof{
+		From:     from,
+		To:       to,
+		Alg:      m.alg,
+		OldPeaks: m.Peaks(from),
+		NewPeaks: m.Peaks(to),
+	}
+	for _, pos := range peaks(from) {
+		p.Paths = append(p.Paths, m.steps(pos, to))
+	}
+	return p, nil
+}
+
+// climb follows steps from the node at pos with the given hash using a,
+// returning the index and hash of the node reached. It returns an error if the
+// steps leave a tree of size n.
+func climb(a Algorithm, pos, n int, node [HashLength]byte, steps []Step) (int, [HashLength]byte, error) {
+	for _, s := range steps {
+		p, sib := parent(pos)
+		if p >= n {
+			return 0, node, fmt.Errorf("%w: path too long", ErrInvalidProof)
+		}
+		if sib < pos {
+			node = hashNode(a, &s.Sibling, &node, s.Data)
+		} else {
+			node = hashNode(a, &node, &s.Sibling, s.Data)
+		}
+		pos = p
+	}
+	return pos, node, nil
+}
+
+// peakIndex returns the index of pos among the peaks of a tree of size n, or
+// -1 if pos is not a peak.
+func peakIndex(pos, n int) int {
+	for i, p := range peaks(n) {
+		if p == pos {
+			return i
+		}
+	}
+	return -1
+}
+
+func checkPeaks(s Summary, ps [][HashLength]byte) error {
+	if len(ps) != len(peaks(s.N)) {
+		return fmt.Errorf("%w: expected %d peaks, got %d", ErrInvalidProof, len(peaks(s.N)), len(ps))
+	}
+	if bag(s.Alg, s.N, ps) != s.Summary {
+		return fmt.Errorf("%w: peaks do not match summary %s", ErrInvalidProof, s)
+	}
+	return nil
+}
+
+// Node returns the hash of the node at the position of p if its data is data.
+func (p *InclusionProof) Node(data []byte) ([HashLength]byte, error) {
+	return p.node(p.Alg.commitment(data))
+}
+
+// node returns the hash of the node at the position of p if it hashes c of its
+// entry.
+func (p *InclusionProof) node(c []byte) ([HashLength]byte, error) {
+	switch {
+	case height(p.Pos) == 0 && len(p.Children) == 0:
+		return hashNode(p.Alg, nil, nil, c), nil
+	case height(p.Pos) > 0 && len(p.Children) == 2:
+		return hashNode(p.Alg, &p.Children[0], &p.Children[1], c), nil
+	default:
+		return [HashLength]byte{}, fmt.Errorf("%w: wrong number of children", ErrInvalidProof)
+	}
+}
+
+// VerifyInclusion verifies that p proves that data is included in the Merkle
+// tree summarized by s.
+func VerifyInclusion(s Summary, data []byte, p *InclusionProof) error {
+	return verifyInclusion(s, p, func() ([HashLength]byte, error) { return p.Node(data) })
+}
+
+// VerifyRedacted verifies that p proves that a redacted entry with the data
+// hash of p is included in the Merkle tree summarized by s. The data of the
+// entry was withheld, so it cannot be verified.
+func VerifyRedacted(s Summary, p *InclusionProof) error {
+	if p.DataHash == nil {
+		return fmt.Errorf("%w: entry %d was not redacted", ErrInvalidProof, p.Pos)
+	}
+	if !p.Alg.IsDataHashed() || len(p.DataHash) != p.Alg.Size() {
+		return fmt.Errorf("%w: invalid data hash for %s", ErrInvalidProof, p.Alg)
+	}
+	return verifyInclusion(s, p, func() ([HashLength]byte, error) { return p.node(p.DataHash) })
+}
+
+// verifyInclusion verifies that p proves that the node returned by node is
+// included in the Merkle tree summarized by s.
+func verifyInclusion(s Summary, p *InclusionProof, node func() ([HashLength]byte, error)) error {
+	if p.N != s.N {
+		return fmt.Errorf("%w: proof for size %d, summary of size %d", ErrInvalidProof, p.N, s.N)
+	}
+	if p.Pos < 0 || p.Pos >= p.N {
+		return fmt.Errorf("%w: position %d out of range", ErrInvalidProof, p.Pos)
+	}
+	if err := checkAlgorithm(s.Alg, p.Alg); err != nil {
+		return err
+	}
+	if err := checkPeaks(s, p.Peaks); err != nil {
+		return err
+	}
+	n, err := node()
+	if err != nil {
+		return err
+	}
+	pos, n, err := climb(p.Alg, p.Pos, p.N, n, p.Path)
+	if err != nil {
+		return err
+	}
+	i := peakIndex(pos, p.N)
+	if i < 0 {
+		return fmt.Errorf("%w: path too short", ErrInvalidProof)
+	}
+	if p.Peaks[i] != n {
+		return fmt.Errorf("%w: entry does not match peak", ErrInvalidProof)
+	}
+	return nil
+}
+
+// VerifyConsistency verifies that p proves that the Merkle tree summarized by
+// from is a prefix of the Merkle tree summarized by to.
+func VerifyConsistency(from, to Summary, p *ConsistencyProof) error {
+	if p.From != from.N || p.To != to.N {
+		return fmt.Errorf("%w: proof for sizes %d and %d, summaries of sizes %d and %d", ErrInvalidProof, p.From, p.To, from.N, to.N)
+	}
+	if p.From > p.To {
+		return fmt.Errorf("%w: tree shrank from %d to %d", ErrInvalidProof, p.From, p.To)
+	}
+	if err := checkAlgorithm(from.Alg, p.Alg); err != nil {
+		return err
+	}
+	if err := checkAlgorithm(to.Alg, p.Alg); err != nil {
+		return err
+	}
+	if err := checkPeaks(from, p.OldPeaks); err != nil {
+		return err
+	}
+	if err := checkPeaks(to, p.NewPeaks); err != nil {
+		return err
+	}
+	if len(p.Paths) != len(p.OldPeaks) {
+		return fmt.Errorf("%w: expected %d paths, got %d", ErrInvalidProof, len(p.OldPeaks), len(p.Paths))
+	}
+	for i, pos := range peaks(p.From) {
+		pos, node, err := climb(p.Alg, pos, p.To, p.OldPeaks[i], p.Paths[i])
+		if err != nil {
+			return err
+		}
+		j := peakIndex(pos, p.To)
+		if j < 0 {
+			return fmt.Errorf("%w: path too short", ErrInvalidProof)
+		}
+		if p.NewPeaks[j] != node {
+			return fmt.Errorf("%w: old peak does not match new peak", ErrInvalidProof)
+		}
+	}
+	return nil
+}
+
+// UpgradeInclusion returns a proof that the entry proven by p to be included
+// in a tree of size p.N is included in a tree of size c.To, by extending the
+// path of p from its peak with the path of c from the same peak. Neither proof
+// is verified.
+func UpgradeInclusion(p *InclusionProof, c *ConsistencyProof) (*InclusionProof, error) {
+	if p.N != c.From {
+		return nil, fmt.Errorf("%w: inclusion proof for size %d, consistency proof from size %d", ErrInvalidProof, p.N, c.From)
+	}
+	if p.Alg != c.Alg {
+		return nil, fmt.Errorf("%w: inclusion proof using %s, consistency proof using %s", ErrInvalidProof, p.Alg, c.Alg)
+	}
+	if p.Pos < 0 || p.Pos >= p.N {
+		return nil, fmt.Errorf("%w: position %d out of range", ErrInvalidProof, p.Pos)
+	}
+	pos := p.Pos
+	for range p.Path {
+		pos, _ = parent(pos)
+	}
+	i := peakIndex(pos, p.N)
+	if i < 0 || i >= len(c.Paths) {
+		return nil, fmt.Errorf("%w: path does not reach a peak", ErrInvalidProof)
+	}
+	path := make([]Step, 0, len(p.Path)+len(c.Paths[i]))
+	path = append(append(path, p.Path...), c.Paths[i]...)
+	return &InclusionProof{
+		N:        c.To,
+		Pos:      p.Pos,
+		Alg:      p.Alg,
+		Children: p.Children,
+		Path:     path,
+		Peaks:    c.NewPeaks,
+		DataHash: p.DataHash,
+	}, nil
+}
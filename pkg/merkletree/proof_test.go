@@ -0,0 +1,339 @@
+package merkletree_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+)
+
+const proofTreeSize = 40
+
+func newTestTree(n int) *merkletree.MerkleTree {
+	m := merkletree.New()
+	for i := 0; i < n; i++ {
+		m.Append([]byte{byte(i), byte(i >> 8), 0xff})
+	}
+	return m
+}
+
+func TestInclusionProof(t *testing.T) {
+	m := newTestTree(proofTreeSize)
+	for n := 1; n <= proofTreeSize; n++ {
+		s := m.SummaryAt(n)
+		for pos := 0; pos < n; pos++ {
+			p, err := m.ProveInclusion(pos, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := merkletree.VerifyInclusion(s, m.At(pos), p); err != nil {
+				t.Errorf("VerifyInclusion(%d, %d): %v", pos, n, err)
+			}
+			if err := merkletree.VerifyInclusion(s, []byte("bad"), p); !errors.Is(err, merkletree.ErrInvalidProof) {
+				t.Errorf("VerifyInclusion(%d, %d) of bad data: %v", pos, n, err)
+			}
+		}
+	}
+}
+
+func TestInclusionProofErrors(t *testing.T) {
+	m := newTestTree(10)
+	if _, err := m.ProveInclusion(10, 10); err == nil {
+		t.Error("expected error proving entry beyond tree")
+	}
+	if _, err := m.ProveInclusion(3, 11); err == nil {
+		t.Error("expected error proving against size beyond tree")
+	}
+
+	p, err := m.ProveInclusion(3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merkletree.VerifyInclusion(m.SummaryAt(9), m.At(3), p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("expected size mismatch, got %v", err)
+	}
+	p.Path = p.Path[:len(p.Path)-1]
+	if err := merkletree.VerifyInclusion(m.Summary(), m.At(3), p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("expected short path to fail, got %v", err)
+	}
+}
+
+func TestConsistencyProof(t *testing.T) {
+	m := newTestTree(proofTreeSize)
+	for from := 0; from <= proofTreeSize; from++ {
+		for to := from; to <= proofTreeSize; to++ {
+			p, err := m.ProveConsistency(from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := merkletree.VerifyConsistency(m.SummaryAt(from), m.SummaryAt(to), p); err != nil {
+				t.Errorf("VerifyConsistency(%d, %d): %v", from, to, err)
+			}
+		}
+	}
+}
+
+func TestUpgradeInclusion(t *testing.T) {
+	m := newTestTree(proofTreeSize)
+	for from := 1; from <= proofTreeSize; from++ {
+		for to := from; to <= proofTreeSize; to++ {
+			c, err := m.ProveConsistency(from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for pos := 0; pos < from; pos++ {
+				p, err := m.ProveInclusion(pos, from)
+				if err != nil {
+					t.Fatal(err)
+				}
+				up, err := merkletree.UpgradeInclusion(p, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := merkletree.VerifyInclusion(m.SummaryAt(to), m.At(pos), up); err != nil {
+					t.Errorf("UpgradeInclusion(%d, %d, %d): %v", pos, from, to, err)
+				}
+			}
+		}
+	}
+	p, err := m.ProveInclusion(3, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := m.ProveConsistency(11, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := merkletree.UpgradeInclusion(p, c); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("expected size mismatch, got %v", err)
+	}
+}
+
+func TestConsistencyProofFork(t *testing.T) {
+	m := newTestTree(20)
+	fork := newTestTree(12)
+	for i := 12; i < 20; i++ {
+		fork.Append([]byte("fork"))
+	}
+	p, err := fork.ProveConsistency(15, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merkletree.VerifyConsistency(m.SummaryAt(15), fork.Summary(), p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("expected fork to fail verification, got %v", err)
+	}
+	if err := merkletree.VerifyConsistency(fork.SummaryAt(15), m.Summary(), p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("expected fork to fail verification, got %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	m := newTree(t, merkletree.SHAKE256.DataHashed())
+	for i := 0; i < proofTreeSize; i++ {
+		m.Append([]byte{byte(i), byte(i >> 8), 0xff})
+	}
+	before := m.Summary()
+	redacted := map[int]bool{2: true, 6: true, 13: true, 30: true}
+	for pos := range redacted {
+		if err := m.Redact(pos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Redact(6); err != nil {
+		t.Errorf("redacting twice: %v", err)
+	}
+	if !m.Summary().Equals(before) {
+		t.Fatalf("redaction changed summary from %s to %s", before, m.Summary())
+	}
+
+	// Rebuild the tree from its entries and the hashes of redacted entries.
+	r := newTree(t, merkletree.SHAKE256.DataHashed())
+	for i := 0; i < m.Len(); i++ {
+		if m.Redacted(i) != redacted[i] {
+			t.Errorf("Redacted(%d): expected %t", i, redacted[i])
+		}
+		if m.Redacted(i) {
+			if err := r.AppendRedacted(m.At(i)); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			r.Append(m.At(i))
+		}
+	}
+	if !r.Summary().Equals(before) {
+		t.Errorf("rebuilt summary %s, expected %s", r.Summary(), before)
+	}
+
+	for pos := 0; pos < m.Len(); pos++ {
+		p, err := m.ProveInclusion(pos, m.Len())
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := []byte{byte(pos), byte(pos >> 8), 0xff}
+		if err := merkletree.VerifyInclusion(before, data, p); err != nil {
+			t.Errorf("VerifyInclusion(%d): %v", pos, err)
+		}
+		err = merkletree.VerifyRedacted(before, p)
+		if redacted[pos] && err != nil {
+			t.Errorf("VerifyRedacted(%d): %v", pos, err)
+		}
+		if !redacted[pos] && !errors.Is(err, merkletree.ErrInvalidProof) {
+			t.Errorf("VerifyRedacted(%d) of entry that was not redacted: %v", pos, err)
+		}
+	}
+
+	// Range and multi proofs verify redacted entries by their data hashes.
+	var data, withheld [][]byte
+	for pos := 0; pos < m.Len(); pos++ {
+		d := []byte{byte(pos), byte(pos >> 8), 0xff}
+		data = append(data, d)
+		if redacted[pos] {
+			d = nil
+		}
+		withheld = append(withheld, d)
+	}
+	rp, err := m.ProveRange(0, m.Len(), m.Len())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merkletree.VerifyRange(before, data, rp); err != nil {
+		t.Errorf("VerifyRange: %v", err)
+	}
+	if err := merkletree.VerifyRange(before, withheld, rp); err != nil {
+		t.Errorf("VerifyRange of redacted entries: %v", err)
+	}
+	withheld[3] = nil
+	if err := merkletree.VerifyRange(before, withheld, rp); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("VerifyRange withholding entry that was not redacted: %v", err)
+	}
+	mp, err := m.ProveMulti([]int{3, 6, 13, 20}, m.Len())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merkletree.VerifyMulti(before, [][]byte{data[3], nil, nil, data[20]}, mp); err != nil {
+		t.Errorf("VerifyMulti of redacted entries: %v", err)
+	}
+	if err := merkletree.VerifyMulti(before, [][]byte{nil, nil, nil, data[20]}, mp); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("VerifyMulti withholding entry that was not redacted: %v", err)
+	}
+
+	for from := 0; from <= m.Len(); from++ {
+		p, err := m.ProveConsistency(from, m.Len())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := merkletree.VerifyConsistency(m.SummaryAt(from), before, p); err != nil {
+			t.Errorf("VerifyConsistency(%d): %v", from, err)
+		}
+	}
+
+	if err := newTestTree(3).Redact(1); err == nil {
+		t.Error("expected error redacting tree that is not data hashed")
+	}
+	if err := newHashOnly(t, merkletree.SHAKE256).Redact(0); err == nil {
+		t.Error("expected error redacting hash only tree")
+	}
+	if err := m.Redact(m.Len()); err == nil {
+		t.Error("expected error redacting missing entry")
+	}
+}
+
+func TestRangeProof(t *testing.T) {
+	m := newTestTree(proofTreeSize)
+	data := func(begin, end int) [][]byte {
+		var r [][]byte
+		for i := begin; i < end; i++ {
+			r = append(r, m.At(i))
+		}
+		return r
+	}
+	for n := 1; n <= proofTreeSize; n++ {
+		s := m.SummaryAt(n)
+		for begin := 0; begin < n; begin++ {
+			for end := begin + 1; end <= n; end++ {
+				p, err := m.ProveRange(begin, end, n)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := merkletree.VerifyRange(s, data(begin, end), p); err != nil {
+					t.Fatalf("VerifyRange(%d, %d, %d): %v", begin, end, n, err)
+				}
+				bad := data(begin, end)
+				bad[len(bad)-1] = []byte("bad")
+				if err := merkletree.VerifyRange(s, bad, p); !errors.Is(err, merkletree.ErrInvalidProof) {
+					t.Errorf("VerifyRange(%d, %d, %d) of bad data: %v", begin, end, n, err)
+				}
+			}
+		}
+	}
+
+	// A range proof is smaller than separate inclusion proofs.
+	p, err := m.ProveRange(3, 35, proofTreeSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Nodes) > 8 || len(p.Data) > 8 {
+		t.Errorf("unexpectedly large proof with %d hashes and %d data", len(p.Nodes), len(p.Data))
+	}
+	p.Begin++
+	if err := merkletree.VerifyRange(m.Summary(), data(4, 35), p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("VerifyRange of shifted range: %v", err)
+	}
+	if _, err := m.ProveRange(5, 5, proofTreeSize); err == nil {
+		t.Error("expected error proving empty range")
+	}
+}
+
+func TestMultiProof(t *testing.T) {
+	m := newTestTree(proofTreeSize)
+	s := m.Summary()
+	positions := []int{33, 2, 7, 3, 7, 20, 39}
+	p, err := m.ProveMulti(positions, proofTreeSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{2, 3, 7, 20, 33, 39}
+	if len(p.Positions) != len(want) {
+		t.Fatalf("expected positions %v, got %v", want, p.Positions)
+	}
+	var data [][]byte
+	for i, pos := range p.Positions {
+		if pos != want[i] {
+			t.Fatalf("expected positions %v, got %v", want, p.Positions)
+		}
+		data = append(data, m.At(pos))
+	}
+	if err := merkletree.VerifyMulti(s, data, p); err != nil {
+		t.Fatal(err)
+	}
+
+	// Shared nodes are included once.
+	size := 0
+	for _, pos := range p.Positions {
+		ip, err := m.ProveInclusion(pos, proofTreeSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size += len(ip.Path) + len(ip.Children)
+	}
+	if len(p.Nodes) >= size {
+		t.Errorf("multi-proof of %d hashes, separate proofs of %d", len(p.Nodes), size)
+	}
+
+	data[3] = []byte("bad")
+	if err := merkletree.VerifyMulti(s, data, p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("VerifyMulti of bad data: %v", err)
+	}
+	data[3] = m.At(20)
+	p.Positions[3] = 21
+	if err := merkletree.VerifyMulti(s, data, p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("VerifyMulti of wrong position: %v", err)
+	}
+	p.Positions[3] = 2
+	if err := merkletree.VerifyMulti(s, data, p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("VerifyMulti of unordered positions: %v", err)
+	}
+	if _, err := m.ProveMulti([]int{3, proofTreeSize}, proofTreeSize); err == nil {
+		t.Error("expected error proving missing entry")
+	}
+}
@@ -0,0 +1,199 @@
+package merkletree
+
+import (
+	"fmt"
+	"sort"
+)
+
+// RangeProof is a proof that the entries at a range of positions are included
+// in a Merkle tree of a given size.
+//
+// Nodes in the range and their ancestors are hashed by the verifier, so a
+// RangeProof includes only the hashes of other nodes they depend on, which are
+// at most a few per level of the tree.
+type RangeProof struct {
+	N          int       // size of the tree
+	Begin, End int       // positions of the entries, from Begin up to End
+	Alg        Algorithm // hash algorithm of the tree
+
+	// Nodes are the hashes of the children of nodes in the range or their
+	// ancestors that are neither in the range nor ancestors of it, in position
+	// order.
+	Nodes [][HashLength]byte
+
+	// Data are the data of ancestors of the range that are not in it, in
+	// position order, or their data hashes if the algorithm is data hashed.
+	Data [][]byte
+
+	// Peaks are the hashes of all peaks of the tree.
+	Peaks [][HashLength]byte
+
+	// DataHashes are the data hashes of the entries in the range that were
+	// redacted, in position order, and nil for the others. It is nil if no
+	// entry was redacted.
+	DataHashes [][]byte
+}
+
+// cover returns the positions of nodes of a tree of size n that are hashed to
+// verify the entries at targets, which must be distinct and in order. Nodes are
+// the targets and their ancestors, hashes are the children of those nodes that
+// are not among them, and data are the ancestors that are not targets, all in
+// position order.
+func cover(targets []int, n int) (nodes, hashes, data []int) {
+	known := make(map[int]bool)
+	for _, pos := range targets {
+		known[pos] = true
+	}
+	for _, pos := range targets {
+		for p, _ := parent(pos); p < n && !known[p]; p, _ = parent(p) {
+			known[p] = true
+			data = append(data, p)
+		}
+	}
+	for pos := range known {
+		nodes = append(nodes, pos)
+	}
+	sort.Ints(nodes)
+	sort.Ints(data)
+	for _, pos := range nodes {
+		for _, c := range children(pos, height(pos)) {
+			if !known[c] {
+				hashes = append(hashes, c)
+			}
+		}
+	}
+	sort.Ints(hashes)
+	return nodes, hashes, data
+}
+
+// batch is the contents of a proof of the entries at targets, laid out as
+// returned by cover, and the data hashes of targets that were redacted.
+type batch struct {
+	hashes     [][HashLength]byte
+	data       [][]byte
+	dataHashes [][]byte
+}
+
+// proveBatch returns the contents of a proof of the entries at targets in the
+// Merkle tree when it had n entries.
+func (m *MerkleTree) proveBatch(targets []int, n int) batch {
+	_, hashes, data := cover(targets, n)
+	var b batch
+	for _, pos := range hashes {
+		b.hashes = append(b.hashes, m.nodes[pos])
+	}
+	for _, pos := range data {
+		b.data = append(b.data, m.commitment(pos))
+	}
+	for i, pos := range targets {
+		if !m.redacted[pos] {
+			continue
+		}
+		if b.dataHashes == nil {
+			b.dataHashes = make([][]byte, len(targets))
+		}
+		b.dataHashes[i] = append([]byte(nil), m.data[pos]...)
+	}
+	return b
+}
+
+// verifyBatch verifies that b proves that the entries at targets, which must
+// be distinct and in order, with the given data are included in a Merkle tree
+// of size n using a with the given peaks. Entries with nil data are verified
+// by their data hashes in b, if any.
+func verifyBatch(a Algorithm, n int, targets []int, data [][]byte, b batch, peakHashes [][HashLength]byte) error {
+	nodes, hashes, ancestors := cover(targets, n)
+	if b.dataHashes != nil && len(b.dataHashes) != len(targets) {
+		return fmt.Errorf("%w: expected %d data hashes, got %d", ErrInvalidProof, len(targets), len(b.dataHashes))
+	}
+	if len(b.hashes) != len(hashes) {
+		return fmt.Errorf("%w: expected %d hashes, got %d", ErrInvalidProof, len(hashes), len(b.hashes))
+	}
+	if len(b.data) != len(ancestors) {
+		return fmt.Errorf("%w: expected data of %d ancestors, got %d", ErrInvalidProof, len(ancestors), len(b.data))
+	}
+	commitments := make(map[int][]byte, len(nodes))
+	for i, pos := range targets {
+		if data[i] != nil || b.dataHashes == nil || b.dataHashes[i] == nil {
+			commitments[pos] = a.commitment(data[i])
+			continue
+		}
+		if !a.IsDataHashed() || len(b.dataHashes[i]) != a.Size() {
+			return fmt.Errorf("%w: invalid data hash of entry %d for %s", ErrInvalidProof, pos, a)
+		}
+		commitments[pos] = b.dataHashes[i]
+	}
+	for i, pos := range ancestors {
+		commitments[pos] = b.data[i]
+	}
+	computed := make(map[int][HashLength]byte, len(nodes)+len(hashes))
+	for i, pos := range hashes {
+		computed[pos] = b.hashes[i]
+	}
+	// Children precede their parents, so nodes are hashed bottom up.
+	for _, pos := range nodes {
+		var left, right *[HashLength]byte
+		if cs := children(pos, height(pos)); cs != nil {
+			l, r := computed[cs[0]], computed[cs[1]]
+			left, right = &l, &r
+		}
+		computed[pos] = hashNode(a, left, right, commitments[pos])
+	}
+	for i, pos := range peaks(n) {
+		if h, ok := computed[pos]; ok && h != peakHashes[i] {
+			return fmt.Errorf("%w: entries do not match peak %d", ErrInvalidProof, i)
+		}
+	}
+	return nil
+}
+
+// ProveRange returns a proof that the entries from begin up to end are
+// included in the Merkle tree when it had n entries.
+func (m *MerkleTree) ProveRange(begin, end, n int) (*RangeProof, error) {
+	if begin < 0 || begin >= end || end > n || n > m.Len() {
+		return nil, fmt.Errorf("cannot prove entries %d to %d in tree of size %d (length %d)", begin, end, n, m.Len())
+	}
+	b := m.proveBatch(positions(begin, end), n)
+	return &RangeProof{
+		N:          n,
+		Begin:      begin,
+		End:        end,
+		Alg:        m.alg,
+		Nodes:      b.hashes,
+		Data:       b.data,
+		Peaks:      m.Peaks(n),
+		DataHashes: b.dataHashes,
+	}, nil
+}
+
+// positions returns the positions from begin up to end.
+func positions(begin, end int) []int {
+	r := make([]int, end-begin)
+	for i := range r {
+		r[i] = begin + i
+	}
+	return r
+}
+
+// VerifyRange verifies that p proves that the entries with the given data,
+// starting at p.Begin, are included in the Merkle tree summarized by s. The
+// data of entries that were redacted may be nil, in which case their data
+// hashes in p are verified instead.
+func VerifyRange(s Summary, data [][]byte, p *RangeProof) error {
+	if p.N != s.N {
+		return fmt.Errorf("%w: proof for size %d, summary of size %d", ErrInvalidProof, p.N, s.N)
+	}
+	if p.Begin < 0 || p.Begin >= p.End || p.End > p.N {
+		return fmt.Errorf("%w: range %d to %d out of range", ErrInvalidProof, p.Begin, p.End)
+	}
+	if len(data) != p.End-p.Begin {
+		return fmt.Errorf("%w: proof of %d entries, got %d", ErrInvalidProof, p.End-p.Begin, len(data))
+	}
+	if err := checkAlgorithm(s.Alg, p.Alg); err != nil {
+		return err
+	}
+	if err := checkPeaks(s, p.Peaks); err != nil {
+		return err
+	}
+	return verifyBatch(p.Alg, p.N, positions(p.Begin, p.End), data, batch{hashes: p.Nodes, data: p.Data, dataHashes: p.DataHashes}, p.Peaks)
+}
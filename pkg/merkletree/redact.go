@@ -0,0 +1,69 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Redact replaces the data of the entry at pos with its data hash, so that
+// summaries and proofs of the MerkleTree remain valid without the data.
+// Proofs of redacted entries carry their data hashes instead (see
+// VerifyRedacted, VerifyRange and VerifyMulti).
+//
+// Only MerkleTrees created by NewWithAlgorithm with a data hashed algorithm,
+// such as SHAKE256.DataHashed(), can be redacted, since nodes of other
+// algorithms cannot be verified without data. In particular, MerkleTrees
+// created by New cannot be redacted, and hash only MerkleTrees keep no data to
+// redact.
+func (m *MerkleTree) Redact(pos int) error {
+	if pos < 0 || pos >= m.Len() {
+		return fmt.Errorf("cannot redact entry %d of tree of length %d", pos, m.Len())
+	}
+	if err := m.checkRedactable(); err != nil {
+		return err
+	}
+	if m.redacted[pos] {
+		return nil
+	}
+	m.data[pos] = m.alg.DataHash(m.data[pos])
+	if m.redacted == nil {
+		m.redacted = make(map[int]bool)
+	}
+	m.redacted[pos] = true
+	return nil
+}
+
+// checkRedactable returns an error if entries of m cannot be redacted.
+func (m *MerkleTree) checkRedactable() error {
+	switch {
+	case m.hashOnly:
+		return errors.New("cannot redact entries of a hash only tree, which keeps no data")
+	case !m.alg.IsDataHashed():
+		return fmt.Errorf("cannot redact entries of a tree using %s, which is not data hashed; create trees to redact with NewWithAlgorithm using %s", m.alg, m.alg.DataHashed())
+	}
+	return nil
+}
+
+// Redacted returns true if the entry at pos was redacted.
+func (m *MerkleTree) Redacted(pos int) bool {
+	return m.redacted[pos]
+}
+
+// AppendRedacted adds a redacted entry with the given data hash to the
+// MerkleTree, so that a MerkleTree with redacted entries can be rebuilt from
+// its entries and data hashes.
+func (m *MerkleTree) AppendRedacted(dataHash []byte) error {
+	if err := m.checkRedactable(); err != nil {
+		return err
+	}
+	if len(dataHash) != m.alg.Size() {
+		return fmt.Errorf("expected data hash of %d bytes, got %d", m.alg.Size(), len(dataHash))
+	}
+	if m.redacted == nil {
+		m.redacted = make(map[int]bool)
+	}
+	m.redacted[m.Len()] = true
+	h := append([]byte(nil), dataHash...)
+	m.append(h, h)
+	return nil
+}
@@ -0,0 +1,170 @@
+// Package aggregator notarizes many hashes with a single entry of a Merkle
+// weave.
+//
+// An Aggregator collects the hashes submitted over a short window into a local
+// Merkle tree and notarizes only the root of the tree, the summary hash of its
+// peaks. Each submitter receives a Proof with two levels: a proof that its
+// hash is included in the local tree, and a receipt for the root in the Merkle
+// weave. Receipts in proofs can be upgraded like any other receipt with
+// client.Client.UpgradeReceipt.
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+)
+
+// Notarizer notarizes hashes and returns verified receipts for them. It is
+// implemented by client.Client.
+type Notarizer interface {
+	Notarize(ctx context.Context, hash []byte) (*client.Receipt, error)
+}
+
+var _ Notarizer = (*client.Client)(nil)
+
+// Proof proves that a hash is included in a local tree whose root is
+// notarized in a Merkle weave.
+type Proof struct {
+	Hash []byte
+
+	// Inclusion proves that Hash is included in the local tree.
+	Inclusion *merkletree.InclusionProof
+
+	// Receipt is the receipt for the root of the local tree.
+	Receipt *client.Receipt
+}
+
+// Verify checks offline that p proves that its hash is included in the local
+// tree and that the root of the local tree is included in s. If s is nil, the
+// summary of the receipt is used.
+func (p *Proof) Verify(s *client.Summary) error {
+	if p.Inclusion == nil || p.Receipt == nil {
+		return errors.New("missing inclusion proof or receipt")
+	}
+	if len(p.Receipt.Data) != merkletree.HashLength {
+		return fmt.Errorf("expected root of %d bytes, got %d", merkletree.HashLength, len(p.Receipt.Data))
+	}
+	root := merkletree.Summary{N: p.Inclusion.N}
+	copy(root.Summary[:], p.Receipt.Data)
+	if err := merkletree.VerifyInclusion(root, p.Hash, p.Inclusion); err != nil {
+		return fmt.Errorf("local tree: %w", err)
+	}
+	return p.Receipt.Verify(s)
+}
+
+// Timestamp returns the timestamp of the notarization of the root.
+func (p *Proof) Timestamp() time.Time {
+	return p.Receipt.Timestamp
+}
+
+// batch is a local tree of hashes to be notarized together.
+type batch struct {
+	t    *merkletree.MerkleTree
+	done chan struct{} // closed when notarized
+
+	receipt *client.Receipt
+	err     error
+}
+
+// Aggregator notarizes batches of hashes.
+type Aggregator struct {
+	n        Notarizer
+	window   time.Duration
+	maxBatch int
+	timeout  time.Duration
+
+	mu    sync.Mutex
+	batch *batch // nil if no batch is open
+}
+
+// Option configures an Aggregator.
+type Option func(*Aggregator)
+
+// WithWindow returns an Option that sets how long a batch collects hashes
+// after its first hash is submitted. The default is one second.
+func WithWindow(d time.Duration) Option {
+	return func(a *Aggregator) { a.window = d }
+}
+
+// WithMaxBatch returns an Option that notarizes a batch as soon as it has n
+// hashes, before its window closes. The default is no limit.
+func WithMaxBatch(n int) Option {
+	return func(a *Aggregator) { a.maxBatch = n }
+}
+
+// WithTimeout returns an Option that sets the timeout for notarizing a batch.
+// The default is 30 seconds.
+func WithTimeout(d time.Duration) Option {
+	return func(a *Aggregator) { a.timeout = d }
+}
+
+// New returns an Aggregator that notarizes batches with n.
+func New(n Notarizer, opts ...Option) *Aggregator {
+	a := &Aggregator{n: n, window: time.Second, timeout: 30 * time.Second}
+	for _, o := range opts {
+		o(a)
+	}
+	return a
+}
+
+// Submit adds hash to the open batch, waits for the batch to be notarized and
+// returns a proof for hash.
+func (a *Aggregator) Submit(ctx context.Context, hash []byte) (*Proof, error) {
+	a.mu.Lock()
+	b := a.batch
+	if b == nil {
+		b = &batch{t: merkletree.New(), done: make(chan struct{})}
+		a.batch = b
+		time.AfterFunc(a.window, func() { a.close(b) })
+	}
+	pos := b.t.Len()
+	b.t.Append(hash)
+	if a.maxBatch > 0 && b.t.Len() >= a.maxBatch {
+		a.batch = nil
+		go a.notarize(b)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	// The tree of a notarized batch is no longer modified.
+	p, err := b.t.ProveInclusion(pos, b.t.Len())
+	if err != nil {
+		return nil, err
+	}
+	return &Proof{Hash: hash, Inclusion: p, Receipt: b.receipt}, nil
+}
+
+// close notarizes b when its window closes, unless it was already notarized
+// for being full.
+func (a *Aggregator) close(b *batch) {
+	a.mu.Lock()
+	if a.batch != b {
+		a.mu.Unlock()
+		return
+	}
+	a.batch = nil
+	a.mu.Unlock()
+	a.notarize(b)
+}
+
+// notarize notarizes the root of b, which must no longer be open.
+func (a *Aggregator) notarize(b *batch) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+	root := b.t.Summary().Summary
+	b.receipt, b.err = a.n.Notarize(ctx, root[:])
+	close(b.done)
+}
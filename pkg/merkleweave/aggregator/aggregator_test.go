@@ -0,0 +1,100 @@
+package aggregator_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/aggregator"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves w over an in-memory connection and returns a client for it.
+func dial(t *testing.T, w *merkleweave.MerkleWeave) servicepb.FabulaClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	servicepb.RegisterFabulaService(s, server.New(w).Service())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return servicepb.NewFabulaClient(conn)
+}
+
+// submit submits n hashes concurrently and returns their proofs.
+func submit(t *testing.T, a *aggregator.Aggregator, n int) []*aggregator.Proof {
+	ctx := context.Background()
+	proofs := make([]*aggregator.Proof, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p, err := a.Submit(ctx, []byte{byte(i), byte(i >> 8), 3, 4})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			proofs[i] = p
+		}(i)
+	}
+	wg.Wait()
+	return proofs
+}
+
+func TestAggregator(t *testing.T) {
+	w := merkleweave.New()
+	c := client.New(dial(t, w))
+	a := aggregator.New(c, aggregator.WithWindow(50*time.Millisecond))
+	proofs := submit(t, a, 200)
+	if t.Failed() {
+		t.FailNow()
+	}
+	if w.Len() >= len(proofs) {
+		t.Errorf("expected fewer than %d entries, got %d", len(proofs), w.Len())
+	}
+	for i, p := range proofs {
+		if err := p.Verify(nil); err != nil {
+			t.Errorf("proof %d: %v", i, err)
+		}
+		if err := p.Verify(c.Pinned()); err != nil {
+			t.Errorf("proof %d against pinned summary: %v", i, err)
+		}
+	}
+
+	// Proofs are bound to their hashes.
+	p := *proofs[0]
+	p.Hash = proofs[1].Hash
+	if err := p.Verify(nil); err == nil {
+		t.Error("expected error for wrong hash")
+	}
+}
+
+func TestMaxBatch(t *testing.T) {
+	w := merkleweave.New()
+	a := aggregator.New(client.New(dial(t, w)), aggregator.WithWindow(time.Hour), aggregator.WithMaxBatch(5))
+	proofs := submit(t, a, 25)
+	if t.Failed() {
+		t.FailNow()
+	}
+	if w.Len() != 5 {
+		t.Errorf("expected 5 entries, got %d", w.Len())
+	}
+	for i, p := range proofs {
+		if p.Inclusion.N != 5 {
+			t.Errorf("proof %d: expected batch of 5, got %d", i, p.Inclusion.N)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package audit verifies the stored entries of a Merkle weave.
+//
+// An audit reads the entries of each tree from storage in order and checks
+// that:
+//
+//   - the node hash of each entry matches the node hash recomputed from its
+//     data and the node hashes and timestamps of its previous entry and left
+//     child, as specified in storage.proto;
+//   - the timestamps of the entries of each tree are strictly increasing,
+//     except for repeats of an entry whose cross trees are the same tree; and
+//   - each entry other than a sentinel appears with the same timestamp in
+//     all of its cross trees.
+//
+// If all checks pass, the audit produces the summary of the Merkle weave
+// recomputed from the stored entries, including the number of notarized
+// entries.
+package audit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/vsekhar/merkleweave/driver"
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/storagepb"
+)
+
+// Problem is an inconsistency found in stored entries.
+type Problem struct {
+	Position merkleweave.Position
+	Err      error
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("entry %x:%d: %v", p.Position.Prefix, p.Position.Index, p.Err)
+}
+
+// Report is the result of an audit.
+type Report struct {
+	Entries   int // including sentinels
+	Sentinels int
+	Notarized int // distinct notarized entries
+	Problems  []Problem
+
+	// Summary is the summary recomputed from the stored entries, or nil if
+	// there are problems.
+	Summary *client.Summary
+}
+
+// OK returns true if the audit found no problems.
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// WriteTo writes a human-readable report to w.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%d entries, %d sentinels, %d notarized\n", r.Entries, r.Sentinels, r.Notarized)
+	for _, p := range r.Problems {
+		fmt.Fprintln(&b, p)
+	}
+	if r.OK() {
+		fmt.Fprintf(&b, "no problems found, high water mark %s\n", r.Summary.HWM().Format(time.RFC3339Nano))
+	} else {
+		fmt.Fprintf(&b, "%d problems found\n", len(r.Problems))
+	}
+	return b.WriteTo(w)
+}
+
+// occurrence identifies the notarization of data at a timestamp.
+type occurrence struct {
+	data string
+	ts   time.Time
+}
+
+// Audit audits the entries stored in d. It returns an error only if entries
+// cannot be read.
+func Audit(d driver.Get) (*Report, error) {
+	r := &Report{}
+	s := &client.Summary{}
+	found := make(map[occurrence][]merkleweave.Position)
+	problem := func(pos merkleweave.Position, format string, a ...interface{}) {
+		r.Problems = append(r.Problems, Problem{Position: pos, Err: fmt.Errorf(format, a...)})
+	}
+
+	for _, p := range merkleweave.Prefixes() {
+		n, err := d.Len(p)
+		if err != nil {
+			return nil, err
+		}
+		t := merkletree.New()
+		es := make([]*storagepb.StorageEntry, 0, n)
+		var last time.Time
+		for i := 0; i < int(n); i++ {
+			pos := merkleweave.Position{Prefix: p, Index: i}
+			e, err := d.Get(p, int64(i))
+			if err != nil {
+				return nil, err
+			}
+			es = append(es, e)
+			r.Entries++
+			data := e.GetDataSha3256()
+			t.Append(data)
+
+			if err := e.GetTimestamp().CheckValid(); err != nil {
+				problem(pos, "invalid timestamp: %v", err)
+				continue
+			}
+			ts := e.GetTimestamp().AsTime()
+			// An entry whose cross trees are the same tree is appended to it
+			// twice with the same timestamp.
+			repeat := i > 0 && ts.Equal(last) && bytes.Equal(data, es[i-1].GetDataSha3256())
+			if i > 0 && !ts.After(last) && !repeat {
+				problem(pos, "timestamp %s not after previous %s", ts.Format(time.RFC3339Nano), last.Format(time.RFC3339Nano))
+			}
+			last = ts
+
+			var prev, left *storagepb.StorageEntry
+			if i > 0 {
+				prev = es[i-1]
+			}
+			if l := merkletree.LeftChild(i); l >= 0 {
+				left = es[l]
+			}
+			if node := merkleweave.StorageNodeHash(data, prev, left); !bytes.Equal(node, e.GetNodeSha3256()) {
+				problem(pos, "node hash mismatch")
+			}
+
+			if _, err := merkleweave.CrossTreePrefixes(data); err != nil {
+				problem(pos, "%v", err)
+				continue
+			}
+			o := occurrence{data: string(data), ts: ts}
+			found[o] = append(found[o], pos)
+		}
+		s.Trees = append(s.Trees, client.Tree{Prefix: p, Summary: t.Summary(), Last: last, Peaks: t.Peaks(t.Len())})
+	}
+
+	// Check cross trees, in position order for a deterministic report.
+	var missing []Problem
+	for o, ps := range found {
+		expected, _ := merkleweave.CrossTreePrefixes([]byte(o.data))
+		if sameTrees(ps, expected) {
+			r.Notarized++
+			continue
+		}
+		if sentinel, _ := merkleweave.Sentinel(ps[0].Prefix); o.data == string(sentinel) && len(ps) == 1 {
+			r.Sentinels++
+			continue
+		}
+		for _, pos := range ps {
+			missing = append(missing, Problem{Position: pos, Err: fmt.Errorf("found in trees %s, expected %x", treesOf(ps), expected)})
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		a, b := missing[i].Position, missing[j].Position
+		if c := bytes.Compare(a.Prefix, b.Prefix); c != 0 {
+			return c < 0
+		}
+		return a.Index < b.Index
+	})
+	r.Problems = append(r.Problems, missing...)
+
+	if r.OK() {
+		s.Entries = r.Notarized
+		r.Summary = s
+	}
+	return r, nil
+}
+
+// sameTrees returns true if ps are positions in the trees with prefixes
+// expected, counting repeated prefixes.
+func sameTrees(ps []merkleweave.Position, expected [][]byte) bool {
+	if len(ps) != len(expected) {
+		return false
+	}
+	got := make(map[string]int)
+	for _, pos := range ps {
+		got[string(pos.Prefix)]++
+	}
+	for _, p := range expected {
+		got[string(p)]--
+	}
+	for _, n := range got {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func treesOf(ps []merkleweave.Position) string {
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, pos := range ps {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%x", pos.Prefix)
+	}
+	b.WriteByte(']')
+	return b.String()
+}
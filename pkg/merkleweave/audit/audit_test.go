@@ -0,0 +1,142 @@
+package audit_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/driver/memdriver"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/audit"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/storagepb"
+)
+
+func newStorage(t *testing.T) *memdriver.Driver {
+	d := memdriver.New()
+	m, err := merkleweave.Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := m.Notarize([]byte{byte(i), byte(i * 7), 3, 4}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := m.Notarize([]byte{5, 0x23, byte(i), 4}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.Advance([]byte{0xfe}, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// copyStorage copies d, calling f on each entry. Entries for which f returns
+// nil are dropped.
+func copyStorage(t *testing.T, d *memdriver.Driver, f func(p []byte, i int64, e *storagepb.StorageEntry) *storagepb.StorageEntry) *memdriver.Driver {
+	d2 := memdriver.New()
+	for _, p := range merkleweave.Prefixes() {
+		n, _ := d.Len(p)
+		for i := int64(0); i < n; i++ {
+			e, err := d.Get(p, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if e = f(p, i, e); e != nil {
+				d2.WriteNext(p, e)
+			}
+		}
+	}
+	return d2
+}
+
+func TestAudit(t *testing.T) {
+	d := newStorage(t)
+	r, err := audit.Audit(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.OK() {
+		t.Fatalf("expected no problems, got %v", r.Problems)
+	}
+	if r.Entries != 207 || r.Sentinels != 1 {
+		t.Errorf("expected 207 entries and 1 sentinel, got %d and %d", r.Entries, r.Sentinels)
+	}
+	m, err := merkleweave.Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := m.Summary()
+	for _, tr := range r.Summary.Trees {
+		ts, last, err := s.Tree(tr.Prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ts.Equals(tr.Summary) || !last.Equal(tr.Last) {
+			t.Errorf("tree %x: expected %s at %s, got %s at %s", tr.Prefix, ts, last, tr.Summary, tr.Last)
+		}
+	}
+	// The recomputed summary, including the number of entries, has the same
+	// digest as the summary of the Merkle weave.
+	if r.Notarized != 103 {
+		t.Errorf("expected 103 notarized entries, got %d", r.Notarized)
+	}
+	w, err := r.Summary.Weave()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Digest() != s.Digest() {
+		t.Error("expected digest of recomputed summary to match")
+	}
+	var b bytes.Buffer
+	r.WriteTo(&b)
+	if !strings.Contains(b.String(), "no problems found") {
+		t.Errorf("unexpected report: %s", b.String())
+	}
+}
+
+func TestAuditProblems(t *testing.T) {
+	d := newStorage(t)
+	for _, c := range []struct {
+		name string
+		f    func(p []byte, i int64, e *storagepb.StorageEntry) *storagepb.StorageEntry
+		want string
+	}{
+		{"data", func(p []byte, i int64, e *storagepb.StorageEntry) *storagepb.StorageEntry {
+			if p[0] == 5 && i == 0 {
+				e.DataSha3256 = append([]byte{5}, e.DataSha3256[1:]...)
+				e.DataSha3256[3]++
+			}
+			return e
+		}, "entry 05:0: node hash mismatch"},
+		{"timestamp", func(p []byte, i int64, e *storagepb.StorageEntry) *storagepb.StorageEntry {
+			if p[0] == 5 && i == 1 {
+				e.Timestamp.Seconds -= 3600
+			}
+			return e
+		}, "entry 05:1: timestamp"},
+		{"missing", func(p []byte, i int64, e *storagepb.StorageEntry) *storagepb.StorageEntry {
+			if p[0] == 0x23 {
+				return nil
+			}
+			return e
+		}, "expected [05 23]"},
+	} {
+		r, err := audit.Audit(copyStorage(t, d, c.f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.OK() || r.Summary != nil {
+			t.Errorf("%s: expected problems", c.name)
+			continue
+		}
+		var b bytes.Buffer
+		r.WriteTo(&b)
+		if !strings.Contains(b.String(), c.want) {
+			t.Errorf("%s: expected %q in report:\n%s", c.name, c.want, b.String())
+		}
+	}
+}
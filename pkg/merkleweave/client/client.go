@@ -0,0 +1,578 @@
+// Package client provides a Fabula client that verifies the responses of the
+// server.
+//
+// A Client pins the last summary of the Merkle weave it has verified. Each new
+// summary is checked to be consistent with the pinned summary before it is
+// pinned in turn, and each receipt is checked to be included in a verified
+// summary. If the client has a key ring, each summary must also be signed by
+// one of its keys. If the client requires witnesses, each summary must also
+// be cosigned by a quorum of them (see WithWitnesses). Responses that fail
+// verification result in errors that match ErrMisbehavior.
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrMisbehavior matches all errors returned when the server misbehaves.
+var ErrMisbehavior = errors.New("server misbehavior")
+
+// ResponseError is returned when the server sends a malformed response.
+type ResponseError struct {
+	Err error
+}
+
+func (e *ResponseError) Error() string { return "malformed response: " + e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *ResponseError) Unwrap() error { return e.Err }
+
+// Is returns true if target is ErrMisbehavior.
+func (e *ResponseError) Is(target error) bool { return target == ErrMisbehavior }
+
+// InconsistencyError is returned when a new summary of a tree is not
+// consistent with the pinned summary of that tree.
+type InconsistencyError struct {
+	Prefix   []byte
+	Old, New Tree
+	Err      error
+
+	// OldSummary and NewSummary are the summaries of all trees that include
+	// Old and New, if known. They can be used as evidence in a fraud proof if
+	// they are signed.
+	OldSummary, NewSummary *Summary
+}
+
+func (e *InconsistencyError) Error() string {
+	return fmt.Sprintf("tree %x: summary %s inconsistent with %s: %v", e.Prefix, e.New.Summary, e.Old.Summary, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *InconsistencyError) Unwrap() error { return e.Err }
+
+// Is returns true if target is ErrMisbehavior.
+func (e *InconsistencyError) Is(target error) bool { return target == ErrMisbehavior }
+
+// TimestampError is returned when the timestamp of a tree or an entry is not
+// as required.
+type TimestampError struct {
+	Prefix []byte
+	Err    error
+}
+
+func (e *TimestampError) Error() string {
+	return fmt.Sprintf("tree %x: %v", e.Prefix, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *TimestampError) Unwrap() error { return e.Err }
+
+// Is returns true if target is ErrMisbehavior.
+func (e *TimestampError) Is(target error) bool { return target == ErrMisbehavior }
+
+// SignatureError is returned when a summary or receipt is not signed by a
+// trusted key.
+type SignatureError struct {
+	Err error
+}
+
+func (e *SignatureError) Error() string { return "signature: " + e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *SignatureError) Unwrap() error { return e.Err }
+
+// Is returns true if target is ErrMisbehavior.
+func (e *SignatureError) Is(target error) bool { return target == ErrMisbehavior }
+
+// InclusionError is returned when an entry cannot be proven to be included in
+// a verified summary.
+type InclusionError struct {
+	Position merkleweave.Position
+	Err      error
+}
+
+func (e *InclusionError) Error() string {
+	return fmt.Sprintf("entry %x:%d: %v", e.Position.Prefix, e.Position.Index, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *InclusionError) Unwrap() error { return e.Err }
+
+// Is returns true if target is ErrMisbehavior.
+func (e *InclusionError) Is(target error) bool { return target == ErrMisbehavior }
+
+// CountError is returned when the number of entries in a new summary is less
+// than in the pinned summary.
+type CountError struct {
+	Old, New int
+}
+
+func (e *CountError) Error() string {
+	return fmt.Sprintf("summary has %d entries, fewer than %d", e.New, e.Old)
+}
+
+// Is returns true if target is ErrMisbehavior.
+func (e *CountError) Is(target error) bool { return target == ErrMisbehavior }
+
+// Tree is a verified summary of a tree of a Merkle weave.
+type Tree struct {
+	Prefix  []byte
+	Summary merkletree.Summary
+	Last    time.Time // zero if the tree is empty
+	Peaks   [][merkletree.HashLength]byte
+}
+
+// PeakSummary returns the summary of t with its peaks.
+func (t Tree) PeakSummary() merkletree.PeakSummary {
+	return merkletree.PeakSummary{N: t.Summary.N, Peaks: t.Peaks, Alg: t.Summary.Alg}
+}
+
+// Summary is a verified summary of all trees of a Merkle weave.
+type Summary struct {
+	Trees []Tree // in prefix order
+
+	// Entries is the number of entries notarized, excluding sentinels. It is
+	// covered by signatures, but can only be fully verified by an audit.
+	Entries int
+
+	// Signature is the signature of the summary by the operator, if any. It
+	// is verified only by clients with a key ring.
+	Signature *signing.Signature
+
+	// Cosignatures are cosignatures of the summary by witnesses. They are
+	// verified only by clients that require them.
+	Cosignatures []signing.Signature
+
+	// Log is the position of the summary in the summary log of the server,
+	// if it keeps one.
+	Log *LogEntry
+}
+
+// Weave returns s as a merkleweave.Summary.
+func (s *Summary) Weave() (*merkleweave.Summary, error) {
+	ss := make([]merkletree.Summary, len(s.Trees))
+	last := make([]time.Time, len(s.Trees))
+	for i, t := range s.Trees {
+		ss[i], last[i] = t.Summary, t.Last
+	}
+	return merkleweave.NewSummary(s.Entries, ss, last)
+}
+
+// VerifySignature verifies that s is signed by a key in k.
+func (s *Summary) VerifySignature(k signing.KeyRing) error {
+	if s.Signature == nil {
+		return errors.New("summary is not signed")
+	}
+	w, err := s.Weave()
+	if err != nil {
+		return err
+	}
+	return k.Verify(w, *s.Signature)
+}
+
+// VerifyCosignatures verifies that s is cosigned by at least q keys in k.
+func (s *Summary) VerifyCosignatures(k signing.KeyRing, q int) error {
+	w, err := s.Weave()
+	if err != nil {
+		return err
+	}
+	return k.VerifyQuorum(w, s.Cosignatures, q)
+}
+
+// Tree returns the summary of the tree with prefix p.
+func (s *Summary) Tree(p []byte) (Tree, bool) {
+	for _, t := range s.Trees {
+		if bytes.Equal(t.Prefix, p) {
+			return t, true
+		}
+	}
+	return Tree{}, false
+}
+
+// HWM returns the high water mark of the summary, the minimum timestamp of the
+// last entries of all trees.
+func (s *Summary) HWM() time.Time {
+	var hwm time.Time
+	for i, t := range s.Trees {
+		if i == 0 || t.Last.Before(hwm) {
+			hwm = t.Last
+		}
+	}
+	return hwm
+}
+
+// EmptySummary returns the summary of an empty Merkle weave.
+func EmptySummary() *Summary {
+	s := &Summary{}
+	for _, p := range merkleweave.Prefixes() {
+		s.Trees = append(s.Trees, Tree{Prefix: p, Summary: merkletree.EmptyTreeSummary})
+	}
+	return s
+}
+
+// Receipt is a verified receipt for an entry in a Merkle weave.
+type Receipt struct {
+	merkleweave.Receipt
+
+	// Summary is the verified summary the entry is proven to be included in.
+	Summary *Summary
+
+	// Proofs are the proofs of inclusion of the entry in each of its cross
+	// trees in Summary.
+	Proofs []*merkletree.InclusionProof
+
+	// Signature is the signature of the receipt by the operator, if any. It
+	// is verified only by clients with a key ring.
+	Signature *signing.Signature
+}
+
+// Client is a Fabula client that verifies responses of the server.
+type Client struct {
+	c    servicepb.FabulaClient
+	keys signing.KeyRing
+
+	witnesses   []Witness
+	witnessKeys signing.KeyRing
+	quorum      int
+
+	mu     sync.Mutex
+	pinned *Summary
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithKeyRing returns an Option that requires summaries to be signed by a key
+// in k.
+func WithKeyRing(k signing.KeyRing) Option {
+	return func(c *Client) { c.keys = k }
+}
+
+// New returns a Client that pins the summary of an empty Merkle weave.
+func New(c servicepb.FabulaClient, opts ...Option) *Client {
+	return NewWithSummary(c, EmptySummary(), opts...)
+}
+
+// NewWithSummary returns a Client that pins s, which must be a summary
+// previously verified by a Client.
+func NewWithSummary(c servicepb.FabulaClient, s *Summary, opts ...Option) *Client {
+	cl := &Client{c: c, pinned: s}
+	for _, o := range opts {
+		o(cl)
+	}
+	return cl
+}
+
+// Pinned returns the last verified summary.
+func (c *Client) Pinned() *Summary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pinned
+}
+
+// Summary requests a new summary from the server whose trees have all advanced
+// past minTimestamp, verifies it is consistent with the pinned summary, and
+// pins it. If minTimestamp is zero, trees are not required to advance.
+func (c *Client) Summary(ctx context.Context, minTimestamp time.Time) (*Summary, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	req := &servicepb.WeaveSummaryRequest{}
+	if !minTimestamp.IsZero() {
+		req.MinTimestamp = timestamppb.New(minTimestamp)
+	}
+	resp, err := c.c.WeaveSummary(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s, err := DecodeSummary(resp)
+	if err != nil {
+		return nil, err
+	}
+	if c.keys != nil {
+		if err := s.VerifySignature(c.keys); err != nil {
+			return nil, &SignatureError{Err: err}
+		}
+	}
+	for i, t := range s.Trees {
+		old := c.pinned.Trees[i]
+		if err := c.VerifyConsistency(ctx, old, t); err != nil {
+			var ie *InconsistencyError
+			if errors.As(err, &ie) {
+				ie.OldSummary, ie.NewSummary = c.pinned, s
+			}
+			return nil, err
+		}
+		if !minTimestamp.IsZero() && !t.Last.After(minTimestamp) {
+			return nil, &TimestampError{Prefix: t.Prefix, Err: fmt.Errorf("last entry at %s, not after %s", t.Last, minTimestamp)}
+		}
+		if t.Last.Before(old.Last) {
+			return nil, &TimestampError{Prefix: t.Prefix, Err: fmt.Errorf("last entry at %s, before %s", t.Last, old.Last)}
+		}
+	}
+	if s.Entries < c.pinned.Entries {
+		return nil, &CountError{Old: c.pinned.Entries, New: s.Entries}
+	}
+	if err := c.verifyLog(ctx, c.pinned, s); err != nil {
+		return nil, err
+	}
+	if c.quorum > 0 {
+		if err := c.cosign(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	c.pinned = s
+	return s, nil
+}
+
+// VerifyConsistency verifies that new is consistent with old, two summaries of
+// the same tree, requesting a consistency proof from the server if needed.
+// The summaries need not have been returned by the server. It returns an
+// InconsistencyError if they are not consistent, including if new is smaller
+// than old.
+func (c *Client) VerifyConsistency(ctx context.Context, old, new Tree) error {
+	inconsistent := func(err error) error {
+		return &InconsistencyError{Prefix: new.Prefix, Old: old, New: new, Err: err}
+	}
+	switch {
+	case new.Summary.N < old.Summary.N:
+		return inconsistent(errors.New("tree shrank"))
+	case new.Summary.N == old.Summary.N:
+		if !new.Summary.Equals(old.Summary) {
+			return inconsistent(errors.New("same size, different hash"))
+		}
+		return nil
+	}
+	resp, err := c.c.ConsistencyProof(ctx, &servicepb.ConsistencyProofRequest{
+		Prefix: new.Prefix,
+		From:   uint64(old.Summary.N),
+		To:     uint64(new.Summary.N),
+	})
+	if err != nil {
+		return err
+	}
+	p, err := decodeConsistencyProof(resp)
+	if err != nil {
+		return err
+	}
+	if err := merkletree.VerifyConsistency(old.Summary, new.Summary, p); err != nil {
+		return inconsistent(err)
+	}
+	return nil
+}
+
+// Notarize notarizes hash and verifies that it is included in a new summary,
+// which is pinned.
+func (c *Client) Notarize(ctx context.Context, hash []byte) (*Receipt, error) {
+	if _, err := merkleweave.CrossTreePrefixes(hash); err != nil {
+		return nil, err
+	}
+	resp, err := c.c.Notarize(ctx, &servicepb.NotarizeRequest{Hash: hash})
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(resp.GetHash(), hash) {
+		return nil, &ResponseError{Err: fmt.Errorf("receipt for %x, expected %x", resp.GetHash(), hash)}
+	}
+	if err := resp.GetTimestamp().CheckValid(); err != nil {
+		return nil, &ResponseError{Err: err}
+	}
+	r := merkleweave.Receipt{
+		Data:      hash,
+		Timestamp: resp.GetTimestamp().AsTime(),
+	}
+	for _, p := range resp.GetPositions() {
+		r.Positions = append(r.Positions, merkleweave.Position{Prefix: p.GetPrefix(), Index: int(p.GetIndex())})
+	}
+	var sig *signing.Signature
+	if resp.GetSignature() != nil {
+		v, err := signing.Decode(resp.GetSignature())
+		if err != nil {
+			return nil, &ResponseError{Err: fmt.Errorf("signature: %v", err)}
+		}
+		sig = &v
+	}
+	if c.keys != nil {
+		sr := Receipt{Receipt: r, Signature: sig}
+		if err := sr.VerifySignature(c.keys); err != nil {
+			return nil, &SignatureError{Err: err}
+		}
+	}
+	old := c.Pinned()
+	if _, err := c.Summary(ctx, time.Time{}); err != nil {
+		return nil, err
+	}
+	for _, p := range r.Positions {
+		t, ok := old.Tree(p.Prefix)
+		if ok && p.Index >= t.Summary.N && !r.Timestamp.After(t.Last) {
+			return nil, &TimestampError{Prefix: p.Prefix, Err: fmt.Errorf("new entry at %s, not after %s", r.Timestamp, t.Last)}
+		}
+	}
+	vr, err := c.VerifyReceipt(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	vr.Signature = sig
+	return vr, nil
+}
+
+// VerifyReceipt verifies that the entry of r is included in the pinned summary
+// and returns a verified receipt.
+func (c *Client) VerifyReceipt(ctx context.Context, r merkleweave.Receipt) (*Receipt, error) {
+	prefixes, err := merkleweave.CrossTreePrefixes(r.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Positions) != len(prefixes) {
+		return nil, &ResponseError{Err: fmt.Errorf("expected %d positions, got %d", len(prefixes), len(r.Positions))}
+	}
+	s := c.Pinned()
+	vr := &Receipt{Receipt: r, Summary: s}
+	for i, pos := range r.Positions {
+		if !bytes.Equal(pos.Prefix, prefixes[i]) {
+			return nil, &ResponseError{Err: fmt.Errorf("position in tree %x, expected %x", pos.Prefix, prefixes[i])}
+		}
+		t, _ := s.Tree(pos.Prefix)
+		if pos.Index >= t.Summary.N {
+			return nil, &InclusionError{Position: pos, Err: fmt.Errorf("not in tree of size %d", t.Summary.N)}
+		}
+		if r.Timestamp.After(t.Last) {
+			return nil, &TimestampError{Prefix: pos.Prefix, Err: fmt.Errorf("entry at %s, after last entry at %s", r.Timestamp, t.Last)}
+		}
+		resp, err := c.c.InclusionProof(ctx, &servicepb.InclusionProofRequest{
+			Position: &servicepb.Position{Prefix: pos.Prefix, Index: uint64(pos.Index)},
+			Size:     uint64(t.Summary.N),
+		})
+		if err != nil {
+			return nil, err
+		}
+		p, err := decodeInclusionProof(resp)
+		if err != nil {
+			return nil, err
+		}
+		if p.Pos != pos.Index {
+			return nil, &ResponseError{Err: fmt.Errorf("proof for index %d, expected %d", p.Pos, pos.Index)}
+		}
+		if err := merkletree.VerifyInclusion(t.Summary, r.Data, p); err != nil {
+			return nil, &InclusionError{Position: pos, Err: err}
+		}
+		vr.Proofs = append(vr.Proofs, p)
+	}
+	return vr, nil
+}
+
+// UpgradeReceipt returns a receipt for the entry of r whose proofs verify
+// against s, a newer summary verified by a Client such as the pinned summary.
+// The proofs of r are extended with proofs of consistency from the summary of
+// r to s requested from the server, so the entry need not be proven again.
+func (c *Client) UpgradeReceipt(ctx context.Context, r *Receipt, s *Summary) (*Receipt, error) {
+	if err := r.Verify(nil); err != nil {
+		return nil, err
+	}
+	up := &Receipt{Receipt: r.Receipt, Summary: s, Signature: r.Signature}
+	for i, pos := range r.Positions {
+		old, _ := r.Summary.Tree(pos.Prefix)
+		new, ok := s.Tree(pos.Prefix)
+		if !ok {
+			return nil, fmt.Errorf("no tree %x in summary", pos.Prefix)
+		}
+		if new.Summary.N < old.Summary.N {
+			return nil, fmt.Errorf("tree %x: summary of size %d older than receipt of size %d", pos.Prefix, new.Summary.N, old.Summary.N)
+		}
+		resp, err := c.c.ConsistencyProof(ctx, &servicepb.ConsistencyProofRequest{
+			Prefix: pos.Prefix,
+			From:   uint64(old.Summary.N),
+			To:     uint64(new.Summary.N),
+		})
+		if err != nil {
+			return nil, err
+		}
+		cp, err := decodeConsistencyProof(resp)
+		if err != nil {
+			return nil, err
+		}
+		if err := merkletree.VerifyConsistency(old.Summary, new.Summary, cp); err != nil {
+			return nil, &InconsistencyError{Prefix: pos.Prefix, Old: old, New: new, Err: err, OldSummary: r.Summary, NewSummary: s}
+		}
+		p, err := merkletree.UpgradeInclusion(r.Proofs[i], cp)
+		if err != nil {
+			return nil, &ResponseError{Err: err}
+		}
+		up.Proofs = append(up.Proofs, p)
+	}
+	if err := up.Verify(nil); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+// VerifySigned verifies r against s as Verify does, and that r is signed by a
+// key in k, so that its timestamp can be trusted as the operator's.
+func (r *Receipt) VerifySigned(s *Summary, k signing.KeyRing) error {
+	if err := r.Verify(s); err != nil {
+		return err
+	}
+	return r.VerifySignature(k)
+}
+
+// VerifySignature verifies that r is signed by a key in k.
+func (r *Receipt) VerifySignature(k signing.KeyRing) error {
+	if r.Signature == nil {
+		return errors.New("receipt is not signed")
+	}
+	return k.VerifyReceipt(&r.Receipt, *r.Signature)
+}
+
+// Verify checks offline that the entry of r is included in s at the positions
+// of r, using the proofs of r, and that its timestamp precedes the last entry
+// of each of its trees in s. If s is nil, the summary of r is used.
+//
+// Timestamps of entries are not committed to by the trees, so Verify only
+// checks that the entry was included no later than the last entries of its
+// trees. The timestamp of r itself is vouched for only by the signature of the
+// operator; use VerifySigned to trust it.
+func (r *Receipt) Verify(s *Summary) error {
+	if s == nil {
+		s = r.Summary
+	}
+	if s == nil {
+		return errors.New("no summary")
+	}
+	prefixes, err := merkleweave.CrossTreePrefixes(r.Data)
+	if err != nil {
+		return err
+	}
+	if len(r.Positions) != len(prefixes) || len(r.Proofs) != len(prefixes) {
+		return fmt.Errorf("expected %d positions and proofs, got %d and %d", len(prefixes), len(r.Positions), len(r.Proofs))
+	}
+	for i, pos := range r.Positions {
+		if !bytes.Equal(pos.Prefix, prefixes[i]) {
+			return fmt.Errorf("position in tree %x, expected %x", pos.Prefix, prefixes[i])
+		}
+		t, ok := s.Tree(pos.Prefix)
+		if !ok {
+			return fmt.Errorf("no tree %x in summary", pos.Prefix)
+		}
+		p := r.Proofs[i]
+		if p.Pos != pos.Index {
+			return fmt.Errorf("proof for index %d, expected %d", p.Pos, pos.Index)
+		}
+		if err := merkletree.VerifyInclusion(t.Summary, r.Data, p); err != nil {
+			return &InclusionError{Position: pos, Err: err}
+		}
+		if r.Timestamp.After(t.Last) {
+			return &TimestampError{Prefix: pos.Prefix, Err: fmt.Errorf("entry at %s, after last entry at %s", r.Timestamp, t.Last)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dial serves w over an in-memory connection and returns a client for it.
+func dial(t *testing.T, w *merkleweave.MerkleWeave, opts ...server.Option) servicepb.FabulaClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	servicepb.RegisterFabulaService(s, server.New(w, opts...).Service())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return servicepb.NewFabulaClient(conn)
+}
+
+// switcher forwards requests to a FabulaClient that can be switched.
+type switcher struct {
+	servicepb.FabulaClient
+}
+
+func TestNotarize(t *testing.T) {
+	ctx := context.Background()
+	c := client.New(dial(t, merkleweave.New()))
+	for i := 0; i < 10; i++ {
+		r, err := c.Notarize(ctx, []byte{byte(i), 2, 3, 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Proofs) != 2 {
+			t.Errorf("expected 2 proofs, got %d", len(r.Proofs))
+		}
+		if r.Summary != c.Pinned() {
+			t.Error("expected receipt against pinned summary")
+		}
+	}
+
+	min := time.Now()
+	time.Sleep(time.Millisecond)
+	s, err := c.Summary(ctx, min)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.HWM().After(min) {
+		t.Errorf("HWM %s not after %s", s.HWM(), min)
+	}
+}
+
+func TestFork(t *testing.T) {
+	ctx := context.Background()
+	w1, w2 := merkleweave.New(), merkleweave.New()
+	w1.Append([]byte{1, 2, 3, 4})
+	w2.Append([]byte{1, 2, 3, 5})
+	w2.Append([]byte{1, 2, 3, 6})
+	sw := &switcher{dial(t, w1)}
+	c := client.New(sw)
+	if _, err := c.Summary(ctx, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	sw.FabulaClient = dial(t, w2)
+	_, err := c.Summary(ctx, time.Time{})
+	var ie *client.InconsistencyError
+	if !errors.As(err, &ie) || !errors.Is(err, client.ErrMisbehavior) {
+		t.Fatalf("expected InconsistencyError, got %v", err)
+	}
+	if ie.Prefix[0] != 1 {
+		t.Errorf("expected inconsistency in tree 01, got %x", ie.Prefix)
+	}
+}
+
+func TestShrink(t *testing.T) {
+	ctx := context.Background()
+	w1, w2 := merkleweave.New(), merkleweave.New()
+	w1.Append([]byte{1, 2, 3, 4})
+	sw := &switcher{dial(t, w1)}
+	c := client.New(sw)
+	if _, err := c.Summary(ctx, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	sw.FabulaClient = dial(t, w2)
+	if _, err := c.Summary(ctx, time.Time{}); !errors.Is(err, client.ErrMisbehavior) {
+		t.Fatalf("expected misbehavior, got %v", err)
+	}
+}
+
+// liar claims entries are in a different tree.
+type liar struct {
+	servicepb.FabulaClient
+}
+
+func (l liar) Notarize(ctx context.Context, in *servicepb.NotarizeRequest, opts ...grpc.CallOption) (*servicepb.NotarizeResponse, error) {
+	resp, err := l.FabulaClient.Notarize(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	resp.Positions[1].Index++
+	return resp, nil
+}
+
+func TestBadReceipt(t *testing.T) {
+	ctx := context.Background()
+	w := merkleweave.New()
+	w.Append([]byte{9, 2, 3, 4})
+	w.Append([]byte{9, 2, 3, 4})
+	c := client.New(liar{dial(t, w)})
+	_, err := c.Notarize(ctx, []byte{1, 2, 3, 4})
+	if !errors.Is(err, client.ErrMisbehavior) {
+		t.Fatalf("expected misbehavior, got %v", err)
+	}
+}
+
+func TestUpgradeReceipt(t *testing.T) {
+	ctx := context.Background()
+	w := merkleweave.New()
+	c := client.New(dial(t, w))
+	r, err := c.Notarize(ctx, []byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		w.Append([]byte{byte(i), 2, 1, 4})
+	}
+	s, err := c.Summary(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, err := c.UpgradeReceipt(ctx, r, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := up.Verify(s); err != nil {
+		t.Error(err)
+	}
+	if err := r.Verify(s); err == nil {
+		t.Error("expected original receipt not to verify against new summary")
+	}
+	if _, err := c.UpgradeReceipt(ctx, up, r.Summary); err == nil {
+		t.Error("expected error upgrading to an older summary")
+	}
+}
+
+// miscount adds delta to the number of entries in summaries.
+type miscount struct {
+	servicepb.FabulaClient
+	delta int64
+}
+
+func (m miscount) WeaveSummary(ctx context.Context, in *servicepb.WeaveSummaryRequest, opts ...grpc.CallOption) (*servicepb.WeaveSummaryResponse, error) {
+	resp, err := m.FabulaClient.WeaveSummary(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	resp.Entries = uint64(int64(resp.Entries) + m.delta)
+	return resp, nil
+}
+
+func TestEntries(t *testing.T) {
+	ctx := context.Background()
+	w := merkleweave.New()
+	w.Append([]byte{1, 2, 3, 4})
+	w.Append([]byte{5, 5, 5, 5})
+	fc := dial(t, w)
+	c := client.New(fc)
+	s, err := c.Summary(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Entries != 2 {
+		t.Errorf("expected 2 entries, got %d", s.Entries)
+	}
+
+	// More entries than fit in the trees.
+	var re *client.ResponseError
+	if _, err := client.New(miscount{fc, 1}).Summary(ctx, time.Time{}); !errors.As(err, &re) {
+		t.Errorf("expected malformed response, got %v", err)
+	}
+
+	// Fewer entries than the pinned summary.
+	w.Append([]byte{6, 7, 8, 9})
+	c2 := client.NewWithSummary(miscount{fc, -2}, s)
+	var ce *client.CountError
+	if _, err := c2.Summary(ctx, time.Time{}); !errors.As(err, &ce) || !errors.Is(err, client.ErrMisbehavior) {
+		t.Errorf("expected count error, got %v", err)
+	}
+}
+
+func TestSignedSummary(t *testing.T) {
+	ctx := context.Background()
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := merkleweave.New()
+	w.Append([]byte{1, 2, 3, 4})
+	fc := dial(t, w, server.WithSigner(signing.NewSigner(key)))
+
+	c := client.New(fc, client.WithKeyRing(signing.NewKeyRing(pub)))
+	s, err := c.Summary(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Signature == nil {
+		t.Fatal("expected signature")
+	}
+
+	// Signatures survive JSON encoding.
+	b, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s2 client.Summary
+	if err := json.Unmarshal(b, &s2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.VerifySignature(signing.NewKeyRing(pub)); err != nil {
+		t.Fatal(err)
+	}
+	s2.Trees[1].Last = s2.Trees[1].Last.Add(time.Second)
+	if err := s2.VerifySignature(signing.NewKeyRing(pub)); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature, got %v", err)
+	}
+
+	// Receipts are signed, and signatures survive JSON encoding.
+	r, err := c.Notarize(ctx, []byte{5, 6, 7, 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err = json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r2 client.Receipt
+	if err := json.Unmarshal(b, &r2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.VerifySignature(signing.NewKeyRing(pub)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.VerifySigned(nil, signing.NewKeyRing(pub)); err != nil {
+		t.Fatal(err)
+	}
+	// Backdated receipts still verify, but not their signatures.
+	r2.Timestamp = r2.Timestamp.Add(-time.Nanosecond)
+	if err := r2.Verify(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.VerifySigned(nil, signing.NewKeyRing(pub)); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad receipt signature, got %v", err)
+	}
+
+	c = client.New(fc, client.WithKeyRing(signing.NewKeyRing(other)))
+	if _, err := c.Summary(ctx, time.Time{}); !errors.Is(err, signing.ErrUnknownKey) || !errors.Is(err, client.ErrMisbehavior) {
+		t.Errorf("expected unknown key, got %v", err)
+	}
+	c = client.New(dial(t, w), client.WithKeyRing(signing.NewKeyRing(pub)))
+	if _, err := c.Summary(ctx, time.Time{}); !errors.Is(err, client.ErrMisbehavior) {
+		t.Errorf("expected misbehavior for unsigned summary, got %v", err)
+	}
+	if _, err := c.Notarize(ctx, []byte{5, 6, 7, 9}); !errors.Is(err, client.ErrMisbehavior) {
+		t.Errorf("expected misbehavior for unsigned receipt, got %v", err)
+	}
+}
+
+func TestSummaryLog(t *testing.T) {
+	ctx := context.Background()
+	w := merkleweave.New()
+	fc := dial(t, w, server.WithSummaryLog(merkleweave.NewSummaryLog()))
+	c := client.New(fc)
+	w.Append([]byte{1, 2, 3, 4})
+	old, err := c.Summary(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Log == nil || old.Log.Index != 0 {
+		t.Fatalf("expected log entry 0, got %+v", old.Log)
+	}
+	for i := 0; i < 3; i++ {
+		w.Append([]byte{byte(i), 5, 6, 7})
+		if _, err := c.Summary(ctx, time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := c.ProveSummary(ctx, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Proofs verify offline, including after JSON encoding.
+	b, err := json.Marshal(c.Pinned())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s client.Summary
+	if err := json.Unmarshal(b, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Log.Head != c.Pinned().Log.Head {
+		t.Errorf("expected log head %v, got %v", c.Pinned().Log.Head, s.Log.Head)
+	}
+	if err := s.VerifyIncludes(old, p); err != nil {
+		t.Error(err)
+	}
+	if err := s.VerifyIncludes(&s, p); !errors.Is(err, client.ErrMisbehavior) {
+		t.Errorf("expected error for wrong summary, got %v", err)
+	}
+
+	// A server with a different summary log is detected.
+	fc2 := dial(t, w, server.WithSummaryLog(merkleweave.NewSummaryLog()))
+	sw := &switcher{fc}
+	c = client.New(sw)
+	if _, err := c.Summary(ctx, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	sw.FabulaClient = fc2
+	var le *client.SummaryLogError
+	if _, err := c.Summary(ctx, time.Time{}); !errors.As(err, &le) {
+		t.Errorf("expected summary log error, got %v", err)
+	}
+}
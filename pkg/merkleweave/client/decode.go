@@ -0,0 +1,186 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+)
+
+func malformed(format string, a ...interface{}) error {
+	return &ResponseError{Err: fmt.Errorf(format, a...)}
+}
+
+func decodeHash(b []byte) ([merkletree.HashLength]byte, error) {
+	var h [merkletree.HashLength]byte
+	if len(b) != len(h) {
+		return h, malformed("expected hash of %d bytes, got %d", len(h), len(b))
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+func decodeHashes(bs [][]byte) ([][merkletree.HashLength]byte, error) {
+	r := make([][merkletree.HashLength]byte, len(bs))
+	for i, b := range bs {
+		h, err := decodeHash(b)
+		if err != nil {
+			return nil, err
+		}
+		r[i] = h
+	}
+	return r, nil
+}
+
+func decodePath(p *servicepb.ProofPath) ([]merkletree.Step, error) {
+	var r []merkletree.Step
+	for _, s := range p.GetSteps() {
+		h, err := decodeHash(s.GetSibling())
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, merkletree.Step{Sibling: h, Data: s.GetData()})
+	}
+	return r, nil
+}
+
+// decodeAlgorithm decodes the hash algorithm of a tree or proof.
+func decodeAlgorithm(a uint32) (merkletree.Algorithm, error) {
+	if a > 0xff || !merkletree.Algorithm(a).Valid() {
+		return 0, malformed("unknown hash algorithm %d", a)
+	}
+	return merkletree.Algorithm(a), nil
+}
+
+// decodePeakSummary decodes the size, peaks and algorithm of t.
+func decodePeakSummary(t *servicepb.TreeSummaryResponse) (merkletree.PeakSummary, error) {
+	alg, err := decodeAlgorithm(t.GetAlgorithm())
+	if err != nil {
+		return merkletree.PeakSummary{}, err
+	}
+	peaks, err := decodeHashes(t.GetHashes())
+	if err != nil {
+		return merkletree.PeakSummary{}, err
+	}
+	return alg.NewPeakSummary(int(t.GetSize()), peaks)
+}
+
+func decodeTree(p []byte, t *servicepb.TreeSummaryResponse) (Tree, error) {
+	r := Tree{Prefix: p}
+	ps, err := decodePeakSummary(t)
+	if err != nil {
+		return r, malformed("tree %x: %v", p, err)
+	}
+	r.Peaks = ps.Peaks
+	if r.Summary, err = ps.Summary(); err != nil {
+		return r, malformed("tree %x: %v", p, err)
+	}
+	switch {
+	case t.GetSize() == 0 && t.GetLast() != nil:
+		return r, malformed("tree %x: empty tree has a timestamp", p)
+	case t.GetSize() > 0 && t.GetLast() == nil:
+		return r, malformed("tree %x: missing timestamp", p)
+	case t.GetLast() != nil:
+		if err := t.GetLast().CheckValid(); err != nil {
+			return r, malformed("tree %x: %v", p, err)
+		}
+		r.Last = t.GetLast().AsTime()
+	}
+	return r, nil
+}
+
+// DecodeSummary decodes a summary of all trees of a Merkle weave and checks
+// that it is well formed. Signatures are not verified.
+func DecodeSummary(resp *servicepb.WeaveSummaryResponse) (*Summary, error) {
+	prefixes := merkleweave.Prefixes()
+	if len(resp.GetTrees()) != len(prefixes) {
+		return nil, malformed("expected %d trees, got %d", len(prefixes), len(resp.GetTrees()))
+	}
+	s := &Summary{}
+	for i, t := range resp.GetTrees() {
+		if !bytes.Equal(t.GetPrefix(), prefixes[i]) {
+			return nil, malformed("expected tree %x, got %x", prefixes[i], t.GetPrefix())
+		}
+		tr, err := decodeTree(t.GetPrefix(), t.GetSummary())
+		if err != nil {
+			return nil, err
+		}
+		s.Trees = append(s.Trees, tr)
+	}
+	s.Entries = int(resp.GetEntries())
+	if _, err := s.Weave(); err != nil {
+		return nil, malformed("%v", err)
+	}
+	if resp.GetSignature() != nil {
+		sig, err := signing.Decode(resp.GetSignature())
+		if err != nil {
+			return nil, malformed("signature: %v", err)
+		}
+		s.Signature = &sig
+	}
+	if l := resp.GetLog(); l != nil {
+		e := &LogEntry{Index: int(l.GetIndex())}
+		ps, err := decodePeakSummary(l.GetHead())
+		if err != nil {
+			return nil, malformed("summary log: %v", err)
+		}
+		e.Peaks = ps.Peaks
+		if e.Head, err = ps.Summary(); err != nil {
+			return nil, malformed("summary log: %v", err)
+		}
+		if e.Index >= e.Head.N {
+			return nil, malformed("summary log: index %d not in log of size %d", e.Index, e.Head.N)
+		}
+		s.Log = e
+	}
+	return s, nil
+}
+
+func decodeInclusionProof(resp *servicepb.InclusionProofResponse) (*merkletree.InclusionProof, error) {
+	p := &merkletree.InclusionProof{
+		N:   int(resp.GetSize()),
+		Pos: int(resp.GetPosition().GetIndex()),
+	}
+	var err error
+	if p.Alg, err = decodeAlgorithm(resp.GetAlgorithm()); err != nil {
+		return nil, err
+	}
+	if p.Children, err = decodeHashes(resp.GetChildren()); err != nil {
+		return nil, err
+	}
+	if p.Path, err = decodePath(resp.GetPath()); err != nil {
+		return nil, err
+	}
+	if p.Peaks, err = decodeHashes(resp.GetPeaks()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func decodeConsistencyProof(resp *servicepb.ConsistencyProofResponse) (*merkletree.ConsistencyProof, error) {
+	p := &merkletree.ConsistencyProof{
+		From: int(resp.GetFrom()),
+		To:   int(resp.GetTo()),
+	}
+	var err error
+	if p.Alg, err = decodeAlgorithm(resp.GetAlgorithm()); err != nil {
+		return nil, err
+	}
+	if p.OldPeaks, err = decodeHashes(resp.GetOldPeaks()); err != nil {
+		return nil, err
+	}
+	if p.NewPeaks, err = decodeHashes(resp.GetNewPeaks()); err != nil {
+		return nil, err
+	}
+	for _, path := range resp.GetPaths() {
+		steps, err := decodePath(path)
+		if err != nil {
+			return nil, err
+		}
+		p.Paths = append(p.Paths, steps)
+	}
+	return p, nil
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+)
+
+func TestDecodePeakSummary(t *testing.T) {
+	m, err := merkletree.NewWithAlgorithm(merkletree.SHA256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 11; i++ {
+		m.Append([]byte{byte(i)})
+	}
+	want := m.PeakSummary()
+	r := encodeTree(want, time.Unix(100, 0))
+	if r.GetLast().AsTime().Unix() != 100 {
+		t.Errorf("unexpected timestamp %v", r.GetLast())
+	}
+	got, err := decodePeakSummary(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Alg != want.Alg || got.N != want.N || len(got.Peaks) != len(want.Peaks) {
+		t.Fatalf("expected %d %s peaks at size %d, got %d %s peaks at size %d", len(want.Peaks), want.Alg, want.N, len(got.Peaks), got.Alg, got.N)
+	}
+	for i := range got.Peaks {
+		if got.Peaks[i] != want.Peaks[i] {
+			t.Errorf("peak %d: expected %x, got %x", i, want.Peaks[i], got.Peaks[i])
+		}
+	}
+	s, err := got.Summary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Equals(m.Summary()) {
+		t.Errorf("expected %s, got %s", m.Summary(), s)
+	}
+
+	r.Algorithm = 0x100 | uint32(merkletree.SHA256)
+	if _, err := decodePeakSummary(r); err == nil {
+		t.Error("expected error for out of range algorithm")
+	}
+	r.Algorithm = uint32(merkletree.SHA256)
+	r.Hashes = r.Hashes[1:]
+	if _, err := decodePeakSummary(r); err == nil {
+		t.Error("expected error for missing peak")
+	}
+	r.Hashes = [][]byte{{1, 2, 3}}
+	if _, err := decodePeakSummary(r); err == nil {
+		t.Error("expected error for short hash")
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// encodeTree returns the summary of a tree with peak summary p whose last entry
+// has timestamp last, if not zero.
+func encodeTree(p merkletree.PeakSummary, last time.Time) *servicepb.TreeSummaryResponse {
+	r := &servicepb.TreeSummaryResponse{
+		Size:      uint64(p.N),
+		Algorithm: uint32(p.Alg),
+	}
+	for i := range p.Peaks {
+		r.Hashes = append(r.Hashes, append([]byte(nil), p.Peaks[i][:]...))
+	}
+	if !last.IsZero() {
+		r.Last = timestamppb.New(last)
+	}
+	return r
+}
+
+// Encode returns s encoded as a WeaveSummaryResponse, including the signature
+// of the operator and the log entry, if any.
+func (s *Summary) Encode() *servicepb.WeaveSummaryResponse {
+	resp := &servicepb.WeaveSummaryResponse{Entries: uint64(s.Entries)}
+	for _, t := range s.Trees {
+		ts := encodeTree(t.PeakSummary(), t.Last)
+		resp.Trees = append(resp.Trees, &servicepb.PrefixTreeSummaryResponse{Prefix: t.Prefix, Summary: ts})
+	}
+	if s.Signature != nil {
+		resp.Signature = s.Signature.Encode()
+	}
+	if s.Log != nil {
+		resp.Log = &servicepb.SummaryLogEntry{
+			Index: uint64(s.Log.Index),
+			Head:  encodeTree(merkletree.PeakSummary{N: s.Log.Head.N, Peaks: s.Log.Peaks, Alg: s.Log.Head.Alg}, time.Time{}),
+		}
+	}
+	return resp
+}
+
+// Encode returns r encoded as a NotarizeResponse, including the signature of
+// the operator, if any.
+func (r *Receipt) Encode() *servicepb.NotarizeResponse {
+	resp := &servicepb.NotarizeResponse{
+		Hash:      r.Data,
+		Timestamp: timestamppb.New(r.Timestamp),
+	}
+	for _, p := range r.Positions {
+		resp.Positions = append(resp.Positions, &servicepb.Position{Prefix: p.Prefix, Index: uint64(p.Index)})
+	}
+	if r.Signature != nil {
+		resp.Signature = r.Signature.Encode()
+	}
+	return resp
+}
@@ -0,0 +1,330 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/jsonapi"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+)
+
+// Summaries and receipts are encoded in JSON for storage and exchange, as in
+// package jsonapi.
+
+type jsonReceipt struct {
+	Data      string                   `json:"data"`
+	Timestamp time.Time                `json:"timestamp"`
+	Positions []jsonapi.Position       `json:"positions"`
+	Summary   *Summary                 `json:"summary"`
+	Proofs    []jsonapi.InclusionProof `json:"proofs"`
+	Signature *jsonapi.Signature       `json:"signature,omitempty"`
+}
+
+type jsonOrderProof struct {
+	Before      *Receipt                   `json:"before"`
+	After       *Receipt                   `json:"after"`
+	Consistency []jsonapi.ConsistencyProof `json:"consistency"`
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+func encodeHashes(hs [][merkletree.HashLength]byte) []string {
+	r := make([]string, len(hs))
+	for i := range hs {
+		r[i] = encode(hs[i][:])
+	}
+	return r
+}
+
+func decodeHashStrings(ss []string) ([][merkletree.HashLength]byte, error) {
+	bs := make([][]byte, len(ss))
+	for i, s := range ss {
+		b, err := decode(s)
+		if err != nil {
+			return nil, err
+		}
+		bs[i] = b
+	}
+	return decodeHashes(bs)
+}
+
+func encodeSignature(sig signing.Signature) jsonapi.Signature {
+	return jsonapi.Signature{
+		KeyID:     encode(sig.KeyID),
+		Time:      sig.Time,
+		Signature: encode(sig.Signature),
+	}
+}
+
+func decodeSignature(js jsonapi.Signature) (signing.Signature, error) {
+	sig := signing.Signature{Time: js.Time}
+	var err error
+	if sig.KeyID, err = decode(js.KeyID); err != nil {
+		return sig, err
+	}
+	if sig.Signature, err = decode(js.Signature); err != nil {
+		return sig, err
+	}
+	return sig, nil
+}
+
+// MarshalJSON encodes s as JSON.
+func (s *Summary) MarshalJSON() ([]byte, error) {
+	js := jsonapi.WeaveSummary{Trees: make([]jsonapi.TreeSummary, 0, len(s.Trees)), Entries: uint64(s.Entries)}
+	for _, t := range s.Trees {
+		jt := jsonapi.TreeSummary{
+			Prefix:    hex.EncodeToString(t.Prefix),
+			Size:      uint64(t.Summary.N),
+			Peaks:     encodeHashes(t.Peaks),
+			Algorithm: t.Summary.Alg,
+		}
+		if !t.Last.IsZero() {
+			last := t.Last
+			jt.Last = &last
+		}
+		js.Trees = append(js.Trees, jt)
+	}
+	if s.Signature != nil {
+		sig := encodeSignature(*s.Signature)
+		js.Signature = &sig
+	}
+	for _, sig := range s.Cosignatures {
+		js.Cosignatures = append(js.Cosignatures, encodeSignature(sig))
+	}
+	if s.Log != nil {
+		js.Log = &jsonapi.SummaryLogEntry{Index: uint64(s.Log.Index), Size: uint64(s.Log.Head.N), Peaks: encodeHashes(s.Log.Peaks), Algorithm: s.Log.Head.Alg}
+	}
+	return json.Marshal(js)
+}
+
+// UnmarshalJSON decodes s from JSON, recomputing the summary of each tree from
+// its peaks.
+func (s *Summary) UnmarshalJSON(b []byte) error {
+	var js jsonapi.WeaveSummary
+	if err := json.Unmarshal(b, &js); err != nil {
+		return err
+	}
+	prefixes := merkleweave.Prefixes()
+	if len(js.Trees) != len(prefixes) {
+		return fmt.Errorf("expected %d trees, got %d", len(prefixes), len(js.Trees))
+	}
+	trees := make([]Tree, 0, len(js.Trees))
+	for i, jt := range js.Trees {
+		if jt.Prefix != hex.EncodeToString(prefixes[i]) {
+			return fmt.Errorf("expected tree %x, got %s", prefixes[i], jt.Prefix)
+		}
+		t := Tree{Prefix: prefixes[i]}
+		var err error
+		if t.Peaks, err = decodeHashStrings(jt.Peaks); err != nil {
+			return fmt.Errorf("tree %s: %v", jt.Prefix, err)
+		}
+		if t.Summary, err = jt.Algorithm.NewSummary(int(jt.Size), t.Peaks); err != nil {
+			return fmt.Errorf("tree %s: %v", jt.Prefix, err)
+		}
+		if jt.Last != nil {
+			t.Last = *jt.Last
+		}
+		trees = append(trees, t)
+	}
+	var sig *signing.Signature
+	if js.Signature != nil {
+		v, err := decodeSignature(*js.Signature)
+		if err != nil {
+			return fmt.Errorf("signature: %v", err)
+		}
+		sig = &v
+	}
+	var cosigs []signing.Signature
+	for _, jsig := range js.Cosignatures {
+		v, err := decodeSignature(jsig)
+		if err != nil {
+			return fmt.Errorf("cosignature: %v", err)
+		}
+		cosigs = append(cosigs, v)
+	}
+	var log *LogEntry
+	if js.Log != nil {
+		log = &LogEntry{Index: int(js.Log.Index)}
+		var err error
+		if log.Peaks, err = decodeHashStrings(js.Log.Peaks); err != nil {
+			return fmt.Errorf("log: %v", err)
+		}
+		if log.Head, err = js.Log.Algorithm.NewSummary(int(js.Log.Size), log.Peaks); err != nil {
+			return fmt.Errorf("log: %v", err)
+		}
+	}
+	s.Trees, s.Entries, s.Signature, s.Cosignatures, s.Log = trees, int(js.Entries), sig, cosigs, log
+	return nil
+}
+
+func encodeSteps(steps []merkletree.Step) []jsonapi.Step {
+	r := make([]jsonapi.Step, 0, len(steps))
+	for _, s := range steps {
+		r = append(r, jsonapi.Step{Sibling: encode(s.Sibling[:]), Data: encode(s.Data)})
+	}
+	return r
+}
+
+func decodeSteps(js []jsonapi.Step) ([]merkletree.Step, error) {
+	var r []merkletree.Step
+	for _, s := range js {
+		sib, err := decode(s.Sibling)
+		if err != nil {
+			return nil, err
+		}
+		h, err := decodeHash(sib)
+		if err != nil {
+			return nil, err
+		}
+		data, err := decode(s.Data)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, merkletree.Step{Sibling: h, Data: data})
+	}
+	return r, nil
+}
+
+// MarshalJSON encodes r as JSON, including the summary the entry of r is
+// proven to be included in.
+func (r *Receipt) MarshalJSON() ([]byte, error) {
+	jr := jsonReceipt{
+		Data:      encode(r.Data),
+		Timestamp: r.Timestamp,
+		Summary:   r.Summary,
+	}
+	for _, p := range r.Positions {
+		jr.Positions = append(jr.Positions, jsonapi.Position{Prefix: hex.EncodeToString(p.Prefix), Index: uint64(p.Index)})
+	}
+	if r.Signature != nil {
+		sig := encodeSignature(*r.Signature)
+		jr.Signature = &sig
+	}
+	for i, p := range r.Proofs {
+		jp := jsonapi.InclusionProof{
+			Position:  jsonapi.Position{Index: uint64(p.Pos)},
+			Size:      uint64(p.N),
+			Children:  encodeHashes(p.Children),
+			Path:      encodeSteps(p.Path),
+			Peaks:     encodeHashes(p.Peaks),
+			Algorithm: p.Alg,
+		}
+		if i < len(r.Positions) {
+			jp.Position.Prefix = hex.EncodeToString(r.Positions[i].Prefix)
+		}
+		jr.Proofs = append(jr.Proofs, jp)
+	}
+	return json.Marshal(jr)
+}
+
+// UnmarshalJSON decodes r from JSON. The decoded receipt is not verified; use
+// Verify.
+func (r *Receipt) UnmarshalJSON(b []byte) error {
+	var jr jsonReceipt
+	if err := json.Unmarshal(b, &jr); err != nil {
+		return err
+	}
+	data, err := decode(jr.Data)
+	if err != nil {
+		return fmt.Errorf("data: %v", err)
+	}
+	nr := Receipt{
+		Receipt: merkleweave.Receipt{Data: data, Timestamp: jr.Timestamp},
+		Summary: jr.Summary,
+	}
+	for _, jp := range jr.Positions {
+		p, err := hex.DecodeString(jp.Prefix)
+		if err != nil {
+			return fmt.Errorf("prefix: %v", err)
+		}
+		nr.Positions = append(nr.Positions, merkleweave.Position{Prefix: p, Index: int(jp.Index)})
+	}
+	if jr.Signature != nil {
+		sig, err := decodeSignature(*jr.Signature)
+		if err != nil {
+			return fmt.Errorf("signature: %v", err)
+		}
+		nr.Signature = &sig
+	}
+	for _, jp := range jr.Proofs {
+		if !jp.Algorithm.Valid() {
+			return fmt.Errorf("unknown hash algorithm %s", jp.Algorithm)
+		}
+		p := &merkletree.InclusionProof{N: int(jp.Size), Pos: int(jp.Position.Index), Alg: jp.Algorithm}
+		if p.Children, err = decodeHashStrings(jp.Children); err != nil {
+			return fmt.Errorf("children: %v", err)
+		}
+		if p.Path, err = decodeSteps(jp.Path); err != nil {
+			return fmt.Errorf("path: %v", err)
+		}
+		if p.Peaks, err = decodeHashStrings(jp.Peaks); err != nil {
+			return fmt.Errorf("peaks: %v", err)
+		}
+		nr.Proofs = append(nr.Proofs, p)
+	}
+	*r = nr
+	return nil
+}
+
+// MarshalJSON encodes p as JSON.
+func (p *OrderProof) MarshalJSON() ([]byte, error) {
+	jp := jsonOrderProof{Before: p.Before, After: p.After}
+	for _, cp := range p.Consistency {
+		jc := jsonapi.ConsistencyProof{
+			From:      uint64(cp.From),
+			To:        uint64(cp.To),
+			OldPeaks:  encodeHashes(cp.OldPeaks),
+			NewPeaks:  encodeHashes(cp.NewPeaks),
+			Algorithm: cp.Alg,
+		}
+		for _, path := range cp.Paths {
+			jc.Paths = append(jc.Paths, encodeSteps(path))
+		}
+		jp.Consistency = append(jp.Consistency, jc)
+	}
+	return json.Marshal(jp)
+}
+
+// UnmarshalJSON decodes p from JSON. The decoded proof is not verified; use
+// Verify.
+func (p *OrderProof) UnmarshalJSON(b []byte) error {
+	var jp jsonOrderProof
+	if err := json.Unmarshal(b, &jp); err != nil {
+		return err
+	}
+	np := OrderProof{Before: jp.Before, After: jp.After}
+	for _, jc := range jp.Consistency {
+		if !jc.Algorithm.Valid() {
+			return fmt.Errorf("unknown hash algorithm %s", jc.Algorithm)
+		}
+		cp := &merkletree.ConsistencyProof{From: int(jc.From), To: int(jc.To), Alg: jc.Algorithm}
+		var err error
+		if cp.OldPeaks, err = decodeHashStrings(jc.OldPeaks); err != nil {
+			return fmt.Errorf("old peaks: %v", err)
+		}
+		if cp.NewPeaks, err = decodeHashStrings(jc.NewPeaks); err != nil {
+			return fmt.Errorf("new peaks: %v", err)
+		}
+		for _, path := range jc.Paths {
+			steps, err := decodeSteps(path)
+			if err != nil {
+				return fmt.Errorf("path: %v", err)
+			}
+			cp.Paths = append(cp.Paths, steps)
+		}
+		np.Consistency = append(np.Consistency, cp)
+	}
+	*p = np
+	return nil
+}
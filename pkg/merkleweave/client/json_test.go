@@ -0,0 +1,61 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+)
+
+func TestReceiptJSON(t *testing.T) {
+	ctx := context.Background()
+	w := merkleweave.New()
+	c := client.New(dial(t, w))
+	for i := 0; i < 5; i++ {
+		w.Append([]byte{byte(i), 7, 8, 9})
+	}
+	r, err := c.Notarize(ctx, []byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r2 client.Receipt
+	if err := json.Unmarshal(b, &r2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.Verify(nil); err != nil {
+		t.Fatal(err)
+	}
+	if r2.Summary.HWM() != r.Summary.HWM() {
+		t.Errorf("expected HWM %s, got %s", r.Summary.HWM(), r2.Summary.HWM())
+	}
+
+	// Proofs do not verify against a different summary.
+	w.Append([]byte{9, 9, 9, 9})
+	w.Append([]byte{1, 2, 3, 5})
+	s, err := c.Summary(ctx, r.Timestamp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r2.Verify(s); err == nil {
+		t.Error("expected error verifying against a later summary")
+	}
+
+	// Tampered data.
+	r2.Data = []byte{1, 2, 3, 5}
+	if err := r2.Verify(nil); err == nil {
+		t.Error("expected error for tampered data")
+	}
+
+	// Tampered summary.
+	var s2 client.Summary
+	b, _ = json.Marshal(r.Summary)
+	if err := json.Unmarshal(b[:len(b)-20], &s2); err == nil {
+		t.Error("expected error for truncated summary")
+	}
+}
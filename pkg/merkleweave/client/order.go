@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+)
+
+// ErrUnordered is returned when one entry cannot be proven to have been
+// committed before another.
+var ErrUnordered = errors.New("entries not ordered")
+
+// OrderProof proves that one entry of a Merkle weave was committed before
+// another was appended.
+//
+// Before proves that the earlier entry is included in Before.Summary. After
+// proves that the later entry is included in After.Summary at positions past
+// the end of its trees in Before.Summary, and Consistency proves that each of
+// those trees in Before.Summary is a prefix of the same tree in After.Summary.
+// So the later entry was appended after Before.Summary was taken, and since
+// the timestamps of each tree increase, its timestamp is after the high water
+// mark of Before.Summary.
+type OrderProof struct {
+	Before, After *Receipt
+
+	// Consistency are proofs of consistency from Before.Summary to
+	// After.Summary for each position of After.
+	Consistency []*merkletree.ConsistencyProof
+}
+
+// ProveOrder returns a proof that the entry of before was committed before the
+// entry of after was appended, fetching consistency proofs from the server. It
+// returns an error matching ErrUnordered if the entry of after is included in
+// the summary of before.
+func (c *Client) ProveOrder(ctx context.Context, before, after *Receipt) (*OrderProof, error) {
+	if before.Summary == nil || after.Summary == nil {
+		return nil, errors.New("receipts must have summaries")
+	}
+	p := &OrderProof{Before: before, After: after}
+	for _, pos := range after.Positions {
+		old, _ := before.Summary.Tree(pos.Prefix)
+		new, _ := after.Summary.Tree(pos.Prefix)
+		if pos.Index < old.Summary.N {
+			return nil, fmt.Errorf("%w: entry %x:%d in tree of size %d", ErrUnordered, pos.Prefix, pos.Index, old.Summary.N)
+		}
+		resp, err := c.c.ConsistencyProof(ctx, &servicepb.ConsistencyProofRequest{
+			Prefix: pos.Prefix,
+			From:   uint64(old.Summary.N),
+			To:     uint64(new.Summary.N),
+		})
+		if err != nil {
+			return nil, err
+		}
+		cp, err := decodeConsistencyProof(resp)
+		if err != nil {
+			return nil, err
+		}
+		if err := merkletree.VerifyConsistency(old.Summary, new.Summary, cp); err != nil {
+			return nil, &InconsistencyError{Prefix: pos.Prefix, Old: old, New: new, Err: err}
+		}
+		p.Consistency = append(p.Consistency, cp)
+	}
+	if err := p.Verify(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// HWM returns the high water mark of the summary of the earlier entry. The
+// later entry is proven to be timestamped after it.
+func (p *OrderProof) HWM() time.Time {
+	return p.Before.Summary.HWM()
+}
+
+// Verify checks offline that p proves that the entry of p.Before was committed
+// before the entry of p.After was appended. It does not verify signatures of
+// the summaries.
+func (p *OrderProof) Verify() error {
+	if p.Before == nil || p.After == nil || p.Before.Summary == nil || p.After.Summary == nil {
+		return errors.New("missing receipt or summary")
+	}
+	if err := p.Before.Verify(nil); err != nil {
+		return fmt.Errorf("earlier entry: %w", err)
+	}
+	if err := p.After.Verify(nil); err != nil {
+		return fmt.Errorf("later entry: %w", err)
+	}
+	if len(p.Consistency) != len(p.After.Positions) {
+		return fmt.Errorf("expected %d consistency proofs, got %d", len(p.After.Positions), len(p.Consistency))
+	}
+	for i, pos := range p.After.Positions {
+		old, ok := p.Before.Summary.Tree(pos.Prefix)
+		if !ok {
+			return fmt.Errorf("no tree %x in summary", pos.Prefix)
+		}
+		new, _ := p.After.Summary.Tree(pos.Prefix)
+		if pos.Index < old.Summary.N {
+			return fmt.Errorf("%w: entry %x:%d in tree of size %d", ErrUnordered, pos.Prefix, pos.Index, old.Summary.N)
+		}
+		if err := merkletree.VerifyConsistency(old.Summary, new.Summary, p.Consistency[i]); err != nil {
+			return &InconsistencyError{Prefix: pos.Prefix, Old: old, New: new, Err: err}
+		}
+		if !p.After.Timestamp.After(old.Last) {
+			return &TimestampError{Prefix: pos.Prefix, Err: fmt.Errorf("later entry at %s, not after %s", p.After.Timestamp, old.Last)}
+		}
+	}
+	return nil
+}
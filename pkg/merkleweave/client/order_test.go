@@ -0,0 +1,63 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+)
+
+func TestOrder(t *testing.T) {
+	ctx := context.Background()
+	w := merkleweave.New()
+	c := client.New(dial(t, w))
+	a, err := c.Notarize(ctx, []byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Append([]byte{5, 6, 7, 8})
+	b, err := c.Notarize(ctx, []byte{2, 9, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := c.ProveOrder(ctx, a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Timestamp.After(p.HWM()) {
+		t.Errorf("expected later entry at %s after high water mark %s", b.Timestamp, p.HWM())
+	}
+
+	// Round trip through JSON.
+	js, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p2 client.OrderProof
+	if err := json.Unmarshal(js, &p2); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reverse order cannot be proven.
+	if _, err := c.ProveOrder(ctx, b, a); !errors.Is(err, client.ErrUnordered) {
+		t.Errorf("expected unordered, got %v", err)
+	}
+
+	// The later entry is included in the summary the earlier entry is
+	// verified against.
+	a2, err := c.VerifyReceipt(ctx, a.Receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2.Before = a2
+	if err := p2.Verify(); !errors.Is(err, client.ErrUnordered) {
+		t.Errorf("expected unordered, got %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+)
+
+// LogEntry is the position of a summary in the summary log of a server (see
+// merkleweave.SummaryLog).
+type LogEntry struct {
+	Index int
+
+	// Head is the summary of the log including the summary, and Peaks are
+	// the hashes of its peaks.
+	Head  merkletree.Summary
+	Peaks [][merkletree.HashLength]byte
+}
+
+// SummaryLogError is returned when a summary cannot be proven to be included
+// in the summary log, or when summary logs are inconsistent.
+type SummaryLogError struct {
+	Err error
+}
+
+func (e *SummaryLogError) Error() string { return "summary log: " + e.Err.Error() }
+
+// Unwrap returns the underlying error.
+func (e *SummaryLogError) Unwrap() error { return e.Err }
+
+// Is returns true if target is ErrMisbehavior.
+func (e *SummaryLogError) Is(target error) bool { return target == ErrMisbehavior }
+
+// verifyLog verifies that s is included in its summary log and that the log is
+// consistent with that of old.
+func (c *Client) verifyLog(ctx context.Context, old, s *Summary) error {
+	if s.Log == nil {
+		if old.Log != nil {
+			return &SummaryLogError{Err: errors.New("missing log entry")}
+		}
+		return nil
+	}
+	p, err := c.proveLogInclusion(ctx, s.Log.Index, s.Log.Head.N)
+	if err != nil {
+		return err
+	}
+	if err := s.verifyIncludes(s, p); err != nil {
+		return err
+	}
+	if old.Log == nil {
+		return nil
+	}
+	from, to := old.Log.Head, s.Log.Head
+	switch {
+	case to.N < from.N:
+		return &SummaryLogError{Err: errors.New("log shrank")}
+	case to.N == from.N:
+		if !to.Equals(from) {
+			return &SummaryLogError{Err: errors.New("same size, different hash")}
+		}
+		return nil
+	}
+	resp, err := c.c.SummaryLogConsistencyProof(ctx, &servicepb.SummaryLogConsistencyProofRequest{
+		From: uint64(from.N),
+		To:   uint64(to.N),
+	})
+	if err != nil {
+		return err
+	}
+	cp, err := decodeConsistencyProof(resp)
+	if err != nil {
+		return err
+	}
+	if err := merkletree.VerifyConsistency(from, to, cp); err != nil {
+		return &SummaryLogError{Err: err}
+	}
+	return nil
+}
+
+func (c *Client) proveLogInclusion(ctx context.Context, index, n int) (*merkletree.InclusionProof, error) {
+	resp, err := c.c.SummaryLogInclusionProof(ctx, &servicepb.SummaryLogInclusionProofRequest{
+		Index: uint64(index),
+		Size:  uint64(n),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return decodeInclusionProof(resp)
+}
+
+// ProveSummary returns a proof that old, a summary previously verified by a
+// Client, is included in the summary log of the pinned summary. The proof can
+// be checked offline with VerifyIncludes.
+func (c *Client) ProveSummary(ctx context.Context, old *Summary) (*merkletree.InclusionProof, error) {
+	s := c.Pinned()
+	if old.Log == nil || s.Log == nil {
+		return nil, errors.New("summary has no log entry")
+	}
+	if old.Log.Index >= s.Log.Head.N {
+		return nil, &SummaryLogError{Err: fmt.Errorf("summary %d not in log of size %d", old.Log.Index, s.Log.Head.N)}
+	}
+	p, err := c.proveLogInclusion(ctx, old.Log.Index, s.Log.Head.N)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.VerifyIncludes(old, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// VerifyIncludes verifies offline that p proves that old is included in the
+// summary log of s.
+func (s *Summary) VerifyIncludes(old *Summary, p *merkletree.InclusionProof) error {
+	if s.Log == nil || old.Log == nil {
+		return errors.New("summary has no log entry")
+	}
+	return s.verifyIncludes(old, p)
+}
+
+func (s *Summary) verifyIncludes(old *Summary, p *merkletree.InclusionProof) error {
+	if p.Pos != old.Log.Index {
+		return &SummaryLogError{Err: fmt.Errorf("proof for index %d, expected %d", p.Pos, old.Log.Index)}
+	}
+	w, err := old.Weave()
+	if err != nil {
+		return err
+	}
+	if err := merkleweave.VerifySummaryInclusion(s.Log.Head, w, p); err != nil {
+		return &SummaryLogError{Err: err}
+	}
+	return nil
+}
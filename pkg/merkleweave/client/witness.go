@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/witnesspb"
+)
+
+// Witness is a witness that cosigns summaries (see package witness).
+type Witness struct {
+	Client witnesspb.WitnessClient
+	Key    ed25519.PublicKey
+}
+
+// WithWitnesses returns an Option that requires summaries to be cosigned by at
+// least q of ws. Each new summary is submitted to all of ws for cosigning
+// before it is pinned.
+func WithWitnesses(q int, ws ...Witness) Option {
+	return func(c *Client) {
+		c.quorum, c.witnesses = q, ws
+		var keys []ed25519.PublicKey
+		for _, w := range ws {
+			keys = append(keys, w.Key)
+		}
+		c.witnessKeys = signing.NewKeyRing(keys...)
+	}
+}
+
+// cosign requests cosignatures of s from the witnesses of c and adds them to
+// s. It returns an error if fewer than the quorum of c were obtained.
+func (c *Client) cosign(ctx context.Context, s *Summary) error {
+	w, err := s.Weave()
+	if err != nil {
+		return err
+	}
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []string
+	)
+	for _, wit := range c.witnesses {
+		wg.Add(1)
+		go func(wit Witness) {
+			defer wg.Done()
+			sig, err := c.requestCosignature(ctx, wit, s)
+			if err == nil {
+				err = signing.NewKeyRing(wit.Key).VerifyCosignature(w, sig)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%x: %v", signing.KeyID(wit.Key), err))
+				return
+			}
+			s.Cosignatures = append(s.Cosignatures, sig)
+		}(wit)
+	}
+	wg.Wait()
+	if err := c.witnessKeys.VerifyQuorum(w, s.Cosignatures, c.quorum); err != nil {
+		if len(errs) > 0 {
+			err = fmt.Errorf("%w (%s)", err, strings.Join(errs, "; "))
+		}
+		return err
+	}
+	return nil
+}
+
+// requestCosignature submits s to wit with proofs of its consistency with the
+// last summary cosigned by wit.
+func (c *Client) requestCosignature(ctx context.Context, wit Witness, s *Summary) (signing.Signature, error) {
+	lresp, err := wit.Client.Latest(ctx, &witnesspb.LatestRequest{})
+	if err != nil {
+		return signing.Signature{}, err
+	}
+	latest, err := DecodeSummary(lresp.GetSummary())
+	if err != nil {
+		return signing.Signature{}, fmt.Errorf("latest summary: %v", err)
+	}
+	req := &witnesspb.CosignRequest{Summary: s.Encode()}
+	for i, t := range s.Trees {
+		from := latest.Trees[i].Summary.N
+		if from >= t.Summary.N {
+			continue
+		}
+		p, err := c.c.ConsistencyProof(ctx, &servicepb.ConsistencyProofRequest{
+			Prefix: t.Prefix,
+			From:   uint64(from),
+			To:     uint64(t.Summary.N),
+		})
+		if err != nil {
+			return signing.Signature{}, err
+		}
+		req.Proofs = append(req.Proofs, p)
+	}
+	resp, err := wit.Client.Cosign(ctx, req)
+	if err != nil {
+		return signing.Signature{}, err
+	}
+	return signing.Decode(resp.GetCosignature())
+}
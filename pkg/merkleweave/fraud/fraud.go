@@ -0,0 +1,400 @@
+// Package fraud builds and verifies fraud proofs, portable evidence that the
+// operator of a Merkle weave has misbehaved.
+//
+// A fraud proof (see fraud.proto) consists of summaries and receipts signed by
+// an operator, together with proofs that show they contradict each other.
+// VerifyFraudProof checks a fraud proof without contacting the operator,
+// including that each summary and receipt is signed by a key of the operator,
+// so that a fraud proof cannot be built from forged claims.
+package fraud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/fraudpb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ErrNotFraud is returned when a fraud proof does not show misbehavior.
+var ErrNotFraud = errors.New("not a fraud proof")
+
+func notFraud(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrNotFraud, fmt.Sprintf(format, a...))
+}
+
+// NewInclusionProof returns p encoded as in an InclusionProofResponse for an
+// entry of the tree with prefix.
+func NewInclusionProof(prefix []byte, p *merkletree.InclusionProof) *servicepb.InclusionProofResponse {
+	resp := &servicepb.InclusionProofResponse{
+		Position:  &servicepb.Position{Prefix: prefix, Index: uint64(p.Pos)},
+		Size:      uint64(p.N),
+		Path:      &servicepb.ProofPath{},
+		Algorithm: uint32(p.Alg),
+	}
+	for i := range p.Children {
+		resp.Children = append(resp.Children, append([]byte(nil), p.Children[i][:]...))
+	}
+	for _, s := range p.Path {
+		resp.Path.Steps = append(resp.Path.Steps, &servicepb.ProofStep{Sibling: append([]byte(nil), s.Sibling[:]...), Data: s.Data})
+	}
+	for i := range p.Peaks {
+		resp.Peaks = append(resp.Peaks, append([]byte(nil), p.Peaks[i][:]...))
+	}
+	return resp
+}
+
+func decodeHash(b []byte) ([merkletree.HashLength]byte, error) {
+	var h [merkletree.HashLength]byte
+	if len(b) != merkletree.HashLength {
+		return h, fmt.Errorf("expected hash of %d bytes, got %d", merkletree.HashLength, len(b))
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+func decodeHashes(bs [][]byte) ([][merkletree.HashLength]byte, error) {
+	var r [][merkletree.HashLength]byte
+	for _, b := range bs {
+		h, err := decodeHash(b)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, h)
+	}
+	return r, nil
+}
+
+// signedTree returns the tree with prefix p of a summary signed by a key in k.
+func signedTree(k signing.KeyRing, resp *servicepb.WeaveSummaryResponse, p []byte) (client.Tree, error) {
+	if resp == nil {
+		return client.Tree{}, errors.New("missing summary")
+	}
+	s, err := client.DecodeSummary(resp)
+	if err != nil {
+		return client.Tree{}, err
+	}
+	if err := s.VerifySignature(k); err != nil {
+		return client.Tree{}, err
+	}
+	t, ok := s.Tree(p)
+	if !ok {
+		return client.Tree{}, fmt.Errorf("no tree %x in summary", p)
+	}
+	return t, nil
+}
+
+// signedReceipt decodes a receipt signed by a key in k.
+func signedReceipt(k signing.KeyRing, resp *servicepb.NotarizeResponse) (*client.Receipt, error) {
+	if resp == nil {
+		return nil, errors.New("missing receipt")
+	}
+	ts, err := timestamp(resp.GetTimestamp())
+	if err != nil {
+		return nil, err
+	}
+	r := &client.Receipt{Receipt: merkleweave.Receipt{Data: resp.GetHash(), Timestamp: ts}}
+	for _, p := range resp.GetPositions() {
+		r.Positions = append(r.Positions, merkleweave.Position{Prefix: p.GetPrefix(), Index: int(p.GetIndex())})
+	}
+	if resp.GetSignature() != nil {
+		sig, err := signing.Decode(resp.GetSignature())
+		if err != nil {
+			return nil, fmt.Errorf("signature: %v", err)
+		}
+		r.Signature = &sig
+	}
+	if err := r.VerifySignature(k); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func decodeInclusionProof(resp *servicepb.InclusionProofResponse) (*merkletree.InclusionProof, error) {
+	if resp == nil {
+		return nil, errors.New("missing inclusion proof")
+	}
+	alg := merkletree.Algorithm(resp.GetAlgorithm())
+	if resp.GetAlgorithm() > 0xff || !alg.Valid() {
+		return nil, fmt.Errorf("unknown hash algorithm %d", resp.GetAlgorithm())
+	}
+	p := &merkletree.InclusionProof{N: int(resp.GetSize()), Pos: int(resp.GetPosition().GetIndex()), Alg: alg}
+	var err error
+	if p.Children, err = decodeHashes(resp.GetChildren()); err != nil {
+		return nil, err
+	}
+	for _, s := range resp.GetPath().GetSteps() {
+		sib, err := decodeHash(s.GetSibling())
+		if err != nil {
+			return nil, err
+		}
+		p.Path = append(p.Path, merkletree.Step{Sibling: sib, Data: s.GetData()})
+	}
+	if p.Peaks, err = decodeHashes(resp.GetPeaks()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// verifyEntry verifies that resp proves that data is in tree t.
+func verifyEntry(t client.Tree, data []byte, resp *servicepb.InclusionProofResponse) (*merkletree.InclusionProof, error) {
+	p, err := decodeInclusionProof(resp)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(resp.GetPosition().GetPrefix(), t.Prefix) {
+		return nil, fmt.Errorf("proof for tree %x, expected %x", resp.GetPosition().GetPrefix(), t.Prefix)
+	}
+	if err := merkletree.VerifyInclusion(t.Summary, data, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func timestamp(ts *timestamppb.Timestamp) (time.Time, error) {
+	if err := ts.CheckValid(); err != nil {
+		return time.Time{}, err
+	}
+	return ts.AsTime(), nil
+}
+
+// VerifyFraudProof returns nil if p shows that the operator with a key in k
+// has misbehaved. Otherwise, including if any summary or receipt in p is not
+// signed by a key in k, it returns an error that matches ErrNotFraud.
+func VerifyFraudProof(k signing.KeyRing, p *fraudpb.FraudProof) error {
+	switch p.GetProof().(type) {
+	case *fraudpb.FraudProof_Equivocation:
+		return verifyEquivocation(k, p.GetEquivocation())
+	case *fraudpb.FraudProof_Backdating:
+		return verifyBackdating(k, p.GetBackdating())
+	case *fraudpb.FraudProof_MissingEntry:
+		return verifyMissingEntry(k, p.GetMissingEntry())
+	default:
+		return notFraud("empty proof")
+	}
+}
+
+func verifyEquivocation(k signing.KeyRing, e *fraudpb.Equivocation) error {
+	a, err := signedTree(k, e.GetA(), e.GetPrefix())
+	if err != nil {
+		return notFraud("summary a: %v", err)
+	}
+	b, err := signedTree(k, e.GetB(), e.GetPrefix())
+	if err != nil {
+		return notFraud("summary b: %v", err)
+	}
+	return equivocation(a, b, e.GetData(), e.GetProof())
+}
+
+// equivocation returns nil if a and b, two heads of the same tree, cannot both
+// be heads of one append-only tree, using the entry data of b proven by proof
+// if b is larger.
+func equivocation(a, b client.Tree, data []byte, proof *servicepb.InclusionProofResponse) error {
+	switch {
+	case a.Summary.N > b.Summary.N:
+		return notFraud("tree larger in summary a than in summary b")
+	case a.Summary.N == b.Summary.N:
+		if a.Summary.Equals(b.Summary) {
+			return notFraud("heads are equal")
+		}
+		return nil
+	}
+	p, err := verifyEntry(b, data, proof)
+	if err != nil {
+		return notFraud("entry of summary b: %v", err)
+	}
+	for i, pos := range merkletree.PeakPositions(a.Summary.N) {
+		if pos != p.Pos {
+			continue
+		}
+		node, _ := p.Node(data)
+		if node == a.Peaks[i] {
+			return notFraud("entry matches peak of summary a")
+		}
+		return nil
+	}
+	return notFraud("entry at %d not a peak of summary a", p.Pos)
+}
+
+func verifyBackdating(k signing.KeyRing, b *fraudpb.Backdating) error {
+	t, err := signedTree(k, b.GetSummary(), b.GetPrefix())
+	if err != nil {
+		return notFraud("summary: %v", err)
+	}
+	earlier, err := verifyReceiptEntry(k, t, b.GetEarlier(), b.GetEarlierProof())
+	if err != nil {
+		return notFraud("earlier entry: %v", err)
+	}
+	if b.GetLater() == nil {
+		if earlier.ts.After(t.Last) {
+			return nil
+		}
+		return notFraud("entry at %s not after last entry at %s", earlier.ts, t.Last)
+	}
+	later, err := verifyReceiptEntry(k, t, b.GetLater(), b.GetLaterProof())
+	if err != nil {
+		return notFraud("later entry: %v", err)
+	}
+	if later.index <= earlier.index {
+		return notFraud("later entry at %d not after earlier entry at %d", later.index, earlier.index)
+	}
+	switch {
+	case later.ts.Before(earlier.ts):
+		return nil
+	case later.ts.Equal(earlier.ts):
+		// An entry whose cross trees are the same tree is appended to it
+		// twice with the same timestamp.
+		if later.index == earlier.index+1 && bytes.Equal(later.data, earlier.data) {
+			return notFraud("repeated entry")
+		}
+		return nil
+	}
+	return notFraud("timestamps increase")
+}
+
+type entry struct {
+	index int
+	data  []byte
+	ts    time.Time
+}
+
+// verifyReceiptEntry verifies that resp is a receipt signed by a key in k for
+// an entry that proof shows is in tree t at a position of the receipt.
+func verifyReceiptEntry(k signing.KeyRing, t client.Tree, resp *servicepb.NotarizeResponse, proof *servicepb.InclusionProofResponse) (*entry, error) {
+	r, err := signedReceipt(k, resp)
+	if err != nil {
+		return nil, err
+	}
+	p, err := verifyEntry(t, r.Data, proof)
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range r.Positions {
+		if bytes.Equal(pos.Prefix, t.Prefix) && pos.Index == p.Pos {
+			return &entry{index: p.Pos, data: r.Data, ts: r.Timestamp}, nil
+		}
+	}
+	return nil, fmt.Errorf("proof for index %d, not a position of the receipt", p.Pos)
+}
+
+func verifyMissingEntry(k signing.KeyRing, m *fraudpb.MissingEntry) error {
+	r, err := signedReceipt(k, m.GetReceipt())
+	if err != nil {
+		return notFraud("receipt: %v", err)
+	}
+	prefixes, err := merkleweave.CrossTreePrefixes(r.Data)
+	if err != nil {
+		return notFraud("receipt: %v", err)
+	}
+	t, err := signedTree(k, m.GetSummary(), m.GetPrefix())
+	if err != nil {
+		return notFraud("summary: %v", err)
+	}
+	cross := false
+	for _, p := range prefixes {
+		cross = cross || bytes.Equal(p, t.Prefix)
+	}
+	if !cross {
+		return notFraud("tree %x is not a cross tree of the entry", t.Prefix)
+	}
+	if t.Last.Before(r.Timestamp) {
+		return notFraud("last entry of tree at %s, before entry at %s", t.Last, r.Timestamp)
+	}
+
+	var indexes []int
+	for _, pos := range r.Positions {
+		if bytes.Equal(pos.Prefix, t.Prefix) {
+			indexes = append(indexes, pos.Index)
+		}
+	}
+	if len(indexes) == 0 {
+		// The receipt omits one of the cross trees of its entry.
+		return nil
+	}
+	if m.GetProof() == nil {
+		for _, i := range indexes {
+			if i >= t.Summary.N {
+				return nil
+			}
+		}
+		return notFraud("entry within tree")
+	}
+	p, err := verifyEntry(t, m.GetData(), m.GetProof())
+	if err != nil {
+		return notFraud("entry of tree: %v", err)
+	}
+	for _, i := range indexes {
+		if i == p.Pos {
+			if bytes.Equal(m.GetData(), r.Data) {
+				return notFraud("entry in tree")
+			}
+			return nil
+		}
+	}
+	return notFraud("proof for index %d, not a position of the receipt", p.Pos)
+}
+
+// ProveEquivocation returns a fraud proof that the trees with prefix p in
+// signed summaries a and b are inconsistent, requesting proofs as needed from
+// c, which must serve the larger of the two.
+func ProveEquivocation(ctx context.Context, c servicepb.FabulaClient, p []byte, a, b *client.Summary) (*fraudpb.FraudProof, error) {
+	if a == nil || b == nil || a.Signature == nil || b.Signature == nil {
+		return nil, errors.New("summaries are not signed")
+	}
+	ta, ok := a.Tree(p)
+	if !ok {
+		return nil, fmt.Errorf("no tree %x in summary", p)
+	}
+	tb, ok := b.Tree(p)
+	if !ok {
+		return nil, fmt.Errorf("no tree %x in summary", p)
+	}
+	if ta.Summary.N > tb.Summary.N {
+		a, b, ta, tb = b, a, tb, ta
+	}
+	e := &fraudpb.Equivocation{Prefix: p, A: a.Encode(), B: b.Encode()}
+	fp := &fraudpb.FraudProof{Proof: &fraudpb.FraudProof_Equivocation{Equivocation: e}}
+	if ta.Summary.N == tb.Summary.N {
+		if ta.Summary.Equals(tb.Summary) {
+			return nil, errors.New("heads are equal")
+		}
+		return fp, nil
+	}
+	for i, pos := range merkletree.PeakPositions(ta.Summary.N) {
+		resp, err := c.InclusionProof(ctx, &servicepb.InclusionProofRequest{
+			Position: &servicepb.Position{Prefix: p, Index: uint64(pos)},
+			Size:     uint64(tb.Summary.N),
+		})
+		if err != nil {
+			return nil, err
+		}
+		entry, err := c.Entry(ctx, &servicepb.EntryRequest{Position: resp.GetPosition()})
+		if err != nil {
+			return nil, err
+		}
+		proof, err := decodeInclusionProof(resp)
+		if err != nil {
+			return nil, err
+		}
+		node, err := proof.Node(entry.GetData())
+		if err != nil {
+			return nil, err
+		}
+		if node != ta.Peaks[i] {
+			e.Data, e.Proof = entry.GetData(), resp
+			if err := equivocation(ta, tb, e.Data, e.Proof); err != nil {
+				return nil, err
+			}
+			return fp, nil
+		}
+	}
+	return nil, errors.New("heads are consistent")
+}
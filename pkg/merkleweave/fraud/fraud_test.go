@@ -0,0 +1,214 @@
+package fraud_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/fraud"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/fraudpb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// newOperator returns a signer for an operator and a key ring trusting it.
+func newOperator(t *testing.T) (*signing.Signer, signing.KeyRing) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signing.NewSigner(key), signing.NewKeyRing(pub)
+}
+
+// dial serves w signed by s over an in-memory connection and returns a client
+// for it.
+func dial(t *testing.T, w *merkleweave.MerkleWeave, s *signing.Signer) servicepb.FabulaClient {
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	servicepb.RegisterFabulaService(srv, server.New(w, server.WithSigner(s)).Service())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return servicepb.NewFabulaClient(conn)
+}
+
+// summary returns the verified summary of w signed by s.
+func summary(t *testing.T, w *merkleweave.MerkleWeave, s *signing.Signer) *client.Summary {
+	sum, err := client.New(dial(t, w, s)).Summary(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sum
+}
+
+// signReceipt returns r signed by s, as by a misbehaving operator.
+func signReceipt(s *signing.Signer, r merkleweave.Receipt) *servicepb.NotarizeResponse {
+	sig := s.SignReceipt(&r)
+	return (&client.Receipt{Receipt: r, Signature: &sig}).Encode()
+}
+
+func expectFraud(t *testing.T, k signing.KeyRing, name string, fp *fraudpb.FraudProof, want bool) {
+	t.Helper()
+	err := fraud.VerifyFraudProof(k, fp)
+	if want && err != nil {
+		t.Errorf("%s: expected fraud, got %v", name, err)
+	}
+	if !want && !errors.Is(err, fraud.ErrNotFraud) {
+		t.Errorf("%s: expected ErrNotFraud, got %v", name, err)
+	}
+}
+
+func TestEquivocation(t *testing.T) {
+	ctx := context.Background()
+	s, k := newOperator(t)
+	w1, w2 := merkleweave.New(), merkleweave.New()
+	w1.Append([]byte{1, 2, 3, 4})
+	w2.Append([]byte{1, 2, 3, 5})
+	fp, err := fraud.ProveEquivocation(ctx, dial(t, w2, s), []byte{1}, summary(t, w1, s), summary(t, w2, s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectFraud(t, k, "same size", fp, true)
+
+	// Claims must be signed by the operator.
+	_, other := newOperator(t)
+	expectFraud(t, other, "other operator", fp, false)
+	unsigned := proto.Clone(fp).(*fraudpb.FraudProof)
+	unsigned.GetEquivocation().A.Signature = nil
+	expectFraud(t, k, "unsigned", unsigned, false)
+
+	w2.Append([]byte{1, 2, 3, 6})
+	w2.Append([]byte{1, 2, 3, 7})
+	fp, err = fraud.ProveEquivocation(ctx, dial(t, w2, s), []byte{1}, summary(t, w1, s), summary(t, w2, s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectFraud(t, k, "different sizes", fp, true)
+
+	// Consistent heads.
+	old := summary(t, w1, s)
+	w1.Append([]byte{1, 2, 3, 6})
+	w1.Append([]byte{1, 2, 3, 7})
+	if _, err := fraud.ProveEquivocation(ctx, dial(t, w1, s), []byte{1}, old, summary(t, w1, s)); err == nil {
+		t.Error("expected error proving equivocation of consistent heads")
+	}
+	data, _, err := w1.Entry([]byte{1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := w1.ProveInclusion([]byte{1}, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectFraud(t, k, "consistent", &fraudpb.FraudProof{Proof: &fraudpb.FraudProof_Equivocation{Equivocation: &fraudpb.Equivocation{
+		Prefix: []byte{1},
+		A:      old.Encode(),
+		B:      summary(t, w1, s).Encode(),
+		Data:   data,
+		Proof:  fraud.NewInclusionProof([]byte{1}, p),
+	}}}, false)
+}
+
+func TestBackdating(t *testing.T) {
+	s, k := newOperator(t)
+	w := merkleweave.New()
+	for i := 0; i < 3; i++ {
+		w.Append([]byte{1, 2, byte(i), 4})
+	}
+	sum := summary(t, w, s)
+	h, _ := sum.Tree([]byte{1})
+	receipt := func(i int, ts time.Time) (*servicepb.NotarizeResponse, *servicepb.InclusionProofResponse) {
+		data, _, err := w.Entry([]byte{1}, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := w.ProveInclusion([]byte{1}, i, h.Summary.N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := merkleweave.Receipt{
+			Data:      data,
+			Timestamp: ts,
+			Positions: []merkleweave.Position{{Prefix: []byte{1}, Index: i}, {Prefix: []byte{2}, Index: i}},
+		}
+		return signReceipt(s, r), fraud.NewInclusionProof([]byte{1}, p)
+	}
+	backdating := func(i int, its time.Time, j int, jts time.Time) *fraudpb.FraudProof {
+		b := &fraudpb.Backdating{Prefix: []byte{1}, Summary: sum.Encode()}
+		b.Earlier, b.EarlierProof = receipt(i, its)
+		if j >= 0 {
+			b.Later, b.LaterProof = receipt(j, jts)
+		}
+		return &fraudpb.FraudProof{Proof: &fraudpb.FraudProof_Backdating{Backdating: b}}
+	}
+	_, ts0, _ := w.Entry([]byte{1}, 0)
+	_, ts1, _ := w.Entry([]byte{1}, 1)
+	expectFraud(t, k, "honest", backdating(0, ts0, 1, ts1), false)
+	expectFraud(t, k, "swapped", backdating(0, ts1, 1, ts0), true)
+	expectFraud(t, k, "equal", backdating(0, ts0, 1, ts0), true)
+	expectFraud(t, k, "after head", backdating(0, h.Last.Add(time.Second), -1, time.Time{}), true)
+	expectFraud(t, k, "before head", backdating(0, ts0, -1, time.Time{}), false)
+
+	// A forged receipt is not evidence.
+	forged := backdating(0, ts1, 1, ts0)
+	forged.GetBackdating().Later.Signature = forged.GetBackdating().Earlier.Signature
+	expectFraud(t, k, "forged", forged, false)
+}
+
+func TestMissingEntry(t *testing.T) {
+	s, k := newOperator(t)
+	w1, w2 := merkleweave.New(), merkleweave.New()
+	r, err := client.New(dial(t, w1, s)).Notarize(context.Background(), []byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := r.Encode()
+	missing := func(w *merkleweave.MerkleWeave, withProof bool) *fraudpb.FraudProof {
+		sum := summary(t, w, s)
+		m := &fraudpb.MissingEntry{Receipt: receipt, Prefix: []byte{2}, Summary: sum.Encode()}
+		if withProof {
+			h, _ := sum.Tree([]byte{2})
+			data, _, err := w.Entry([]byte{2}, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := w.ProveInclusion([]byte{2}, 0, h.Summary.N)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.Data, m.Proof = data, fraud.NewInclusionProof([]byte{2}, p)
+		}
+		return &fraudpb.FraudProof{Proof: &fraudpb.FraudProof_MissingEntry{MissingEntry: m}}
+	}
+
+	expectFraud(t, k, "honest", missing(w1, true), false)
+	// Tree 02 of w2 is empty at a later time.
+	if _, err := w2.Advance([]byte{2}, time.Now().Add(-time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	expectFraud(t, k, "different entry", missing(w2, true), true)
+
+	w3 := merkleweave.New()
+	w3.Append([]byte{2, 9, 9, 9})
+	receipt.Positions[1].Index = 5
+	expectFraud(t, k, "forged", missing(w3, false), false)
+	bad := r.Receipt
+	bad.Positions = []merkleweave.Position{r.Positions[0], {Prefix: []byte{2}, Index: 5}}
+	receipt = signReceipt(s, bad)
+	expectFraud(t, k, "beyond head", missing(w3, false), true)
+}
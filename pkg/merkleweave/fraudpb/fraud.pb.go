@@ -0,0 +1,598 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.6.1
+// source: fraud.proto
+
+package fraudpb
+
+import (
+	proto "github.com/golang/protobuf/proto"
+	servicepb "github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+// Equivocation shows two signed summaries with heads of the same tree that
+// cannot both be heads of one append-only tree.
+type Equivocation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// prefix of the tree.
+	Prefix []byte                          `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	A      *servicepb.WeaveSummaryResponse `protobuf:"bytes,2,opt,name=a,proto3" json:"a,omitempty"`
+	// the tree has at least as many entries in b as in a.
+	B *servicepb.WeaveSummaryResponse `protobuf:"bytes,3,opt,name=b,proto3" json:"b,omitempty"`
+	// If the tree is larger in b than in a, the entry of the tree in b at the
+	// position of a peak of the tree in a, whose node hash differs from that
+	// peak.
+	Data  []byte                            `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Proof *servicepb.InclusionProofResponse `protobuf:"bytes,5,opt,name=proof,proto3" json:"proof,omitempty"`
+}
+
+func (x *Equivocation) Reset() {
+	*x = Equivocation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fraud_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Equivocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Equivocation) ProtoMessage() {}
+
+func (x *Equivocation) ProtoReflect() protoreflect.Message {
+	mi := &file_fraud_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Equivocation.ProtoReflect.Descriptor instead.
+func (*Equivocation) Descriptor() ([]byte, []int) {
+	return file_fraud_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Equivocation) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *Equivocation) GetA() *servicepb.WeaveSummaryResponse {
+	if x != nil {
+		return x.A
+	}
+	return nil
+}
+
+func (x *Equivocation) GetB() *servicepb.WeaveSummaryResponse {
+	if x != nil {
+		return x.B
+	}
+	return nil
+}
+
+func (x *Equivocation) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Equivocation) GetProof() *servicepb.InclusionProofResponse {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+// Backdating shows signed receipts of entries of a tree whose timestamps do
+// not increase with their positions.
+type Backdating struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// prefix of the tree.
+	Prefix []byte `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// a signed summary including the entries.
+	Summary *servicepb.WeaveSummaryResponse `protobuf:"bytes,2,opt,name=summary,proto3" json:"summary,omitempty"`
+	// a receipt of an entry of the tree included in summary.
+	Earlier      *servicepb.NotarizeResponse       `protobuf:"bytes,3,opt,name=earlier,proto3" json:"earlier,omitempty"`
+	EarlierProof *servicepb.InclusionProofResponse `protobuf:"bytes,4,opt,name=earlierProof,proto3" json:"earlierProof,omitempty"`
+	// a receipt of an entry of the tree included in summary after earlier,
+	// with an earlier timestamp. If omitted, earlier has a timestamp after
+	// the last entry of the tree in summary.
+	Later      *servicepb.NotarizeResponse       `protobuf:"bytes,5,opt,name=later,proto3" json:"later,omitempty"`
+	LaterProof *servicepb.InclusionProofResponse `protobuf:"bytes,6,opt,name=laterProof,proto3" json:"laterProof,omitempty"`
+}
+
+func (x *Backdating) Reset() {
+	*x = Backdating{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fraud_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Backdating) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Backdating) ProtoMessage() {}
+
+func (x *Backdating) ProtoReflect() protoreflect.Message {
+	mi := &file_fraud_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Backdating.ProtoReflect.Descriptor instead.
+func (*Backdating) Descriptor() ([]byte, []int) {
+	return file_fraud_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Backdating) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *Backdating) GetSummary() *servicepb.WeaveSummaryResponse {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *Backdating) GetEarlier() *servicepb.NotarizeResponse {
+	if x != nil {
+		return x.Earlier
+	}
+	return nil
+}
+
+func (x *Backdating) GetEarlierProof() *servicepb.InclusionProofResponse {
+	if x != nil {
+		return x.EarlierProof
+	}
+	return nil
+}
+
+func (x *Backdating) GetLater() *servicepb.NotarizeResponse {
+	if x != nil {
+		return x.Later
+	}
+	return nil
+}
+
+func (x *Backdating) GetLaterProof() *servicepb.InclusionProofResponse {
+	if x != nil {
+		return x.LaterProof
+	}
+	return nil
+}
+
+// MissingEntry shows a signed receipt of an entry that is not in one of its
+// cross trees.
+type MissingEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Receipt *servicepb.NotarizeResponse `protobuf:"bytes,1,opt,name=receipt,proto3" json:"receipt,omitempty"`
+	// prefix of a cross tree of the entry.
+	Prefix []byte `protobuf:"bytes,2,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// a signed summary in which the last entry of the tree is at or after the
+	// timestamp of the receipt.
+	Summary *servicepb.WeaveSummaryResponse `protobuf:"bytes,3,opt,name=summary,proto3" json:"summary,omitempty"`
+	// The entry of the tree in summary at the position of the receipt, with
+	// data other than that of the receipt. Omitted if the tree is not larger
+	// than the index of the position.
+	Data  []byte                            `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	Proof *servicepb.InclusionProofResponse `protobuf:"bytes,5,opt,name=proof,proto3" json:"proof,omitempty"`
+}
+
+func (x *MissingEntry) Reset() {
+	*x = MissingEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fraud_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MissingEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MissingEntry) ProtoMessage() {}
+
+func (x *MissingEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_fraud_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MissingEntry.ProtoReflect.Descriptor instead.
+func (*MissingEntry) Descriptor() ([]byte, []int) {
+	return file_fraud_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MissingEntry) GetReceipt() *servicepb.NotarizeResponse {
+	if x != nil {
+		return x.Receipt
+	}
+	return nil
+}
+
+func (x *MissingEntry) GetPrefix() []byte {
+	if x != nil {
+		return x.Prefix
+	}
+	return nil
+}
+
+func (x *MissingEntry) GetSummary() *servicepb.WeaveSummaryResponse {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *MissingEntry) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *MissingEntry) GetProof() *servicepb.InclusionProofResponse {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+// FraudProof shows that an operator has misbehaved.
+type FraudProof struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Proof:
+	//	*FraudProof_Equivocation
+	//	*FraudProof_Backdating
+	//	*FraudProof_MissingEntry
+	Proof isFraudProof_Proof `protobuf_oneof:"proof"`
+}
+
+func (x *FraudProof) Reset() {
+	*x = FraudProof{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_fraud_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FraudProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FraudProof) ProtoMessage() {}
+
+func (x *FraudProof) ProtoReflect() protoreflect.Message {
+	mi := &file_fraud_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FraudProof.ProtoReflect.Descriptor instead.
+func (*FraudProof) Descriptor() ([]byte, []int) {
+	return file_fraud_proto_rawDescGZIP(), []int{3}
+}
+
+func (m *FraudProof) GetProof() isFraudProof_Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (x *FraudProof) GetEquivocation() *Equivocation {
+	if x, ok := x.GetProof().(*FraudProof_Equivocation); ok {
+		return x.Equivocation
+	}
+	return nil
+}
+
+func (x *FraudProof) GetBackdating() *Backdating {
+	if x, ok := x.GetProof().(*FraudProof_Backdating); ok {
+		return x.Backdating
+	}
+	return nil
+}
+
+func (x *FraudProof) GetMissingEntry() *MissingEntry {
+	if x, ok := x.GetProof().(*FraudProof_MissingEntry); ok {
+		return x.MissingEntry
+	}
+	return nil
+}
+
+type isFraudProof_Proof interface {
+	isFraudProof_Proof()
+}
+
+type FraudProof_Equivocation struct {
+	Equivocation *Equivocation `protobuf:"bytes,1,opt,name=equivocation,proto3,oneof"`
+}
+
+type FraudProof_Backdating struct {
+	Backdating *Backdating `protobuf:"bytes,2,opt,name=backdating,proto3,oneof"`
+}
+
+type FraudProof_MissingEntry struct {
+	MissingEntry *MissingEntry `protobuf:"bytes,3,opt,name=missingEntry,proto3,oneof"`
+}
+
+func (*FraudProof_Equivocation) isFraudProof_Proof() {}
+
+func (*FraudProof_Backdating) isFraudProof_Proof() {}
+
+func (*FraudProof_MissingEntry) isFraudProof_Proof() {}
+
+var File_fraud_proto protoreflect.FileDescriptor
+
+var file_fraud_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x66, 0x72, 0x61, 0x75, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x14, 0x6d,
+	0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x1a, 0x0d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x22, 0xf2, 0x01, 0x0a, 0x0c, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x38, 0x0a, 0x01, 0x61,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77,
+	0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x57, 0x65,
+	0x61, 0x76, 0x65, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x52, 0x01, 0x61, 0x12, 0x38, 0x0a, 0x01, 0x62, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x2a, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x53, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x01, 0x62, 0x12,
+	0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x12, 0x42, 0x0a, 0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73,
+	0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x52, 0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0x8a, 0x03, 0x0a, 0x0a, 0x42, 0x61, 0x63, 0x6b,
+	0x64, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12, 0x44,
+	0x0a, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x2a, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x53, 0x75, 0x6d, 0x6d,
+	0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07, 0x73, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x79, 0x12, 0x40, 0x0a, 0x07, 0x65, 0x61, 0x72, 0x6c, 0x69, 0x65, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65,
+	0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4e, 0x6f, 0x74,
+	0x61, 0x72, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07, 0x65,
+	0x61, 0x72, 0x6c, 0x69, 0x65, 0x72, 0x12, 0x50, 0x0a, 0x0c, 0x65, 0x61, 0x72, 0x6c, 0x69, 0x65,
+	0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x6d,
+	0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f,
+	0x6f, 0x66, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x0c, 0x65, 0x61, 0x72, 0x6c,
+	0x69, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x3c, 0x0a, 0x05, 0x6c, 0x61, 0x74, 0x65,
+	0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65,
+	0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4e,
+	0x6f, 0x74, 0x61, 0x72, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52,
+	0x05, 0x6c, 0x61, 0x74, 0x65, 0x72, 0x12, 0x4c, 0x0a, 0x0a, 0x6c, 0x61, 0x74, 0x65, 0x72, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x6d, 0x65, 0x72,
+	0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x0a, 0x6c, 0x61, 0x74, 0x65, 0x72, 0x50,
+	0x72, 0x6f, 0x6f, 0x66, 0x22, 0x86, 0x02, 0x0a, 0x0c, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x40, 0x0a, 0x07, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x26, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77,
+	0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4e, 0x6f,
+	0x74, 0x61, 0x72, 0x69, 0x7a, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07,
+	0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69,
+	0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x12,
+	0x44, 0x0a, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x2a, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x57, 0x65, 0x61, 0x76, 0x65, 0x53, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x79, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x07, 0x73, 0x75,
+	0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x42, 0x0a, 0x05, 0x70, 0x72, 0x6f,
+	0x6f, 0x66, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x2c, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c,
+	0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e,
+	0x49, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x22, 0xed, 0x01,
+	0x0a, 0x0a, 0x46, 0x72, 0x61, 0x75, 0x64, 0x50, 0x72, 0x6f, 0x6f, 0x66, 0x12, 0x48, 0x0a, 0x0c,
+	0x65, 0x71, 0x75, 0x69, 0x76, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x22, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x45, 0x71, 0x75, 0x69, 0x76, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x00, 0x52, 0x0c, 0x65, 0x71, 0x75, 0x69, 0x76, 0x6f,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x42, 0x0a, 0x0a, 0x62, 0x61, 0x63, 0x6b, 0x64, 0x61,
+	0x74, 0x69, 0x6e, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6d, 0x65, 0x72,
+	0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x42, 0x61, 0x63, 0x6b, 0x64, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x48, 0x00, 0x52, 0x0a,
+	0x62, 0x61, 0x63, 0x6b, 0x64, 0x61, 0x74, 0x69, 0x6e, 0x67, 0x12, 0x48, 0x0a, 0x0c, 0x6d, 0x69,
+	0x73, 0x73, 0x69, 0x6e, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x22, 0x2e, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x48, 0x00, 0x52, 0x0c, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x42, 0x07, 0x0a, 0x05, 0x70, 0x72, 0x6f, 0x6f, 0x66, 0x42, 0x38, 0x5a,
+	0x36, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76, 0x73, 0x65, 0x6b,
+	0x68, 0x61, 0x72, 0x2f, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2f,
+	0x70, 0x6b, 0x67, 0x2f, 0x6d, 0x65, 0x72, 0x6b, 0x6c, 0x65, 0x77, 0x65, 0x61, 0x76, 0x65, 0x2f,
+	0x66, 0x72, 0x61, 0x75, 0x64, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_fraud_proto_rawDescOnce sync.Once
+	file_fraud_proto_rawDescData = file_fraud_proto_rawDesc
+)
+
+func file_fraud_proto_rawDescGZIP() []byte {
+	file_fraud_proto_rawDescOnce.Do(func() {
+		file_fraud_proto_rawDescData = protoimpl.X.CompressGZIP(file_fraud_proto_rawDescData)
+	})
+	return file_fraud_proto_rawDescData
+}
+
+var file_fraud_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_fraud_proto_goTypes = []interface{}{
+	(*Equivocation)(nil),                     // 0: merkleweave.protobuf.Equivocation
+	(*Backdating)(nil),                       // 1: merkleweave.protobuf.Backdating
+	(*MissingEntry)(nil),                     // 2: merkleweave.protobuf.MissingEntry
+	(*FraudProof)(nil),                       // 3: merkleweave.protobuf.FraudProof
+	(*servicepb.WeaveSummaryResponse)(nil),   // 4: merkleweave.protobuf.WeaveSummaryResponse
+	(*servicepb.InclusionProofResponse)(nil), // 5: merkleweave.protobuf.InclusionProofResponse
+	(*servicepb.NotarizeResponse)(nil),       // 6: merkleweave.protobuf.NotarizeResponse
+}
+var file_fraud_proto_depIdxs = []int32{
+	4,  // 0: merkleweave.protobuf.Equivocation.a:type_name -> merkleweave.protobuf.WeaveSummaryResponse
+	4,  // 1: merkleweave.protobuf.Equivocation.b:type_name -> merkleweave.protobuf.WeaveSummaryResponse
+	5,  // 2: merkleweave.protobuf.Equivocation.proof:type_name -> merkleweave.protobuf.InclusionProofResponse
+	4,  // 3: merkleweave.protobuf.Backdating.summary:type_name -> merkleweave.protobuf.WeaveSummaryResponse
+	6,  // 4: merkleweave.protobuf.Backdating.earlier:type_name -> merkleweave.protobuf.NotarizeResponse
+	5,  // 5: merkleweave.protobuf.Backdating.earlierProof:type_name -> merkleweave.protobuf.InclusionProofResponse
+	6,  // 6: merkleweave.protobuf.Backdating.later:type_name -> merkleweave.protobuf.NotarizeResponse
+	5,  // 7: merkleweave.protobuf.Backdating.laterProof:type_name -> merkleweave.protobuf.InclusionProofResponse
+	6,  // 8: merkleweave.protobuf.MissingEntry.receipt:type_name -> merkleweave.protobuf.NotarizeResponse
+	4,  // 9: merkleweave.protobuf.MissingEntry.summary:type_name -> merkleweave.protobuf.WeaveSummaryResponse
+	5,  // 10: merkleweave.protobuf.MissingEntry.proof:type_name -> merkleweave.protobuf.InclusionProofResponse
+	0,  // 11: merkleweave.protobuf.FraudProof.equivocation:type_name -> merkleweave.protobuf.Equivocation
+	1,  // 12: merkleweave.protobuf.FraudProof.backdating:type_name -> merkleweave.protobuf.Backdating
+	2,  // 13: merkleweave.protobuf.FraudProof.missingEntry:type_name -> merkleweave.protobuf.MissingEntry
+	14, // [14:14] is the sub-list for method output_type
+	14, // [14:14] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_fraud_proto_init() }
+func file_fraud_proto_init() {
+	if File_fraud_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_fraud_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Equivocation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_fraud_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Backdating); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_fraud_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MissingEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_fraud_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FraudProof); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_fraud_proto_msgTypes[3].OneofWrappers = []interface{}{
+		(*FraudProof_Equivocation)(nil),
+		(*FraudProof_Backdating)(nil),
+		(*FraudProof_MissingEntry)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_fraud_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_fraud_proto_goTypes,
+		DependencyIndexes: file_fraud_proto_depIdxs,
+		MessageInfos:      file_fraud_proto_msgTypes,
+	}.Build()
+	File_fraud_proto = out.File
+	file_fraud_proto_rawDesc = nil
+	file_fraud_proto_goTypes = nil
+	file_fraud_proto_depIdxs = nil
+}
@@ -0,0 +1,3 @@
+package fraudpb
+
+//go:generate protoc -I ../../../proto --go_out=../../.. --go_opt=module=github.com/vsekhar/merkleweave ../../../proto/fraud.proto
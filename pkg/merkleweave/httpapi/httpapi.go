@@ -0,0 +1,551 @@
+// Package httpapi serves the Fabula service as JSON over HTTP, encoded as in
+// package jsonapi.
+//
+//	GET  /summary?minTimestamp=&prefixesWithMinTimestamp=&prefixesToReturn=
+//	POST /notarize                   {"hash": ...}
+//	GET  /entry?prefix=&index=
+//	GET  /proof/inclusion?prefix=&index=&size=
+//	GET  /proof/consistency?prefix=&from=&to=
+//	GET  /summarylog/inclusion?index=&size=
+//	GET  /summarylog/consistency?from=&to=
+//	GET  /rfc6962/sth?prefix=
+//	GET  /rfc6962/proof/inclusion?prefix=&leafIndex=&treeSize=
+//	GET  /rfc6962/proof/consistency?prefix=&first=&second=
+//
+// Prefix query parameters may be repeated.
+package httpapi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/jsonapi"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxRequestBytes is the largest request body accepted, ample for a hash.
+const maxRequestBytes = 64 << 10
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type handler struct {
+	s           *server.Server
+	interceptor grpc.UnaryServerInterceptor
+}
+
+// Option configures a handler.
+type Option func(*handler)
+
+// WithInterceptor runs requests through i, as a gRPC server would. HTTP
+// request headers are passed to i as incoming metadata.
+func WithInterceptor(i grpc.UnaryServerInterceptor) Option {
+	return func(h *handler) {
+		h.interceptor = i
+	}
+}
+
+// NewHandler returns a handler serving s. To mount it under a path other than
+// the root, use http.StripPrefix.
+func NewHandler(s *server.Server, opts ...Option) http.Handler {
+	h := &handler{s: s}
+	for _, opt := range opts {
+		opt(h)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/summary", h.summary)
+	mux.HandleFunc("/notarize", h.notarize)
+	mux.HandleFunc("/entry", h.entry)
+	mux.HandleFunc("/proof/inclusion", h.inclusionProof)
+	mux.HandleFunc("/proof/consistency", h.consistencyProof)
+	mux.HandleFunc("/summarylog/inclusion", h.summaryLogInclusionProof)
+	mux.HandleFunc("/summarylog/consistency", h.summaryLogConsistencyProof)
+	mux.HandleFunc("/rfc6962/sth", h.rfc6962TreeHead)
+	mux.HandleFunc("/rfc6962/proof/inclusion", h.rfc6962InclusionProof)
+	mux.HandleFunc("/rfc6962/proof/consistency", h.rfc6962ConsistencyProof)
+	return mux
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func encodeAll(bs [][]byte) []string {
+	r := make([]string, len(bs))
+	for i, b := range bs {
+		r[i] = encode(b)
+	}
+	return r
+}
+
+func encodePath(p *servicepb.ProofPath) []jsonapi.Step {
+	r := make([]jsonapi.Step, 0, len(p.GetSteps()))
+	for _, s := range p.GetSteps() {
+		r = append(r, jsonapi.Step{Sibling: encode(s.GetSibling()), Data: encode(s.GetData())})
+	}
+	return r
+}
+
+func encodePosition(p *servicepb.Position) jsonapi.Position {
+	return jsonapi.Position{Prefix: hex.EncodeToString(p.GetPrefix()), Index: p.GetIndex()}
+}
+
+func encodeSignature(sig *servicepb.SummarySignature) *jsonapi.Signature {
+	return &jsonapi.Signature{
+		KeyID:     encode(sig.GetKeyId()),
+		Time:      sig.GetTime().AsTime(),
+		Signature: encode(sig.GetSignature()),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
+
+// httpCodes maps gRPC status codes to HTTP status codes.
+var httpCodes = map[codes.Code]int{
+	codes.InvalidArgument:   http.StatusBadRequest,
+	codes.NotFound:          http.StatusNotFound,
+	codes.Unauthenticated:   http.StatusUnauthorized,
+	codes.PermissionDenied:  http.StatusForbidden,
+	codes.ResourceExhausted: http.StatusTooManyRequests,
+	codes.Unimplemented:     http.StatusNotImplemented,
+	codes.Unavailable:       http.StatusServiceUnavailable,
+}
+
+func writeStatus(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	code, ok := httpCodes[st.Code()]
+	if !ok {
+		code = http.StatusInternalServerError
+	}
+	writeJSON(w, code, errorResponse{Error: st.Message()})
+}
+
+// call runs f on req, through the interceptor of h if any.
+func (h *handler) call(r *http.Request, method string, req interface{}, f grpc.UnaryHandler) (interface{}, error) {
+	if h.interceptor == nil {
+		return f(r.Context(), req)
+	}
+	md := metadata.MD{}
+	for k, vs := range r.Header {
+		md.Append(strings.ToLower(k), vs...)
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	info := &grpc.UnaryServerInfo{
+		Server:     h.s,
+		FullMethod: "/merkleweave.protobuf.Fabula/" + method,
+	}
+	return h.interceptor(ctx, req, info, f)
+}
+
+func method(w http.ResponseWriter, r *http.Request, m string) bool {
+	if r.Method != m {
+		w.Header().Set("Allow", m)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return false
+	}
+	return true
+}
+
+func prefixes(r *http.Request, key string) ([][]byte, error) {
+	var ps [][]byte
+	for _, v := range r.URL.Query()[key] {
+		p, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+func uintParam(r *http.Request, key string) (uint64, error) {
+	v, err := strconv.ParseUint(r.URL.Query().Get(key), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", key, err)
+	}
+	return v, nil
+}
+
+func positionParam(r *http.Request) (*servicepb.Position, error) {
+	p, err := hex.DecodeString(r.URL.Query().Get("prefix"))
+	if err != nil {
+		return nil, fmt.Errorf("prefix: %v", err)
+	}
+	i, err := uintParam(r, "index")
+	if err != nil {
+		return nil, err
+	}
+	return &servicepb.Position{Prefix: p, Index: i}, nil
+}
+
+func (h *handler) summary(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	req := &servicepb.WeaveSummaryRequest{}
+	var err error
+	if v := r.URL.Query().Get("minTimestamp"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("minTimestamp: %v", err))
+			return
+		}
+		req.MinTimestamp = timestamppb.New(t)
+	}
+	if req.PrefixesWithMinTimestamp, err = prefixes(r, "prefixesWithMinTimestamp"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.PrefixesToReturn, err = prefixes(r, "prefixesToReturn"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	v, err := h.call(r, "WeaveSummary", req, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.WeaveSummary(ctx, req.(*servicepb.WeaveSummaryRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	resp := v.(*servicepb.WeaveSummaryResponse)
+	ws := jsonapi.WeaveSummary{Trees: make([]jsonapi.TreeSummary, 0, len(resp.GetTrees())), Entries: resp.GetEntries()}
+	for _, t := range resp.GetTrees() {
+		ts := jsonapi.TreeSummary{
+			Prefix: hex.EncodeToString(t.GetPrefix()),
+			Size:   t.GetSummary().GetSize(),
+			Peaks:  encodeAll(t.GetSummary().GetHashes()),
+
+			Algorithm: merkletree.Algorithm(t.GetSummary().GetAlgorithm()),
+		}
+		if t.GetSummary().GetLast() != nil {
+			last := t.GetSummary().GetLast().AsTime()
+			ts.Last = &last
+		}
+		ws.Trees = append(ws.Trees, ts)
+	}
+	if sig := resp.GetSignature(); sig != nil {
+		ws.Signature = encodeSignature(sig)
+	}
+	if l := resp.GetLog(); l != nil {
+		ws.Log = &jsonapi.SummaryLogEntry{
+			Index: l.GetIndex(),
+			Size:  l.GetHead().GetSize(),
+			Peaks: encodeAll(l.GetHead().GetHashes()),
+
+			Algorithm: merkletree.Algorithm(l.GetHead().GetAlgorithm()),
+		}
+	}
+	writeJSON(w, http.StatusOK, ws)
+}
+
+func (h *handler) notarize(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodPost) {
+		return
+	}
+	var nr jsonapi.NotarizeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&nr); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	hash, err := base64.RawURLEncoding.DecodeString(nr.Hash)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("hash: %v", err))
+		return
+	}
+	v, err := h.call(r, "Notarize", &servicepb.NotarizeRequest{Hash: hash}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.Notarize(ctx, req.(*servicepb.NotarizeRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	resp := v.(*servicepb.NotarizeResponse)
+	rc := jsonapi.Receipt{
+		Hash:      encode(resp.GetHash()),
+		Timestamp: resp.GetTimestamp().AsTime(),
+	}
+	for _, p := range resp.GetPositions() {
+		rc.Positions = append(rc.Positions, encodePosition(p))
+	}
+	if sig := resp.GetSignature(); sig != nil {
+		rc.Signature = encodeSignature(sig)
+	}
+	writeJSON(w, http.StatusOK, rc)
+}
+
+func (h *handler) entry(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	pos, err := positionParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	v, err := h.call(r, "Entry", &servicepb.EntryRequest{Position: pos}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.Entry(ctx, req.(*servicepb.EntryRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	resp := v.(*servicepb.EntryResponse)
+	writeJSON(w, http.StatusOK, jsonapi.Entry{
+		Data:      encode(resp.GetData()),
+		Timestamp: resp.GetTimestamp().AsTime(),
+	})
+}
+
+func (h *handler) inclusionProof(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	pos, err := positionParam(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	size, err := uintParam(r, "size")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	v, err := h.call(r, "InclusionProof", &servicepb.InclusionProofRequest{Position: pos, Size: size}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.InclusionProof(ctx, req.(*servicepb.InclusionProofRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	writeInclusionProof(w, v.(*servicepb.InclusionProofResponse))
+}
+
+func writeInclusionProof(w http.ResponseWriter, resp *servicepb.InclusionProofResponse) {
+	writeJSON(w, http.StatusOK, jsonapi.InclusionProof{
+		Position: encodePosition(resp.GetPosition()),
+		Size:     resp.GetSize(),
+		Children: encodeAll(resp.GetChildren()),
+		Path:     encodePath(resp.GetPath()),
+		Peaks:    encodeAll(resp.GetPeaks()),
+
+		Algorithm: merkletree.Algorithm(resp.GetAlgorithm()),
+	})
+}
+
+func (h *handler) consistencyProof(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	prefix, err := hex.DecodeString(r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("prefix: %v", err))
+		return
+	}
+	from, err := uintParam(r, "from")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	to, err := uintParam(r, "to")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	v, err := h.call(r, "ConsistencyProof", &servicepb.ConsistencyProofRequest{Prefix: prefix, From: from, To: to}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.ConsistencyProof(ctx, req.(*servicepb.ConsistencyProofRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	writeConsistencyProof(w, v.(*servicepb.ConsistencyProofResponse))
+}
+
+func writeConsistencyProof(w http.ResponseWriter, resp *servicepb.ConsistencyProofResponse) {
+	cp := jsonapi.ConsistencyProof{
+		Prefix:   hex.EncodeToString(resp.GetPrefix()),
+		From:     resp.GetFrom(),
+		To:       resp.GetTo(),
+		OldPeaks: encodeAll(resp.GetOldPeaks()),
+		NewPeaks: encodeAll(resp.GetNewPeaks()),
+		Paths:    make([][]jsonapi.Step, 0, len(resp.GetPaths())),
+
+		Algorithm: merkletree.Algorithm(resp.GetAlgorithm()),
+	}
+	for _, p := range resp.GetPaths() {
+		cp.Paths = append(cp.Paths, encodePath(p))
+	}
+	writeJSON(w, http.StatusOK, cp)
+}
+
+func (h *handler) summaryLogInclusionProof(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	index, err := uintParam(r, "index")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	size, err := uintParam(r, "size")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	v, err := h.call(r, "SummaryLogInclusionProof", &servicepb.SummaryLogInclusionProofRequest{Index: index, Size: size}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.SummaryLogInclusionProof(ctx, req.(*servicepb.SummaryLogInclusionProofRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	writeInclusionProof(w, v.(*servicepb.InclusionProofResponse))
+}
+
+func (h *handler) summaryLogConsistencyProof(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	from, err := uintParam(r, "from")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	to, err := uintParam(r, "to")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	v, err := h.call(r, "SummaryLogConsistencyProof", &servicepb.SummaryLogConsistencyProofRequest{From: from, To: to}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.SummaryLogConsistencyProof(ctx, req.(*servicepb.SummaryLogConsistencyProofRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	writeConsistencyProof(w, v.(*servicepb.ConsistencyProofResponse))
+}
+
+func (h *handler) rfc6962TreeHead(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	prefix, err := hex.DecodeString(r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("prefix: %v", err))
+		return
+	}
+	v, err := h.call(r, "RFC6962TreeHead", &servicepb.RFC6962TreeHeadRequest{Prefix: prefix}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.RFC6962TreeHead(ctx, req.(*servicepb.RFC6962TreeHeadRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	resp := v.(*servicepb.RFC6962TreeHeadResponse)
+	th := jsonapi.RFC6962TreeHead{
+		Prefix:         hex.EncodeToString(resp.GetPrefix()),
+		TreeSize:       resp.GetTreeSize(),
+		Timestamp:      resp.GetTimestamp().AsTime(),
+		SHA256RootHash: encode(resp.GetSha256RootHash()),
+	}
+	if sig := resp.GetSignature(); sig != nil {
+		th.Signature = encodeSignature(sig)
+	}
+	writeJSON(w, http.StatusOK, th)
+}
+
+func (h *handler) rfc6962InclusionProof(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	prefix, err := hex.DecodeString(r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("prefix: %v", err))
+		return
+	}
+	index, err := uintParam(r, "leafIndex")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	size, err := uintParam(r, "treeSize")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	req := &servicepb.RFC6962InclusionProofRequest{Prefix: prefix, LeafIndex: index, TreeSize: size}
+	v, err := h.call(r, "RFC6962InclusionProof", req, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.RFC6962InclusionProof(ctx, req.(*servicepb.RFC6962InclusionProofRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	resp := v.(*servicepb.RFC6962InclusionProofResponse)
+	writeJSON(w, http.StatusOK, jsonapi.RFC6962InclusionProof{
+		Prefix:    hex.EncodeToString(resp.GetPrefix()),
+		LeafIndex: resp.GetLeafIndex(),
+		TreeSize:  resp.GetTreeSize(),
+		AuditPath: encodeAll(resp.GetAuditPath()),
+	})
+}
+
+func (h *handler) rfc6962ConsistencyProof(w http.ResponseWriter, r *http.Request) {
+	if !method(w, r, http.MethodGet) {
+		return
+	}
+	prefix, err := hex.DecodeString(r.URL.Query().Get("prefix"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("prefix: %v", err))
+		return
+	}
+	first, err := uintParam(r, "first")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	second, err := uintParam(r, "second")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	req := &servicepb.RFC6962ConsistencyProofRequest{Prefix: prefix, First: first, Second: second}
+	v, err := h.call(r, "RFC6962ConsistencyProof", req, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return h.s.RFC6962ConsistencyProof(ctx, req.(*servicepb.RFC6962ConsistencyProofRequest))
+	})
+	if err != nil {
+		writeStatus(w, err)
+		return
+	}
+	resp := v.(*servicepb.RFC6962ConsistencyProofResponse)
+	writeJSON(w, http.StatusOK, jsonapi.RFC6962ConsistencyProof{
+		Prefix:      hex.EncodeToString(resp.GetPrefix()),
+		First:       resp.GetFirst(),
+		Second:      resp.GetSecond(),
+		Consistency: encodeAll(resp.GetConsistency()),
+	})
+}
@@ -0,0 +1,152 @@
+package httpapi_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/httpapi"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/jsonapi"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/rfc6962"
+)
+
+func get(t *testing.T, url string, code int, v interface{}) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != code {
+		t.Fatalf("GET %s: status %d, expected %d", url, resp.StatusCode, code)
+	}
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	ts := httptest.NewServer(httpapi.NewHandler(server.New(merkleweave.New())))
+	defer ts.Close()
+
+	hash := []byte{0xab, 0xcd, 0xef, 0x01}
+	body := fmt.Sprintf(`{"hash": %q}`, base64.RawURLEncoding.EncodeToString(hash))
+	resp, err := http.Post(ts.URL+"/notarize", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /notarize: status %d", resp.StatusCode)
+	}
+	var r jsonapi.Receipt
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Positions) != 2 || r.Positions[0].Prefix != "ab" || r.Positions[1].Prefix != "cd" {
+		t.Fatalf("unexpected positions %+v", r.Positions)
+	}
+
+	var e jsonapi.Entry
+	get(t, ts.URL+"/entry?prefix=ab&index=0", http.StatusOK, &e)
+	if e.Data != r.Hash || !e.Timestamp.Equal(r.Timestamp) {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	var s jsonapi.WeaveSummary
+	get(t, ts.URL+"/summary?prefixesToReturn=ab&prefixesToReturn=cd", http.StatusOK, &s)
+	if len(s.Trees) != 2 || s.Trees[0].Size != 1 || len(s.Trees[0].Peaks) != 1 {
+		t.Errorf("unexpected summary %+v", s)
+	}
+
+	var ip jsonapi.InclusionProof
+	get(t, ts.URL+"/proof/inclusion?prefix=cd&index=0&size=1", http.StatusOK, &ip)
+	if ip.Peaks[0] != s.Trees[1].Peaks[0] {
+		t.Errorf("unexpected inclusion proof %+v", ip)
+	}
+
+	var cp jsonapi.ConsistencyProof
+	get(t, ts.URL+"/proof/consistency?prefix=cd&from=0&to=1", http.StatusOK, &cp)
+	if len(cp.OldPeaks) != 0 || len(cp.NewPeaks) != 1 {
+		t.Errorf("unexpected consistency proof %+v", cp)
+	}
+
+	var th jsonapi.RFC6962TreeHead
+	get(t, ts.URL+"/rfc6962/sth?prefix=cd", http.StatusOK, &th)
+	leaf := rfc6962.LeafHash(hash)
+	if th.TreeSize != 1 || th.SHA256RootHash != base64.RawURLEncoding.EncodeToString(leaf[:]) {
+		t.Errorf("unexpected tree head %+v", th)
+	}
+
+	var rip jsonapi.RFC6962InclusionProof
+	get(t, ts.URL+"/rfc6962/proof/inclusion?prefix=cd&leafIndex=0&treeSize=1", http.StatusOK, &rip)
+	if rip.TreeSize != 1 || len(rip.AuditPath) != 0 {
+		t.Errorf("unexpected RFC 6962 inclusion proof %+v", rip)
+	}
+
+	var rcp jsonapi.RFC6962ConsistencyProof
+	get(t, ts.URL+"/rfc6962/proof/consistency?prefix=cd&first=1&second=1", http.StatusOK, &rcp)
+	if rcp.Second != 1 || len(rcp.Consistency) != 0 {
+		t.Errorf("unexpected RFC 6962 consistency proof %+v", rcp)
+	}
+
+	get(t, ts.URL+"/entry?prefix=ab&index=1", http.StatusNotFound, nil)
+	get(t, ts.URL+"/entry?prefix=zz&index=0", http.StatusBadRequest, nil)
+	get(t, ts.URL+"/proof/inclusion?prefix=ab&index=0&size=2", http.StatusBadRequest, nil)
+	get(t, ts.URL+"/rfc6962/proof/inclusion?prefix=cd&leafIndex=1&treeSize=1", http.StatusBadRequest, nil)
+	get(t, ts.URL+"/notarize", http.StatusMethodNotAllowed, nil)
+
+	big := fmt.Sprintf(`{"hash": %q}`, strings.Repeat("A", 1<<20))
+	resp, err = http.Post(ts.URL+"/notarize", "application/json", strings.NewReader(big))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("POST /notarize with large body: status %d, expected %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestHandlerInterceptor(t *testing.T) {
+	a := server.NewQuotaAccountant(server.Quota{
+		Window: time.Hour,
+		Limit:  server.Cost{Notarizations: 1},
+	})
+	i := server.UnaryServerInterceptor(a, server.MetadataCaller("x-caller"))
+	ts := httptest.NewServer(httpapi.NewHandler(server.New(merkleweave.New()), httpapi.WithInterceptor(i)))
+	defer ts.Close()
+
+	notarize := func(caller string) int {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/notarize", strings.NewReader(`{"hash": "AQIDBA"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if caller != "" {
+			req.Header.Set("X-Caller", caller)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := notarize(""); code != http.StatusUnauthorized {
+		t.Errorf("expected %d, got %d", http.StatusUnauthorized, code)
+	}
+	if code := notarize("alice"); code != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, code)
+	}
+	if code := notarize("alice"); code != http.StatusTooManyRequests {
+		t.Errorf("expected %d, got %d", http.StatusTooManyRequests, code)
+	}
+}
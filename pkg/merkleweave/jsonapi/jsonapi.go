@@ -0,0 +1,131 @@
+// Package jsonapi defines the JSON encodings of Fabula responses, shared by
+// the HTTP API (package httpapi) and the summaries and receipts saved by
+// clients (package client).
+//
+// Hashes and data are encoded as unpadded base64url strings, as in
+// merkletree.Summary.String(). Prefixes are encoded as hex strings.
+package jsonapi
+
+import (
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+)
+
+// TreeSummary is the summary of a tree.
+type TreeSummary struct {
+	Prefix    string               `json:"prefix"`
+	Size      uint64               `json:"size"`
+	Last      *time.Time           `json:"last,omitempty"`
+	Peaks     []string             `json:"peaks"`
+	Algorithm merkletree.Algorithm `json:"algorithm"`
+}
+
+// Signature is a signature of a WeaveSummary or a Receipt.
+type Signature struct {
+	KeyID     string    `json:"keyId"`
+	Time      time.Time `json:"time"`
+	Signature string    `json:"signature"`
+}
+
+// SummaryLogEntry is the position of a summary in the summary log.
+type SummaryLogEntry struct {
+	Index     uint64               `json:"index"`
+	Size      uint64               `json:"size"`
+	Peaks     []string             `json:"peaks"`
+	Algorithm merkletree.Algorithm `json:"algorithm"`
+}
+
+// WeaveSummary is the summary of a Merkle weave.
+type WeaveSummary struct {
+	Trees   []TreeSummary `json:"trees"`
+	Entries uint64        `json:"entries,omitempty"`
+
+	// Signature is the signature of the operator, if any.
+	Signature *Signature `json:"signature,omitempty"`
+
+	// Cosignatures are the signatures of witnesses, if any.
+	Cosignatures []Signature `json:"cosignatures,omitempty"`
+
+	Log *SummaryLogEntry `json:"log,omitempty"`
+}
+
+// NotarizeRequest is the body of a notarization request.
+type NotarizeRequest struct {
+	Hash string `json:"hash"`
+}
+
+// Position is the position of an entry in a tree.
+type Position struct {
+	Prefix string `json:"prefix"`
+	Index  uint64 `json:"index"`
+}
+
+// Receipt is the result of a notarization.
+type Receipt struct {
+	Hash      string     `json:"hash"`
+	Timestamp time.Time  `json:"timestamp"`
+	Positions []Position `json:"positions"`
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// Entry is an entry in a tree.
+type Entry struct {
+	Data      string    `json:"data"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Step is a step in a proof from a node up to its parent.
+type Step struct {
+	Sibling string `json:"sibling"`
+	Data    string `json:"data"`
+}
+
+// InclusionProof is a proof that an entry is included in a tree.
+type InclusionProof struct {
+	Position  Position             `json:"position"`
+	Size      uint64               `json:"size"`
+	Children  []string             `json:"children,omitempty"`
+	Path      []Step               `json:"path"`
+	Peaks     []string             `json:"peaks"`
+	Algorithm merkletree.Algorithm `json:"algorithm"`
+}
+
+// ConsistencyProof is a proof that a tree of one size is a prefix of the same
+// tree of a larger size.
+type ConsistencyProof struct {
+	Prefix    string               `json:"prefix,omitempty"`
+	From      uint64               `json:"from"`
+	To        uint64               `json:"to"`
+	OldPeaks  []string             `json:"oldPeaks"`
+	Paths     [][]Step             `json:"paths"`
+	NewPeaks  []string             `json:"newPeaks"`
+	Algorithm merkletree.Algorithm `json:"algorithm"`
+}
+
+// RFC6962TreeHead is the head of the RFC 6962 Merkle tree of the entries of a
+// tree.
+type RFC6962TreeHead struct {
+	Prefix         string     `json:"prefix"`
+	TreeSize       uint64     `json:"treeSize"`
+	Timestamp      time.Time  `json:"timestamp"`
+	SHA256RootHash string     `json:"sha256RootHash"`
+	Signature      *Signature `json:"signature,omitempty"`
+}
+
+// RFC6962InclusionProof is the RFC 6962 audit path of an entry of a tree.
+type RFC6962InclusionProof struct {
+	Prefix    string   `json:"prefix"`
+	LeafIndex uint64   `json:"leafIndex"`
+	TreeSize  uint64   `json:"treeSize"`
+	AuditPath []string `json:"auditPath"`
+}
+
+// RFC6962ConsistencyProof is the RFC 6962 consistency proof of a tree between
+// two sizes.
+type RFC6962ConsistencyProof struct {
+	Prefix      string   `json:"prefix"`
+	First       uint64   `json:"first"`
+	Second      uint64   `json:"second"`
+	Consistency []string `json:"consistency"`
+}
@@ -5,13 +5,20 @@ package merkleweave
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/vsekhar/merkleweave/internal/merkletree"
+	"github.com/vsekhar/merkleweave/driver"
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/rfc6962"
+	"golang.org/x/crypto/sha3"
 )
 
 const prefixBytes = 1
@@ -19,6 +26,10 @@ const numTrees = 1 << (prefixBytes * 8)
 const numCrossTrees = 2
 const minDataLen = prefixBytes * numCrossTrees
 
+// sentinelLen is the length of sentinel entries, matching the length of a
+// SHA3-256 hash.
+const sentinelLen = 32
+
 type prefix [prefixBytes]byte
 
 func (p *prefix) Less(p2 prefix) bool {
@@ -73,6 +84,39 @@ func fromHex(s string) prefix {
 	return r
 }
 
+func fromBytes(b []byte) (prefix, error) {
+	r := prefix{}
+	if len(b) != prefixBytes {
+		return r, fmt.Errorf("expected %d bytes for prefix, got %d", prefixBytes, len(b))
+	}
+	copy(r[:], b)
+	return r, nil
+}
+
+// Prefixes returns the prefixes of all trees in a Merkle weave, in order.
+func Prefixes() [][]byte {
+	r := make([][]byte, numTrees)
+	for i := range r {
+		p := fromInt(i)
+		r[i] = p[:]
+	}
+	return r
+}
+
+// CrossTreePrefixes returns the prefixes of the trees an entry with data b is
+// appended to.
+func CrossTreePrefixes(b []byte) ([][]byte, error) {
+	if len(b) < minDataLen {
+		return nil, fmt.Errorf("at least %d bytes needed, got %d bytes", minDataLen, len(b))
+	}
+	ps := prefixesOf(b)
+	r := make([][]byte, len(ps))
+	for i := range ps {
+		r[i] = ps[i][:]
+	}
+	return r, nil
+}
+
 func prefixesOf(b []byte) [numCrossTrees]prefix {
 	var r [numCrossTrees]prefix
 	for i := 0; i < numCrossTrees; i++ {
@@ -88,24 +132,64 @@ func prefixesOf(b []byte) [numCrossTrees]prefix {
 }
 
 type tree struct {
-	m *sync.Mutex
-	t *merkletree.MerkleTree
+	m   *sync.Mutex
+	t   *merkletree.MerkleTree
+	ts  []time.Time   // timestamps of entries
+	seq []uint64      // sequence numbers of entries
+	sn  [][]byte      // storage node hashes of entries, if stored
+	r   *rfc6962.Tree // RFC 6962 tree of the same entries
+
+	sentinels []int // indexes of sentinel entries
 }
 
-type treeMap map[prefix]tree
+// last returns the timestamp of the last entry in the tree, or the zero time
+// if the tree is empty.
+func (t *tree) last() time.Time {
+	if len(t.ts) == 0 {
+		return time.Time{}
+	}
+	return t.ts[len(t.ts)-1]
+}
+
+// cut returns the number of entries in the tree with sequence numbers up to
+// seq.
+func (t *tree) cut(seq uint64) int {
+	return sort.Search(len(t.seq), func(i int) bool { return t.seq[i] > seq })
+}
+
+// entries returns the number of entries other than sentinels among the first
+// n entries of the tree.
+func (t *tree) entries(n int) int {
+	return n - sort.SearchInts(t.sentinels, n)
+}
+
+type treeMap map[prefix]*tree
 
 // MerkleWeave is a write-optimized Merkle tree-like data structure.
 type MerkleWeave struct {
-	ts treeMap
+	// seq is the sequence number of the last append, first for 64-bit
+	// alignment. Sequence numbers are assigned while holding the locks of
+	// all trees appended to, so that Summary can take a consistent cut of
+	// all trees without blocking appends to more than one tree at a time.
+	seq uint64
+
+	ts  treeMap
+	now func() time.Time
+	d   driver.Interface // nil if not stored
 }
 
 // New returns a new MerkleWeave.
+//
+// Trees of a MerkleWeave use merkletree.New, so their entries cannot be
+// redacted. Entries are already hashes of the notarized documents, so there is
+// no payload to take down.
 func New() *MerkleWeave {
-	ret := &MerkleWeave{ts: make(treeMap)}
+	ret := &MerkleWeave{ts: make(treeMap), now: time.Now}
 	for i := 0; i < numTrees; i++ {
-		t := tree{
+		t := &tree{
 			m: new(sync.Mutex),
 			t: merkletree.New(),
+			r: rfc6962.New(),
 		}
 		ret.ts[fromInt(i)] = t
 	}
@@ -113,24 +197,93 @@ func New() *MerkleWeave {
 }
 
 // forEach runs f on each tree in parallel.
-func (m *MerkleWeave) forEach(f func(i int, t *merkletree.MerkleTree)) {
+func (m *MerkleWeave) forEach(f func(i int, t *tree)) {
 	wg := sync.WaitGroup{}
 	wg.Add(len(m.ts))
 	for p, t := range m.ts {
-		go func(p prefix, t tree) {
+		go func(p prefix, t *tree) {
 			t.m.Lock()
 			defer t.m.Unlock()
-			f(toInt(p), t.t)
+			f(toInt(p), t)
 			wg.Done()
 		}(p, t)
 	}
 	wg.Wait()
 }
 
+// lockTree returns the locked tree with prefix p.
+func (m *MerkleWeave) lockTree(p []byte) (*tree, error) {
+	pr, err := fromBytes(p)
+	if err != nil {
+		return nil, err
+	}
+	t := m.ts[pr]
+	t.m.Lock()
+	return t, nil
+}
+
+// Position is the position of an entry in a tree of a Merkle weave.
+type Position struct {
+	Prefix []byte
+	Index  int
+}
+
+// Receipt records the timestamp and positions of an entry appended to a Merkle
+// weave.
+type Receipt struct {
+	Data      []byte
+	Timestamp time.Time
+	Positions []Position // one for each cross tree
+}
+
+// MarshalBinary returns the canonical encoding of r. The encoding starts with
+// the length of the data of r as a big-endian uint64 and the data, followed by
+// the timestamp of r as big-endian seconds (8 bytes) and nanoseconds (4 bytes)
+// since the Unix epoch. Then it contains the number of positions as a
+// big-endian uint64, and for each position the length of the prefix as a
+// big-endian uint64, the prefix and the index as a big-endian uint64.
+func (r *Receipt) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	var buf [12]byte
+	putUint64 := func(v uint64) {
+		binary.BigEndian.PutUint64(buf[:8], v)
+		b.Write(buf[:8])
+	}
+	putUint64(uint64(len(r.Data)))
+	b.Write(r.Data)
+	binary.BigEndian.PutUint64(buf[:8], uint64(r.Timestamp.Unix()))
+	binary.BigEndian.PutUint32(buf[8:], uint32(r.Timestamp.Nanosecond()))
+	b.Write(buf[:])
+	putUint64(uint64(len(r.Positions)))
+	for _, p := range r.Positions {
+		putUint64(uint64(len(p.Prefix)))
+		b.Write(p.Prefix)
+		putUint64(uint64(p.Index))
+	}
+	return b.Bytes(), nil
+}
+
+// Digest returns the SHAKE256 hash of the canonical encoding of r.
+func (r *Receipt) Digest() [merkletree.HashLength]byte {
+	b, _ := r.MarshalBinary()
+	var d [merkletree.HashLength]byte
+	sha3.ShakeSum256(d[:], b)
+	return d
+}
+
 // Append adds an entry to a MerkleWeave.
 func (m *MerkleWeave) Append(b []byte) {
+	if _, err := m.Notarize(b); err != nil {
+		panic(err)
+	}
+}
+
+// Notarize adds an entry to a MerkleWeave and returns a receipt for it.
+//
+// The entry is timestamped after the last entry of each of its cross trees.
+func (m *MerkleWeave) Notarize(b []byte) (*Receipt, error) {
 	if len(b) < minDataLen {
-		panic(fmt.Sprintf("at least %d bytes needed, got %d bytes", minDataLen, len(b)))
+		return nil, fmt.Errorf("at least %d bytes needed, got %d bytes", minDataLen, len(b))
 	}
 	ps := prefixesOf(b)
 
@@ -150,17 +303,152 @@ func (m *MerkleWeave) Append(b []byte) {
 		defer l.Unlock()
 	}
 
+	seq := atomic.AddUint64(&m.seq, 1)
+	ts := m.now().UTC()
+	for _, p := range sorted {
+		if last := m.ts[p].last(); !ts.After(last) {
+			ts = last.Add(time.Nanosecond)
+		}
+	}
+	nodes, err := m.store(ps[:], b, ts)
+	if err != nil {
+		return nil, err
+	}
+	r := &Receipt{Data: b, Timestamp: ts}
+	for i := 0; i < numCrossTrees; i++ {
+		p := ps[i]
+		t := m.ts[p]
+		r.Positions = append(r.Positions, Position{Prefix: p[:], Index: t.t.Len()})
+		t.appendEntry(b, ts, seq, nodes[i])
+	}
+	return r, nil
+}
+
+// ErrFutureTimestamp is returned when a tree cannot be advanced to a
+// timestamp because the timestamp has not yet passed.
+var ErrFutureTimestamp = errors.New("timestamp is not in the past")
+
+// sentinel returns the data of a sentinel entry for the tree with prefix p.
+//
+// All cross tree prefixes of a sentinel are p, and the rest of the sentinel is
+// zero.
+func sentinel(p prefix) []byte {
+	b := make([]byte, sentinelLen)
 	for i := 0; i < numCrossTrees; i++ {
-		t := m.ts[ps[i]].t
-		t.Append(b)
+		copy(b[i*prefixBytes:], p[:])
+	}
+	return b
+}
+
+// Sentinel returns the data of the sentinel entries Advance appends to the
+// tree with prefix p.
+func Sentinel(p []byte) ([]byte, error) {
+	pr, err := fromBytes(p)
+	if err != nil {
+		return nil, err
+	}
+	return sentinel(pr), nil
+}
+
+// Advance ensures the last entry of the tree with prefix p has a timestamp
+// after ts, appending a sentinel entry to the tree if needed. It returns true
+// if a sentinel was appended.
+func (m *MerkleWeave) Advance(p []byte, ts time.Time) (bool, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return false, err
+	}
+	defer t.m.Unlock()
+	last := t.last()
+	if last.After(ts) {
+		return false, nil
+	}
+	now := m.now().UTC()
+	if !now.After(ts) {
+		return false, ErrFutureTimestamp
+	}
+	if !now.After(last) {
+		now = last.Add(time.Nanosecond)
+	}
+	pr, _ := fromBytes(p)
+	nodes, err := m.store([]prefix{pr}, sentinel(pr), now)
+	if err != nil {
+		return false, err
+	}
+	t.appendEntry(sentinel(pr), now, atomic.AddUint64(&m.seq, 1), nodes[0])
+	t.sentinels = append(t.sentinels, t.t.Len()-1)
+	return true, nil
+}
+
+// Entry returns the data and timestamp of the entry at index in the tree with
+// prefix p.
+func (m *MerkleWeave) Entry(p []byte, index int) ([]byte, time.Time, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer t.m.Unlock()
+	if index < 0 || index >= t.t.Len() {
+		return nil, time.Time{}, fmt.Errorf("index %d out of range for tree %x of length %d", index, p, t.t.Len())
+	}
+	return t.t.At(index), t.ts[index], nil
+}
+
+// Peaks returns the hashes of the peaks of the tree with prefix p when it had n
+// entries.
+func (m *MerkleWeave) Peaks(p []byte, n int) ([][merkletree.HashLength]byte, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return nil, err
+	}
+	defer t.m.Unlock()
+	if n < 0 || n > t.t.Len() {
+		return nil, fmt.Errorf("size %d out of range for tree %x of length %d", n, p, t.t.Len())
+	}
+	return t.t.Peaks(n), nil
+}
+
+// ProveInclusion returns a proof that the entry at index in the tree with
+// prefix p is included in that tree when it had n entries.
+func (m *MerkleWeave) ProveInclusion(p []byte, index, n int) (*merkletree.InclusionProof, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return nil, err
+	}
+	defer t.m.Unlock()
+	return t.t.ProveInclusion(index, n)
+}
+
+// ProveRange returns a proof that the entries from begin up to end in the tree
+// with prefix p are included in that tree when it had n entries.
+func (m *MerkleWeave) ProveRange(p []byte, begin, end, n int) (*merkletree.RangeProof, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return nil, err
 	}
+	defer t.m.Unlock()
+	return t.t.ProveRange(begin, end, n)
+}
+
+// ProveConsistency returns a proof that the tree with prefix p when it had
+// from entries is a prefix of that tree when it had to entries.
+func (m *MerkleWeave) ProveConsistency(p []byte, from, to int) (*merkletree.ConsistencyProof, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return nil, err
+	}
+	defer t.m.Unlock()
+	return t.t.ProveConsistency(from, to)
 }
 
 // ApproxLen returns an approximate number of entries in the Merkle weave. The Merkle weave can contain spurious entries
+//
+// Deprecated: ApproxLen counts each entry once for each of its cross trees and
+// counts sentinels. Use Len.
 func (m *MerkleWeave) ApproxLen() int {
 	lens := [numTrees]int{}
-	m.forEach(func(i int, t *merkletree.MerkleTree) {
-		lens[i] = t.Len()
+	m.forEach(func(i int, t *tree) {
+		lens[i] = t.t.Len()
 	})
 	l := 0
 	for _, i := range lens {
@@ -169,13 +457,53 @@ func (m *MerkleWeave) ApproxLen() int {
 	return l
 }
 
+// Len returns the number of entries notarized in the Merkle weave, excluding
+// sentinels, as of a consistent cut of its trees (see Summary).
+func (m *MerkleWeave) Len() int {
+	s := m.Summary()
+	return s.Len()
+}
+
+// sumEntries returns the number of notarized entries given the number of
+// entries other than sentinels in each tree. Each notarized entry is appended
+// once for each of its cross trees, even if they are the same tree.
+func sumEntries(entries [numTrees]int) int {
+	n := 0
+	for _, e := range entries {
+		n += e
+	}
+	return n / numCrossTrees
+}
+
 // Summary is a summary of a Merkle weave.
 type Summary struct {
-	ss [numTrees]merkletree.Summary
+	n    int // number of notarized entries
+	ss   [numTrees]merkletree.Summary
+	last [numTrees]time.Time
+}
+
+// Len returns the number of entries notarized in the Merkle weave when s was
+// taken, excluding sentinels.
+func (s *Summary) Len() int {
+	return s.n
+}
+
+// Tree returns the summary of the tree with prefix p and the timestamp of its
+// last entry. The timestamp is zero if the tree is empty.
+func (s *Summary) Tree(p []byte) (merkletree.Summary, time.Time, error) {
+	pr, err := fromBytes(p)
+	if err != nil {
+		return merkletree.Summary{}, time.Time{}, err
+	}
+	i := toInt(pr)
+	return s.ss[i], s.last[i], nil
 }
 
 // Equals returns true if the Summary's are equal.
 func (s *Summary) Equals(s2 *Summary) bool {
+	if s.n != s2.n {
+		return false
+	}
 	for i, t := range s.ss {
 		if !t.Equals(s2.ss[i]) {
 			return false
@@ -201,12 +529,26 @@ func (s *Summary) ShortString() string {
 }
 
 // Summary returns a summary of the Merkle weave.
+//
+// The summary is a consistent cut of the Merkle weave: each entry is included
+// in either all or none of its cross trees. Entries appended concurrently may
+// be excluded.
 func (m *MerkleWeave) Summary() Summary {
 	r := Summary{}
-	m.forEach(func(i int, t *merkletree.MerkleTree) {
-		r.ss[i] = t.Summary()
-		return
+	entries := [numTrees]int{}
+	cut := atomic.LoadUint64(&m.seq)
+	m.forEach(func(i int, t *tree) {
+		// Appends with sequence numbers up to cut hold the locks of their
+		// trees until they complete, so they are complete by the time each
+		// of their trees is locked here.
+		n := t.cut(cut)
+		r.ss[i] = t.t.SummaryAt(n)
+		if n > 0 {
+			r.last[i] = t.ts[n-1]
+		}
+		entries[i] = t.entries(n)
 	})
+	r.n = sumEntries(entries)
 	return r
 }
 
@@ -218,6 +560,3 @@ func newEmptySummary() Summary {
 	}
 	return s
 }
-
-// TODO: ProveEntry
-// TODO: ProveSummary
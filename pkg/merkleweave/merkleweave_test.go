@@ -1,12 +1,16 @@
 package merkleweave
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
 	"sync"
 	"testing"
+	"time"
 
-	"github.com/vsekhar/merkleweave/internal/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/rfc6962"
 )
 
 func fromString(s string) (r [merkletree.HashLength]byte) {
@@ -29,11 +33,12 @@ func TestSummary(t *testing.T) {
 	p2 := toInt(prefixes[1])
 	ts := merkletree.Summary{
 		N:       1,
-		Summary: fromString("HNYSpDRgx5Ujm7dHjpjiCu2oLrmq-htl0U4ByLazz10FhYeo9DZE1tANR9CgFdnl12TXikoQxyBmF-PS3woP0A"),
+		Summary: fromString("Z1k4QSqUifEWLZnQlDfKer6TDUI5Gj76dUY50lVh4j6IcZHqjBamd8n9VnSZzZSxhubMt-HEuX2nCd0XoKyBmA"),
 	}
 	t.Log(base64.RawURLEncoding.EncodeToString(ts.Summary[:]))
 	good.ss[p1] = ts
 	good.ss[p2] = ts
+	good.n = 1
 
 	if !s.Equals(&good) {
 		t.Errorf("expected %s, got %s", good.ShortString(), s.ShortString())
@@ -44,6 +49,125 @@ func TestDuplicatePrefixes(t *testing.T) {
 	m := New()
 	b1 := []byte{1, 2, 1, 2}
 	m.Append(b1)
+	if m.Len() != 1 {
+		t.Errorf("expected 1 entry, got %d", m.Len())
+	}
+	if s := m.Summary(); s.Len() != 1 {
+		t.Errorf("expected summary of 1 entry, got %d", s.Len())
+	}
+}
+
+func TestNotarize(t *testing.T) {
+	m := New()
+	now := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	b1 := []byte{1, 2, 3, 4}
+	r1, err := m.Notarize(b1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r1.Timestamp.Equal(now) {
+		t.Errorf("expected timestamp %s, got %s", now, r1.Timestamp)
+	}
+	if len(r1.Positions) != numCrossTrees {
+		t.Fatalf("expected %d positions, got %d", numCrossTrees, len(r1.Positions))
+	}
+
+	// Same clock reading, so timestamps must still advance.
+	r2, err := m.Notarize([]byte{1, 5, 6, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r2.Timestamp.After(r1.Timestamp) {
+		t.Errorf("expected %s after %s", r2.Timestamp, r1.Timestamp)
+	}
+	if r2.Positions[0].Index != 1 {
+		t.Errorf("expected index 1, got %d", r2.Positions[0].Index)
+	}
+
+	s := m.Summary()
+	for _, p := range r1.Positions {
+		data, ts, err := m.Entry(p.Prefix, p.Index)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, b1) || !ts.Equal(r1.Timestamp) {
+			t.Errorf("unexpected entry %x at %s", data, ts)
+		}
+		ts1, _, err := s.Tree(p.Prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		proof, err := m.ProveInclusion(p.Prefix, p.Index, ts1.N)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := merkletree.VerifyInclusion(ts1, b1, proof); err != nil {
+			t.Error(err)
+		}
+	}
+
+	ts1, _, err := s.Tree([]byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data [][]byte
+	for i := 0; i < ts1.N; i++ {
+		d, _, err := m.Entry([]byte{1}, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, d)
+	}
+	rp, err := m.ProveRange([]byte{1}, 0, ts1.N, ts1.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merkletree.VerifyRange(ts1, data, rp); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := m.Notarize([]byte{1}); err == nil {
+		t.Error("expected error notarizing short data")
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	m := New()
+	now := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	p := []byte{7}
+
+	if _, err := m.Advance(p, now); !errors.Is(err, ErrFutureTimestamp) {
+		t.Errorf("expected ErrFutureTimestamp, got %v", err)
+	}
+	ok, err := m.Advance(p, now.Add(-time.Second))
+	if err != nil || !ok {
+		t.Fatalf("expected sentinel, got %v, %v", ok, err)
+	}
+	ok, err = m.Advance(p, now.Add(-time.Second))
+	if err != nil || ok {
+		t.Fatalf("expected no sentinel, got %v, %v", ok, err)
+	}
+	s := m.Summary()
+	ts, last, err := s.Tree(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts.N != 1 || !last.Equal(now) {
+		t.Errorf("unexpected tree summary %s at %s", ts, last)
+	}
+	if s.Len() != 0 || m.Len() != 0 {
+		t.Errorf("expected sentinels not to be counted, got %d", s.Len())
+	}
+	data, _, err := m.Entry(p, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := prefixesOf(data); ps[0] != fromHex("07") || ps[1] != fromHex("07") {
+		t.Errorf("unexpected sentinel %x", data)
+	}
 }
 
 const records = 1 << 10 // 1024
@@ -96,3 +220,157 @@ func BenchmarkCompare(b *testing.B) {
 		benchmarkAppend(b, w.Append, d)
 	})
 }
+
+func TestConsistentCut(t *testing.T) {
+	m := New()
+	const writers, entries = 8, 500
+	var mu sync.Mutex
+	var receipts []*Receipt
+	var sums []Summary
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			s := m.Summary()
+			mu.Lock()
+			sums = append(sums, s)
+			mu.Unlock()
+		}
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < entries; i++ {
+				b := make([]byte, 4)
+				if _, err := rand.Read(b); err != nil {
+					t.Error(err)
+					return
+				}
+				// Concentrate on a few trees to increase contention.
+				b[0], b[1] = b[0]%4, b[1]%4
+				r, err := m.Notarize(b)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				receipts = append(receipts, r)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	<-done
+
+	for _, s := range sums {
+		for _, r := range receipts {
+			var in [numCrossTrees]bool
+			for i, p := range r.Positions {
+				ts, _, err := s.Tree(p.Prefix)
+				if err != nil {
+					t.Fatal(err)
+				}
+				in[i] = p.Index < ts.N
+			}
+			if in[0] != in[1] {
+				t.Fatalf("entry %x at %v is half included in summary %s", r.Data, r.Positions, s.ShortString())
+			}
+		}
+	}
+}
+
+func TestRFC6962(t *testing.T) {
+	m := New()
+	p := []byte{3}
+	var data [][]byte
+	var old *RFC6962TreeHead
+	for i := 0; i < 10; i++ {
+		if i == 4 {
+			var err error
+			if old, err = m.RFC6962TreeHead(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b := []byte{3, byte(10 + i), 5, 6}
+		m.Append(b)
+		data = append(data, b)
+	}
+	h, err := m.RFC6962TreeHead(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Size != 10 || !bytes.Equal(h.Prefix, p) {
+		t.Fatalf("unexpected tree head %+v", h)
+	}
+	r := rfc6962.New()
+	for _, b := range data {
+		r.Append(b)
+	}
+	if root, _ := r.Root(10); root != h.Root {
+		t.Errorf("expected root %x, got %x", root, h.Root)
+	}
+	for i, b := range data {
+		proof, err := m.RFC6962InclusionProof(p, i, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rfc6962.VerifyInclusion(i, 10, rfc6962.LeafHash(b), h.Root, proof); err != nil {
+			t.Errorf("entry %d: %v", i, err)
+		}
+	}
+	proof, err := m.RFC6962ConsistencyProof(p, old.Size, h.Size)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rfc6962.VerifyConsistency(old.Size, h.Size, old.Root, h.Root, proof); err != nil {
+		t.Error(err)
+	}
+	if _, err := m.RFC6962InclusionProof(p, 0, 11); err == nil {
+		t.Error("expected error for size beyond tree")
+	}
+}
+
+func TestMultiProof(t *testing.T) {
+	m := New()
+	var rs []*Receipt
+	for i := 0; i < 50; i++ {
+		// Entries share trees, and some are in the same tree twice.
+		r, err := m.Notarize([]byte{byte(i % 5), byte(i % 3), byte(i), 0xff})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs = append(rs, r)
+	}
+	s := m.Summary()
+	m.Append([]byte{1, 2, 3, 4})
+
+	p, err := m.ProveMulti(&s, rs[10:40])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyMulti(&s, rs[10:40], p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Trees) != 5 {
+		t.Errorf("expected proofs for 5 trees, got %d", len(p.Trees))
+	}
+	if err := VerifyMulti(&s, rs[11:40], p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("VerifyMulti of fewer receipts: %v", err)
+	}
+	bad := *rs[20]
+	bad.Data = append([]byte(nil), bad.Data...)
+	bad.Data[3] = 0
+	if err := VerifyMulti(&s, append(append([]*Receipt(nil), rs[10:20]...), append([]*Receipt{&bad}, rs[21:40]...)...), p); !errors.Is(err, merkletree.ErrInvalidProof) {
+		t.Errorf("VerifyMulti of bad receipt: %v", err)
+	}
+
+	last, err := m.Notarize([]byte{9, 9, 9, 9})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ProveMulti(&s, []*Receipt{last}); err == nil {
+		t.Error("expected error proving entry after summary")
+	}
+}
@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+)
+
+// History stores the summaries verified for each endpoint.
+type History interface {
+	// Latest returns the last summary appended for endpoint, or nil if there
+	// is none.
+	Latest(endpoint string) (*client.Summary, error)
+
+	// Append appends a summary of endpoint verified at time at.
+	Append(endpoint string, s *client.Summary, at time.Time) error
+}
+
+// Record is a verified summary in a history.
+type Record struct {
+	Time    time.Time       `json:"time"`
+	Summary *client.Summary `json:"summary"`
+}
+
+// FileHistory stores the summaries of each endpoint in a file in a directory,
+// one JSON-encoded Record per line.
+type FileHistory struct {
+	dir string
+	mu  sync.Mutex
+}
+
+var _ History = (*FileHistory)(nil)
+
+// NewFileHistory returns a FileHistory in dir, creating it if necessary.
+func NewFileHistory(dir string) (*FileHistory, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileHistory{dir: dir}, nil
+}
+
+func (h *FileHistory) path(endpoint string) string {
+	return filepath.Join(h.dir, url.PathEscape(endpoint)+".jsonl")
+}
+
+// Records returns all records of endpoint, oldest first.
+func (h *FileHistory) Records(endpoint string) ([]Record, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.Open(h.path(endpoint))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rs []Record
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// Ignore a partial record left by an interrupted write.
+			return rs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var rec Record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return nil, err
+		}
+		rs = append(rs, rec)
+	}
+}
+
+// Latest returns the last summary appended for endpoint, or nil if there is
+// none.
+func (h *FileHistory) Latest(endpoint string) (*client.Summary, error) {
+	rs, err := h.Records(endpoint)
+	if err != nil || len(rs) == 0 {
+		return nil, err
+	}
+	return rs[len(rs)-1].Summary, nil
+}
+
+// Append appends a summary of endpoint verified at time at and syncs the
+// file.
+func (h *FileHistory) Append(endpoint string, s *client.Summary, at time.Time) error {
+	b, err := json.Marshal(Record{Time: at, Summary: s})
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	f, err := os.OpenFile(h.path(endpoint), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
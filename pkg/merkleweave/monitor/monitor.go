@@ -0,0 +1,316 @@
+// Package monitor follows Fabula endpoints and raises alerts when they
+// misbehave.
+//
+// A Monitor polls the summary of each endpoint, verifies that it is consistent
+// with the last summary verified for that endpoint, and appends it to a
+// History. Summaries of different endpoints serving the same Merkle weave are
+// also checked to be consistent with each other, so that an operator showing
+// different forks to different endpoints (equivocating) is detected. Where
+// possible, alerts carry a fraud proof of the misbehavior. Fraud proofs are
+// built only from signed summaries, so operators must sign summaries. With
+// WithKeyRing, summaries not signed by a trusted key raise alerts.
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/fraud"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/fraudpb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+)
+
+// Kind is the kind of an alert.
+type Kind int
+
+const (
+	// Inconsistent is raised when a summary is inconsistent with the last
+	// summary verified for the same endpoint.
+	Inconsistent Kind = iota
+
+	// Shrank is raised when a tree is smaller than in the last summary
+	// verified for the same endpoint.
+	Shrank
+
+	// TimestampRegressed is raised when the last timestamp of a tree is
+	// before that in the last summary verified for the same endpoint.
+	TimestampRegressed
+
+	// Malformed is raised when an endpoint returns a malformed response.
+	Malformed
+
+	// Equivocation is raised when the summaries of two endpoints are
+	// inconsistent with each other.
+	Equivocation
+
+	// BadSignature is raised when a summary is not signed by a key in the
+	// key ring of the Monitor.
+	BadSignature
+)
+
+var kindNames = [...]string{"inconsistent", "shrank", "timestamp regressed", "malformed", "equivocation", "bad signature"}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// Alert describes misbehavior seen by a Monitor.
+type Alert struct {
+	Kind     Kind
+	Time     time.Time
+	Endpoint string
+	Other    string // the other endpoint, for Equivocation
+	Prefix   []byte // the tree concerned, if any
+	Err      error
+
+	// FraudProof is evidence of the misbehavior for third parties, if it
+	// could be obtained. It requires the summaries concerned to be signed.
+	FraudProof *fraudpb.FraudProof
+}
+
+func (a Alert) String() string {
+	if a.Other != "" {
+		return fmt.Sprintf("%s: %s and %s: %v", a.Kind, a.Endpoint, a.Other, a.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", a.Kind, a.Endpoint, a.Err)
+}
+
+// Notifier is notified of alerts.
+type Notifier interface {
+	Notify(a Alert)
+}
+
+// NotifierFunc is a function that implements Notifier.
+type NotifierFunc func(a Alert)
+
+// Notify calls f(a).
+func (f NotifierFunc) Notify(a Alert) { f(a) }
+
+// LogNotifier returns a Notifier that logs alerts to l.
+func LogNotifier(l *log.Logger) Notifier {
+	return NotifierFunc(func(a Alert) { l.Printf("ALERT %s", a) })
+}
+
+// Endpoint is a Fabula endpoint to follow.
+type Endpoint struct {
+	Name   string
+	Client servicepb.FabulaClient
+}
+
+type endpoint struct {
+	name   string
+	fc     servicepb.FabulaClient
+	c      *client.Client
+	latest *client.Summary
+}
+
+// pairTree identifies a tree of a pair of endpoints.
+type pairTree struct {
+	i, j int
+	p    string
+}
+
+// Monitor follows a set of endpoints.
+type Monitor struct {
+	es   []*endpoint
+	h    History
+	n    Notifier
+	now  func() time.Time
+	keys signing.KeyRing
+
+	// checked records the sizes at which the trees of pairs of endpoints
+	// were last checked to be consistent.
+	checked map[pairTree][2]int
+}
+
+// Option configures a Monitor.
+type Option func(*Monitor)
+
+// WithKeyRing returns an Option that requires summaries to be signed by a key
+// in k.
+func WithKeyRing(k signing.KeyRing) Option {
+	return func(m *Monitor) { m.keys = k }
+}
+
+// New returns a Monitor following endpoints, resuming from the latest
+// summaries of each endpoint in h.
+func New(endpoints []Endpoint, h History, n Notifier, opts ...Option) (*Monitor, error) {
+	m := &Monitor{h: h, n: n, now: time.Now, checked: make(map[pairTree][2]int)}
+	for _, o := range opts {
+		o(m)
+	}
+	var copts []client.Option
+	if m.keys != nil {
+		copts = append(copts, client.WithKeyRing(m.keys))
+	}
+	for _, e := range endpoints {
+		s, err := h.Latest(e.Name)
+		if err != nil {
+			return nil, err
+		}
+		c := client.New(e.Client, copts...)
+		if s != nil {
+			c = client.NewWithSummary(e.Client, s, copts...)
+		}
+		m.es = append(m.es, &endpoint{name: e.Name, fc: e.Client, c: c, latest: s})
+	}
+	return m, nil
+}
+
+// alert notifies m's Notifier of misbehavior of e described by err.
+func (m *Monitor) alert(ctx context.Context, e *endpoint, err error) {
+	a := Alert{Time: m.now(), Endpoint: e.name, Err: err}
+	var ie *client.InconsistencyError
+	var te *client.TimestampError
+	var se *client.SignatureError
+	switch {
+	case errors.As(err, &ie):
+		a.Prefix = ie.Prefix
+		a.Kind = Inconsistent
+		if ie.New.Summary.N < ie.Old.Summary.N {
+			a.Kind = Shrank
+		}
+		a.FraudProof, _ = fraud.ProveEquivocation(ctx, e.fc, ie.Prefix, ie.OldSummary, ie.NewSummary)
+	case errors.As(err, &te):
+		a.Prefix = te.Prefix
+		a.Kind = TimestampRegressed
+	case errors.As(err, &se):
+		a.Kind = BadSignature
+	default:
+		a.Kind = Malformed
+	}
+	m.n.Notify(a)
+}
+
+func equal(s1, s2 *client.Summary) bool {
+	if s1 == nil || s2 == nil || len(s1.Trees) != len(s2.Trees) {
+		return false
+	}
+	for i := range s1.Trees {
+		t1, t2 := s1.Trees[i], s2.Trees[i]
+		if !t1.Summary.Equals(t2.Summary) || !t1.Last.Equal(t2.Last) {
+			return false
+		}
+	}
+	return true
+}
+
+// Poll polls each endpoint once, raising alerts for any misbehavior, and
+// appends new verified summaries to the history. It returns the first error
+// not caused by misbehavior, e.g. an endpoint being unreachable, after
+// polling all endpoints.
+//
+// After an alert, the last verified summary of an endpoint is retained, so
+// the alert is raised again on each poll until the endpoint is consistent
+// with it.
+func (m *Monitor) Poll(ctx context.Context) error {
+	var first error
+	setErr := func(err error) {
+		if first == nil {
+			first = err
+		}
+	}
+	for _, e := range m.es {
+		s, err := e.c.Summary(ctx, time.Time{})
+		if errors.Is(err, client.ErrMisbehavior) {
+			m.alert(ctx, e, err)
+			continue
+		}
+		if err != nil {
+			setErr(fmt.Errorf("%s: %v", e.name, err))
+			continue
+		}
+		if !equal(s, e.latest) {
+			if err := m.h.Append(e.name, s, m.now()); err != nil {
+				return err
+			}
+		}
+		e.latest = s
+	}
+	if err := m.crossCheck(ctx); err != nil {
+		setErr(err)
+	}
+	return first
+}
+
+// crossCheck checks that the latest summaries of each pair of endpoints are
+// consistent.
+func (m *Monitor) crossCheck(ctx context.Context) error {
+	for i, e1 := range m.es {
+		for j := i + 1; j < len(m.es); j++ {
+			e2 := m.es[j]
+			if e1.latest == nil || e2.latest == nil {
+				continue
+			}
+			for k, t1 := range e1.latest.Trees {
+				t2 := e2.latest.Trees[k]
+				key := pairTree{i, j, string(t1.Prefix)}
+				sizes := [2]int{t1.Summary.N, t2.Summary.N}
+				if c, ok := m.checked[key]; ok && c == sizes {
+					continue
+				}
+				// The endpoint with the larger tree proves the smaller tree is
+				// a prefix of it.
+				small, large, prover := t1, t2, e2
+				if t1.Summary.N > t2.Summary.N {
+					small, large, prover = t2, t1, e1
+				}
+				err := prover.c.VerifyConsistency(ctx, small, large)
+				if errors.Is(err, client.ErrMisbehavior) {
+					fp, _ := fraud.ProveEquivocation(ctx, prover.fc, t1.Prefix, e1.latest, e2.latest)
+					m.n.Notify(Alert{
+						Kind:       Equivocation,
+						Time:       m.now(),
+						Endpoint:   e1.name,
+						Other:      e2.name,
+						Prefix:     append([]byte(nil), t1.Prefix...),
+						Err:        err,
+						FraudProof: fp,
+					})
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("%s: %v", prover.name, err)
+				}
+				m.checked[key] = sizes
+			}
+		}
+	}
+	return nil
+}
+
+// Run polls the endpoints every interval until ctx is done, logging errors
+// not caused by misbehavior to l.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration, l *log.Logger) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		if err := m.Poll(ctx); err != nil && ctx.Err() == nil {
+			l.Print(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Latest returns the latest verified summary of the endpoint named name, or
+// nil if there is none.
+func (m *Monitor) Latest(name string) *client.Summary {
+	for _, e := range m.es {
+		if e.name == name {
+			return e.latest
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package monitor_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/fraud"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/monitor"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dial serves w over an in-memory connection and returns a client for it.
+func dial(t *testing.T, w *merkleweave.MerkleWeave, opts ...server.Option) servicepb.FabulaClient {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	servicepb.RegisterFabulaService(s, server.New(w, opts...).Service())
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return servicepb.NewFabulaClient(conn)
+}
+
+// switcher forwards requests to a FabulaClient that can be switched.
+type switcher struct {
+	servicepb.FabulaClient
+}
+
+// backdater moves the last timestamps of all trees back by an hour.
+type backdater struct {
+	servicepb.FabulaClient
+}
+
+func (b backdater) WeaveSummary(ctx context.Context, req *servicepb.WeaveSummaryRequest, opts ...grpc.CallOption) (*servicepb.WeaveSummaryResponse, error) {
+	resp, err := b.FabulaClient.WeaveSummary(ctx, req, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range resp.Trees {
+		if s := t.GetSummary(); s.GetLast() != nil {
+			s.Last = timestamppb.New(s.Last.AsTime().Add(-time.Hour))
+		}
+	}
+	return resp, nil
+}
+
+type alerts []monitor.Alert
+
+func (as *alerts) Notify(a monitor.Alert) { *as = append(*as, a) }
+
+func (as *alerts) take() []monitor.Kind {
+	var ks []monitor.Kind
+	for _, a := range *as {
+		ks = append(ks, a.Kind)
+	}
+	*as = nil
+	return ks
+}
+
+func newHistory(t *testing.T) *monitor.FileHistory {
+	dir, err := ioutil.TempDir("", "monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	h, err := monitor.NewFileHistory(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// newOperator returns a server option signing with a new key and a key ring
+// trusting it.
+func newOperator(t *testing.T) (server.Option, signing.KeyRing) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server.WithSigner(signing.NewSigner(key)), signing.NewKeyRing(pub)
+}
+
+// expectFraudProofs checks that each alert in as carries a fraud proof
+// verified by k.
+func expectFraudProofs(t *testing.T, as *alerts, k signing.KeyRing) {
+	t.Helper()
+	for _, a := range *as {
+		if err := fraud.VerifyFraudProof(k, a.FraudProof); err != nil {
+			t.Errorf("%s: tree %x: %v", a.Kind, a.Prefix, err)
+		}
+	}
+}
+
+func expectKinds(t *testing.T, as *alerts, want ...monitor.Kind) {
+	t.Helper()
+	got := as.take()
+	if len(got) != len(want) {
+		t.Fatalf("expected alerts %v, got %v", want, got)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("expected alerts %v, got %v", want, got)
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	ctx := context.Background()
+	op, k := newOperator(t)
+	w := merkleweave.New()
+	w.Append([]byte{1, 2, 3, 4})
+	a, b := &switcher{dial(t, w, op)}, &switcher{dial(t, w, op)}
+	h := newHistory(t)
+	as := &alerts{}
+	m, err := monitor.New([]monitor.Endpoint{{Name: "a", Client: a}, {Name: "b", Client: b}}, h, as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	w.Append([]byte{1, 2, 3, 5})
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectKinds(t, as)
+	if rs, err := h.Records("a"); err != nil || len(rs) != 2 {
+		t.Fatalf("expected 2 records, got %d, %v", len(rs), err)
+	}
+
+	// Endpoint a forks.
+	fork := merkleweave.New()
+	fork.Append([]byte{1, 2, 3, 4})
+	fork.Append([]byte{1, 2, 3, 6})
+	fork.Append([]byte{1, 2, 3, 7})
+	a.FabulaClient = dial(t, fork, op)
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectFraudProofs(t, as, k)
+	expectKinds(t, as, monitor.Inconsistent)
+
+	// A restarted monitor resumes from history.
+	m, err = monitor.New([]monitor.Endpoint{{Name: "a", Client: a}}, h, as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectKinds(t, as, monitor.Inconsistent)
+
+	// Endpoint a shrinks.
+	a.FabulaClient = dial(t, merkleweave.New())
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectKinds(t, as, monitor.Shrank)
+
+	// Endpoint a moves timestamps back.
+	a.FabulaClient = backdater{dial(t, w)}
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectKinds(t, as, monitor.TimestampRegressed)
+}
+
+func TestEquivocation(t *testing.T) {
+	ctx := context.Background()
+	w1, w2 := merkleweave.New(), merkleweave.New()
+	w1.Append([]byte{1, 2, 3, 4})
+	w2.Append([]byte{1, 2, 3, 5})
+	w2.Append([]byte{1, 2, 3, 6})
+	op, k := newOperator(t)
+	as := &alerts{}
+	m, err := monitor.New([]monitor.Endpoint{
+		{Name: "a", Client: dial(t, w1, op)},
+		{Name: "b", Client: dial(t, w2, op)},
+	}, newHistory(t), as)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Trees 01 and 02 differ.
+	expectFraudProofs(t, as, k)
+	expectKinds(t, as, monitor.Equivocation, monitor.Equivocation)
+}
+
+func TestKeyRing(t *testing.T) {
+	ctx := context.Background()
+	w := merkleweave.New()
+	w.Append([]byte{1, 2, 3, 4})
+	op, k := newOperator(t)
+	other, _ := newOperator(t)
+	as := &alerts{}
+	m, err := monitor.New([]monitor.Endpoint{
+		{Name: "a", Client: dial(t, w, op)},
+		{Name: "b", Client: dial(t, w, other)},
+		{Name: "c", Client: dial(t, w)},
+	}, newHistory(t), as, monitor.WithKeyRing(k))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	expectKinds(t, as, monitor.BadSignature, monitor.BadSignature)
+}
@@ -0,0 +1,130 @@
+package merkleweave
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+)
+
+// MultiProof is a proof that the entries of a set of receipts are included in
+// a Merkle weave with a given summary.
+//
+// It has one merkletree.MultiProof for each tree with any of the entries, so
+// that nodes and peaks shared by entries of a tree are included once. The data
+// of each entry is provided by its receipt, once for all of its cross trees.
+type MultiProof struct {
+	Trees []TreeMultiProof // in prefix order
+}
+
+// TreeMultiProof is a proof of the entries of a MultiProof in one tree.
+type TreeMultiProof struct {
+	Prefix []byte
+	Proof  *merkletree.MultiProof
+}
+
+// entriesOf returns the data of the entries of rs at each index of each tree,
+// by the position of the tree among the prefixes of a Merkle weave.
+func entriesOf(rs []*Receipt) (map[int]map[int][]byte, error) {
+	r := make(map[int]map[int][]byte)
+	for _, rc := range rs {
+		if len(rc.Data) < minDataLen || len(rc.Positions) != numCrossTrees {
+			return nil, fmt.Errorf("malformed receipt for %x", rc.Data)
+		}
+		for i, p := range prefixesOf(rc.Data) {
+			pos := rc.Positions[i]
+			if !bytes.Equal(pos.Prefix, p[:]) {
+				return nil, fmt.Errorf("receipt for %x: expected prefix %x, got %x", rc.Data, p[:], pos.Prefix)
+			}
+			t := toInt(p)
+			if r[t] == nil {
+				r[t] = make(map[int][]byte)
+			}
+			if d, ok := r[t][pos.Index]; ok && !bytes.Equal(d, rc.Data) {
+				return nil, fmt.Errorf("receipts for %x and %x at the same position", d, rc.Data)
+			}
+			r[t][pos.Index] = rc.Data
+		}
+	}
+	return r, nil
+}
+
+// sortedIndexes returns the indexes of es in order.
+func sortedIndexes(es map[int][]byte) []int {
+	r := make([]int, 0, len(es))
+	for i := range es {
+		r = append(r, i)
+	}
+	sort.Ints(r)
+	return r
+}
+
+// ProveMulti returns a proof that the entries of rs are included in the Merkle
+// weave when it had summary s.
+func (m *MerkleWeave) ProveMulti(s *Summary, rs []*Receipt) (*MultiProof, error) {
+	entries, err := entriesOf(rs)
+	if err != nil {
+		return nil, err
+	}
+	r := &MultiProof{}
+	for i := 0; i < numTrees; i++ {
+		if entries[i] == nil {
+			continue
+		}
+		p := fromInt(i)
+		t, err := m.lockTree(p[:])
+		if err != nil {
+			return nil, err
+		}
+		proof, err := t.t.ProveMulti(sortedIndexes(entries[i]), s.ss[i].N)
+		t.m.Unlock()
+		if err != nil {
+			return nil, fmt.Errorf("prefix %x: %w", p[:], err)
+		}
+		r.Trees = append(r.Trees, TreeMultiProof{Prefix: p[:], Proof: proof})
+	}
+	return r, nil
+}
+
+// VerifyMulti verifies that p proves that the entries of rs are included in
+// the Merkle weave summarized by s.
+func VerifyMulti(s *Summary, rs []*Receipt, p *MultiProof) error {
+	entries, err := entriesOf(rs)
+	if err != nil {
+		return err
+	}
+	if len(p.Trees) != len(entries) {
+		return fmt.Errorf("%w: expected proofs for %d trees, got %d", merkletree.ErrInvalidProof, len(entries), len(p.Trees))
+	}
+	for _, tp := range p.Trees {
+		pr, err := fromBytes(tp.Prefix)
+		if err != nil {
+			return fmt.Errorf("%w: %v", merkletree.ErrInvalidProof, err)
+		}
+		i := toInt(pr)
+		es := entries[i]
+		if es == nil {
+			return fmt.Errorf("%w: unexpected proof for prefix %x", merkletree.ErrInvalidProof, tp.Prefix)
+		}
+		delete(entries, i)
+		if tp.Proof == nil {
+			return fmt.Errorf("%w: prefix %x: missing proof", merkletree.ErrInvalidProof, tp.Prefix)
+		}
+		indexes := sortedIndexes(es)
+		if len(indexes) != len(tp.Proof.Positions) {
+			return fmt.Errorf("%w: prefix %x: expected proof of %d entries, got %d", merkletree.ErrInvalidProof, tp.Prefix, len(indexes), len(tp.Proof.Positions))
+		}
+		data := make([][]byte, len(indexes))
+		for j, index := range indexes {
+			if tp.Proof.Positions[j] != index {
+				return fmt.Errorf("%w: prefix %x: expected proof of entry %d, got %d", merkletree.ErrInvalidProof, tp.Prefix, index, tp.Proof.Positions[j])
+			}
+			data[j] = es[index]
+		}
+		if err := merkletree.VerifyMulti(s.ss[i], data, tp.Proof); err != nil {
+			return fmt.Errorf("prefix %x: %w", tp.Prefix, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package merkleweave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/rfc6962"
+	"golang.org/x/crypto/sha3"
+)
+
+// RFC6962TreeHead is the head of the RFC 6962 Merkle tree of the entries of a
+// tree of a Merkle weave, like the signed tree head of a Certificate
+// Transparency log.
+type RFC6962TreeHead struct {
+	Prefix    []byte
+	Size      int
+	Timestamp time.Time // when the tree head was taken
+	Root      [rfc6962.HashLength]byte
+}
+
+// MarshalBinary returns the canonical encoding of h: the length of the prefix
+// and the prefix, followed by the TreeHeadSignature structure of RFC 6962
+// section 3.5 (version v1, signature type tree_hash, the timestamp in
+// milliseconds, the size and the root). Integers are big-endian.
+func (h *RFC6962TreeHead) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(len(h.Prefix)))
+	b.Write(buf[:])
+	b.Write(h.Prefix)
+	b.Write([]byte{0, 1})
+	binary.BigEndian.PutUint64(buf[:], uint64(h.Timestamp.UnixNano()/int64(time.Millisecond)))
+	b.Write(buf[:])
+	binary.BigEndian.PutUint64(buf[:], uint64(h.Size))
+	b.Write(buf[:])
+	b.Write(h.Root[:])
+	return b.Bytes(), nil
+}
+
+// Digest returns the SHAKE256 hash of the canonical encoding of h.
+func (h *RFC6962TreeHead) Digest() [merkletree.HashLength]byte {
+	b, _ := h.MarshalBinary()
+	var d [merkletree.HashLength]byte
+	sha3.ShakeSum256(d[:], b)
+	return d
+}
+
+// RFC6962TreeHead returns the head of the RFC 6962 Merkle tree of the entries
+// of the tree with prefix p, for verification with Certificate Transparency
+// tooling.
+func (m *MerkleWeave) RFC6962TreeHead(p []byte) (*RFC6962TreeHead, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return nil, err
+	}
+	defer t.m.Unlock()
+	root, err := t.r.Root(t.r.Len())
+	if err != nil {
+		return nil, err
+	}
+	return &RFC6962TreeHead{
+		Prefix:    append([]byte(nil), p...),
+		Size:      t.r.Len(),
+		Timestamp: m.now().UTC(),
+		Root:      root,
+	}, nil
+}
+
+// RFC6962InclusionProof returns the RFC 6962 audit path of the entry at index
+// in the tree with prefix p when it had n entries.
+func (m *MerkleWeave) RFC6962InclusionProof(p []byte, index, n int) ([][rfc6962.HashLength]byte, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return nil, err
+	}
+	defer t.m.Unlock()
+	return t.r.ProveInclusion(index, n)
+}
+
+// RFC6962ConsistencyProof returns the RFC 6962 consistency proof of the tree
+// with prefix p from size from to size to.
+func (m *MerkleWeave) RFC6962ConsistencyProof(p []byte, from, to int) ([][rfc6962.HashLength]byte, error) {
+	t, err := m.lockTree(p)
+	if err != nil {
+		return nil, err
+	}
+	defer t.m.Unlock()
+	return t.r.ProveConsistency(from, to)
+}
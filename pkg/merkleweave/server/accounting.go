@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Cost is the cost of one or more requests.
+type Cost struct {
+	// PrefixesWithMinTimestamp is the number of trees summary requests
+	// required to be advanced past a minimum timestamp.
+	PrefixesWithMinTimestamp int
+
+	// Sentinels is the number of sentinel entries written to advance trees.
+	Sentinels int
+
+	// Notarizations is the number of entries notarized.
+	Notarizations int
+
+	// Publications is the number of summaries appended to the summary log.
+	Publications int
+}
+
+// Add returns the sum of c and c2.
+func (c Cost) Add(c2 Cost) Cost {
+	return Cost{
+		PrefixesWithMinTimestamp: c.PrefixesWithMinTimestamp + c2.PrefixesWithMinTimestamp,
+		Sentinels:                c.Sentinels + c2.Sentinels,
+		Notarizations:            c.Notarizations + c2.Notarizations,
+		Publications:             c.Publications + c2.Publications,
+	}
+}
+
+// Sub returns c minus c2.
+func (c Cost) Sub(c2 Cost) Cost {
+	return Cost{
+		PrefixesWithMinTimestamp: c.PrefixesWithMinTimestamp - c2.PrefixesWithMinTimestamp,
+		Sentinels:                c.Sentinels - c2.Sentinels,
+		Notarizations:            c.Notarizations - c2.Notarizations,
+		Publications:             c.Publications - c2.Publications,
+	}
+}
+
+// Accountant attributes the cost of requests to callers.
+type Accountant interface {
+	// Reserve is called before a request is handled with an upper bound on
+	// its cost, which is held against the caller until Charge is called. If
+	// Reserve returns an error, nothing is reserved and the request is
+	// rejected.
+	Reserve(caller string, c Cost) error
+
+	// Charge is called after a request is handled, whether or not it
+	// succeeded, with the cost reserved for it and its actual cost, which
+	// replaces the reservation.
+	Charge(caller string, reserved, c Cost)
+}
+
+// CallerFunc identifies the caller of a request.
+type CallerFunc func(ctx context.Context) (string, error)
+
+// ErrNoCaller is returned when the caller of a request cannot be identified.
+var ErrNoCaller = errors.New("caller not identified")
+
+// MetadataCaller returns a CallerFunc that identifies callers by the value of
+// the request metadata with the given key. It is only suitable for use behind
+// a proxy that authenticates callers and sets the metadata.
+func MetadataCaller(key string) CallerFunc {
+	return func(ctx context.Context) (string, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		vs := md.Get(key)
+		if len(vs) != 1 || vs[0] == "" {
+			return "", ErrNoCaller
+		}
+		return vs[0], nil
+	}
+}
+
+type costKey struct{}
+
+// meter accumulates the actual cost of a request.
+type meter struct {
+	mu sync.Mutex
+	c  Cost
+}
+
+// charge adds c to the cost of the request handled with ctx, if any.
+func charge(ctx context.Context, c Cost) {
+	if m, ok := ctx.Value(costKey{}).(*meter); ok {
+		m.mu.Lock()
+		m.c = m.c.Add(c)
+		m.mu.Unlock()
+	}
+}
+
+// estimate returns an upper bound on the cost of req.
+func estimate(req interface{}) Cost {
+	switch r := req.(type) {
+	case *servicepb.WeaveSummaryRequest:
+		n := len(minTimestampPrefixes(r))
+		c := Cost{PrefixesWithMinTimestamp: n, Sentinels: n}
+		if len(r.GetPrefixesToReturn()) == 0 {
+			c.Publications = 1
+		}
+		return c
+	case *servicepb.NotarizeRequest:
+		return Cost{Notarizations: 1}
+	}
+	return Cost{}
+}
+
+// UnaryServerInterceptor returns an interceptor that identifies the caller of
+// each request, rejects requests that a does not reserve, and charges the cost
+// of handled requests to a, releasing their reservations.
+//
+// Requests from callers that cannot be identified fail with
+// codes.Unauthenticated and requests that cannot be reserved fail with
+// codes.ResourceExhausted.
+func UnaryServerInterceptor(a Accountant, caller CallerFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id, err := caller(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+		}
+		reserved := estimate(req)
+		if err := a.Reserve(id, reserved); err != nil {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s: %v", id, err)
+		}
+		m := &meter{}
+		defer func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			a.Charge(id, reserved, m.c)
+		}()
+		return handler(context.WithValue(ctx, costKey{}, m), req)
+	}
+}
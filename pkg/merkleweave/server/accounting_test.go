@@ -0,0 +1,194 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const callerKey = "x-caller"
+
+func withCaller(caller string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(callerKey, caller))
+}
+
+func TestInterceptor(t *testing.T) {
+	s := server.New(merkleweave.New())
+	a := server.NewQuotaAccountant(server.Quota{
+		Window: time.Hour,
+		Limit:  server.Cost{Sentinels: 3},
+	})
+	i := server.UnaryServerInterceptor(a, server.MetadataCaller(callerKey))
+	info := &grpc.UnaryServerInfo{FullMethod: "/merkleweave.protobuf.Fabula/WeaveSummary"}
+	summary := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.WeaveSummary(ctx, req.(*servicepb.WeaveSummaryRequest))
+	}
+	notarize := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Notarize(ctx, req.(*servicepb.NotarizeRequest))
+	}
+
+	if _, err := i(context.Background(), &servicepb.WeaveSummaryRequest{}, info, summary); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+
+	if _, err := i(withCaller("alice"), &servicepb.NotarizeRequest{Hash: []byte{1, 2, 3}}, info, notarize); err != nil {
+		t.Fatal(err)
+	}
+	req := &servicepb.WeaveSummaryRequest{
+		MinTimestamp:             timestamppb.New(time.Now().Add(-time.Second)),
+		PrefixesWithMinTimestamp: [][]byte{{1}, {2}, {3}},
+	}
+	if _, err := i(withCaller("alice"), req, info, summary); err != nil {
+		t.Fatal(err)
+	}
+	expected := server.Cost{PrefixesWithMinTimestamp: 3, Sentinels: 1, Notarizations: 1}
+	if u := a.Usage("alice"); u != expected {
+		t.Errorf("expected usage %+v, got %+v", expected, u)
+	}
+
+	// Estimate of 3 more sentinels exceeds quota.
+	if _, err := i(withCaller("alice"), req, info, summary); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted, got %v", err)
+	}
+	// Failed requests are charged only their actual cost.
+	failing := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, errors.New("failed")
+	}
+	if _, err := i(withCaller("alice"), &servicepb.NotarizeRequest{}, info, failing); err == nil {
+		t.Error("expected error")
+	}
+	if u := a.Usage("alice"); u != expected {
+		t.Errorf("expected usage %+v, got %+v", expected, u)
+	}
+	// Other callers are unaffected.
+	if _, err := i(withCaller("bob"), req, info, summary); err != nil {
+		t.Error(err)
+	}
+	if u := a.Usage("bob"); u.Sentinels != 0 || u.PrefixesWithMinTimestamp != 3 {
+		t.Errorf("unexpected usage %+v", u)
+	}
+}
+
+func TestInterceptorPublications(t *testing.T) {
+	l := merkleweave.NewSummaryLog()
+	s := server.New(merkleweave.New(), server.WithSummaryLog(l))
+	a := server.NewQuotaAccountant(server.Quota{
+		Window: time.Hour,
+		Limit:  server.Cost{Publications: 1},
+	})
+	i := server.UnaryServerInterceptor(a, server.MetadataCaller(callerKey))
+	info := &grpc.UnaryServerInfo{FullMethod: "/merkleweave.protobuf.Fabula/WeaveSummary"}
+	summary := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.WeaveSummary(ctx, req.(*servicepb.WeaveSummaryRequest))
+	}
+
+	if _, err := i(withCaller("alice"), &servicepb.WeaveSummaryRequest{}, info, summary); err != nil {
+		t.Fatal(err)
+	}
+	if u := a.Usage("alice"); u.Publications != 1 {
+		t.Errorf("expected 1 publication, got %+v", u)
+	}
+	// Summaries of an unchanged weave are not published again.
+	if _, err := i(withCaller("bob"), &servicepb.WeaveSummaryRequest{}, info, summary); err != nil {
+		t.Fatal(err)
+	}
+	if u := a.Usage("bob"); u.Publications != 0 || l.Len() != 1 {
+		t.Errorf("expected no publication, got %+v and %d summaries", u, l.Len())
+	}
+	if _, err := i(withCaller("alice"), &servicepb.WeaveSummaryRequest{}, info, summary); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted, got %v", err)
+	}
+	// Summaries of some trees are not published.
+	req := &servicepb.WeaveSummaryRequest{PrefixesToReturn: [][]byte{{1}}}
+	if _, err := i(withCaller("alice"), req, info, summary); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQuotaWindow(t *testing.T) {
+	a := server.NewQuotaAccountant(server.Quota{
+		Window: time.Millisecond,
+		Limit:  server.Cost{Notarizations: 1},
+	})
+	one := server.Cost{Notarizations: 1}
+	if err := a.Reserve("alice", one); err != nil {
+		t.Fatal(err)
+	}
+	a.Charge("alice", one, one)
+	if err := a.Reserve("alice", one); !errors.Is(err, server.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if err := a.Reserve("alice", one); err != nil {
+		t.Errorf("expected quota to reset, got %v", err)
+	}
+}
+
+func TestQuotaReserve(t *testing.T) {
+	a := server.NewQuotaAccountant(server.Quota{
+		Window: time.Hour,
+		Limit:  server.Cost{Notarizations: 2},
+	})
+	one := server.Cost{Notarizations: 1}
+	// Reservations of requests in progress count against the quota.
+	for i := 0; i < 2; i++ {
+		if err := a.Reserve("alice", one); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Reserve("alice", one); !errors.Is(err, server.ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if u := a.Usage("alice"); u != (server.Cost{Notarizations: 2}) {
+		t.Errorf("unexpected usage %+v", u)
+	}
+	// A request that fails releases its reservation.
+	a.Charge("alice", one, server.Cost{})
+	if u := a.Usage("alice"); u != one {
+		t.Errorf("unexpected usage %+v", u)
+	}
+	if err := a.Reserve("alice", one); err != nil {
+		t.Errorf("expected released reservation to be available, got %v", err)
+	}
+}
+
+func TestQuotaSweep(t *testing.T) {
+	a := server.NewQuotaAccountant(server.Quota{
+		Window: time.Minute,
+		Limit:  server.Cost{Notarizations: 1},
+	})
+	now := time.Now()
+	server.SetNowForTest(a, func() time.Time { return now })
+	one := server.Cost{Notarizations: 1}
+	for _, c := range []string{"alice", "bob", "carol"} {
+		if err := a.Reserve(c, one); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.Charge("alice", one, one)
+	a.Charge("bob", one, one)
+	if n := server.CallersForTest(a); n != 3 {
+		t.Fatalf("expected 3 callers, got %d", n)
+	}
+	// Expired usages are removed, except those with requests in progress.
+	now = now.Add(time.Minute)
+	if err := a.Reserve("dave", one); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.CallersForTest(a); n != 2 {
+		t.Errorf("expected carol and dave, got %d callers", n)
+	}
+	if u := a.Usage("carol"); u != one {
+		t.Errorf("expected reservation of carol to be kept, got %+v", u)
+	}
+}
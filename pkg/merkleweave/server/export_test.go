@@ -0,0 +1,15 @@
+package server
+
+import "time"
+
+// SetNowForTest sets the clock of a.
+func SetNowForTest(a *QuotaAccountant, now func() time.Time) {
+	a.now = now
+}
+
+// CallersForTest returns the number of callers whose usage a tracks.
+func CallersForTest(a *QuotaAccountant) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.usages)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned (possibly wrapped) when a caller exceeds its
+// quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota limits the cost callers may incur in each window of time. A limit of
+// zero means unlimited.
+type Quota struct {
+	Window time.Duration
+	Limit  Cost
+}
+
+type usage struct {
+	start    time.Time
+	cost     Cost
+	reserved Cost // by requests in progress
+}
+
+// QuotaAccountant is an Accountant that tracks the cost incurred by each
+// caller and enforces a Quota on each caller. Callers are forgotten once their
+// window has passed without requests in progress, unless the Quota has no
+// window.
+type QuotaAccountant struct {
+	q   Quota
+	now func() time.Time
+
+	mu     sync.Mutex
+	usages map[string]*usage
+	swept  time.Time // when expired usages were last removed
+}
+
+// NewQuotaAccountant returns a QuotaAccountant enforcing q.
+func NewQuotaAccountant(q Quota) *QuotaAccountant {
+	return &QuotaAccountant{
+		q:      q,
+		now:    time.Now,
+		usages: make(map[string]*usage),
+	}
+}
+
+// usage returns the usage of caller in the current window. Reservations of
+// requests in progress are carried into a new window. It must be called with
+// a.mu held.
+func (a *QuotaAccountant) usage(caller string) *usage {
+	now := a.now()
+	a.sweep(now)
+	u, ok := a.usages[caller]
+	if !ok {
+		u = &usage{start: now}
+		a.usages[caller] = u
+	} else if a.q.Window > 0 && now.Sub(u.start) >= a.q.Window {
+		u = &usage{start: now, reserved: u.reserved}
+		a.usages[caller] = u
+	}
+	return u
+}
+
+// sweep removes the usages of windows that have passed and have no requests
+// in progress, at most once per window. It must be called with a.mu held.
+func (a *QuotaAccountant) sweep(now time.Time) {
+	if a.q.Window <= 0 || now.Sub(a.swept) < a.q.Window {
+		return
+	}
+	for caller, u := range a.usages {
+		if now.Sub(u.start) >= a.q.Window && u.reserved == (Cost{}) {
+			delete(a.usages, caller)
+		}
+	}
+	a.swept = now
+}
+
+func exceeds(used, limit int, what string) error {
+	if limit > 0 && used > limit {
+		return fmt.Errorf("%w: %d %s, limit %d", ErrQuotaExceeded, used, what, limit)
+	}
+	return nil
+}
+
+// Reserve adds c to the usage of caller in the current window, or returns an
+// error if c would take caller over its quota. Concurrent requests thus
+// cannot together exceed the quota.
+func (a *QuotaAccountant) Reserve(caller string, c Cost) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	u := a.usage(caller)
+	total := u.cost.Add(u.reserved).Add(c)
+	if err := exceeds(total.PrefixesWithMinTimestamp, a.q.Limit.PrefixesWithMinTimestamp, "prefixes with min timestamps"); err != nil {
+		return err
+	}
+	if err := exceeds(total.Sentinels, a.q.Limit.Sentinels, "sentinels"); err != nil {
+		return err
+	}
+	if err := exceeds(total.Notarizations, a.q.Limit.Notarizations, "notarizations"); err != nil {
+		return err
+	}
+	if err := exceeds(total.Publications, a.q.Limit.Publications, "publications"); err != nil {
+		return err
+	}
+	u.reserved = u.reserved.Add(c)
+	return nil
+}
+
+// Charge releases the reservation of a request by caller and adds its actual
+// cost c to the usage of caller in the current window.
+func (a *QuotaAccountant) Charge(caller string, reserved, c Cost) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	u := a.usage(caller)
+	u.reserved = u.reserved.Sub(reserved)
+	u.cost = u.cost.Add(c)
+}
+
+// Usage returns the cost incurred by caller in the current window, including
+// the cost reserved by requests in progress.
+func (a *QuotaAccountant) Usage(caller string) Cost {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	u := a.usage(caller)
+	return u.cost.Add(u.reserved)
+}
@@ -0,0 +1,380 @@
+// Package server implements the Fabula service over a Merkle weave.
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"github.com/vsekhar/merkleweave/pkg/rfc6962"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server serves a Merkle weave.
+type Server struct {
+	w      *merkleweave.MerkleWeave
+	signer *signing.Signer
+	log    *merkleweave.SummaryLog
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithSigner returns an Option that signs summaries and receipts with s.
+func WithSigner(s *signing.Signer) Option {
+	return func(srv *Server) { srv.signer = s }
+}
+
+// WithSummaryLog returns an Option that publishes summaries of the whole Merkle
+// weave to l, once per state of the weave.
+func WithSummaryLog(l *merkleweave.SummaryLog) Option {
+	return func(srv *Server) { srv.log = l }
+}
+
+// New returns a new Server serving w.
+func New(w *merkleweave.MerkleWeave, opts ...Option) *Server {
+	s := &Server{w: w}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}
+
+// Service returns the Fabula service implemented by s, suitable for
+// servicepb.RegisterFabulaService.
+func (s *Server) Service() *servicepb.FabulaService {
+	return &servicepb.FabulaService{
+		WeaveSummary:     s.WeaveSummary,
+		Notarize:         s.Notarize,
+		Entry:            s.Entry,
+		InclusionProof:   s.InclusionProof,
+		ConsistencyProof: s.ConsistencyProof,
+
+		SummaryLogInclusionProof:   s.SummaryLogInclusionProof,
+		SummaryLogConsistencyProof: s.SummaryLogConsistencyProof,
+
+		RFC6962TreeHead:         s.RFC6962TreeHead,
+		RFC6962InclusionProof:   s.RFC6962InclusionProof,
+		RFC6962ConsistencyProof: s.RFC6962ConsistencyProof,
+	}
+}
+
+func contains(ps [][]byte, p []byte) bool {
+	for _, q := range ps {
+		if bytes.Equal(p, q) {
+			return true
+		}
+	}
+	return false
+}
+
+// returnedPrefixes returns the prefixes of trees to return in response to req.
+func returnedPrefixes(req *servicepb.WeaveSummaryRequest) [][]byte {
+	if len(req.GetPrefixesToReturn()) == 0 {
+		return merkleweave.Prefixes()
+	}
+	return req.GetPrefixesToReturn()
+}
+
+// minTimestampPrefixes returns the prefixes of trees that must be advanced
+// past the minimum timestamp of req.
+func minTimestampPrefixes(req *servicepb.WeaveSummaryRequest) [][]byte {
+	if req.GetMinTimestamp() == nil {
+		return nil
+	}
+	toReturn := returnedPrefixes(req)
+	withMin := req.GetPrefixesWithMinTimestamp()
+	if len(withMin) == 0 {
+		return toReturn
+	}
+	var r [][]byte
+	for _, p := range withMin {
+		if contains(toReturn, p) && !contains(r, p) {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// WeaveSummary returns a summary of the Merkle weave, advancing trees as
+// needed to satisfy the minimum timestamp of the request. Summaries of the
+// whole weave are published to the summary log, if any, and charged as a
+// publication if the weave changed since the last one.
+func (s *Server) WeaveSummary(ctx context.Context, req *servicepb.WeaveSummaryRequest) (*servicepb.WeaveSummaryResponse, error) {
+	if req.GetMinTimestamp() != nil {
+		if err := req.GetMinTimestamp().CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "minTimestamp: %v", err)
+		}
+		withMin := minTimestampPrefixes(req)
+		charge(ctx, Cost{PrefixesWithMinTimestamp: len(withMin)})
+		minTimestamp := req.GetMinTimestamp().AsTime()
+		for _, p := range withMin {
+			advanced, err := s.w.Advance(p, minTimestamp)
+			if err != nil {
+				if errors.Is(err, merkleweave.ErrFutureTimestamp) {
+					return nil, status.Errorf(codes.InvalidArgument, "minTimestamp: %v", err)
+				}
+				return nil, status.Errorf(codes.InvalidArgument, "prefix %x: %v", p, err)
+			}
+			if advanced {
+				charge(ctx, Cost{Sentinels: 1})
+			}
+		}
+	}
+
+	sum := s.w.Summary()
+	resp := &servicepb.WeaveSummaryResponse{}
+	for _, p := range returnedPrefixes(req) {
+		ts, last, err := sum.Tree(p)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "prefix %x: %v", p, err)
+		}
+		peaks, err := s.w.Peaks(p, ts.N)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "prefix %x: %v", p, err)
+		}
+		resp.Trees = append(resp.Trees, &servicepb.PrefixTreeSummaryResponse{
+			Prefix:  p,
+			Summary: treeSummary(merkletree.PeakSummary{N: ts.N, Peaks: peaks, Alg: ts.Alg}, last),
+		})
+	}
+	if len(req.GetPrefixesToReturn()) == 0 {
+		resp.Entries = uint64(sum.Len())
+		if s.signer != nil {
+			resp.Signature = s.signer.Sign(&sum).Encode()
+		}
+		if s.log != nil {
+			index, head, appended, err := s.log.Publish(&sum)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "publishing summary: %v", err)
+			}
+			if appended {
+				charge(ctx, Cost{Publications: 1})
+			}
+			peaks, err := s.log.Peaks(head.N)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "summary log: %v", err)
+			}
+			resp.Log = &servicepb.SummaryLogEntry{
+				Index: uint64(index),
+				Head:  treeSummary(merkletree.PeakSummary{N: head.N, Peaks: peaks, Alg: head.Alg}, time.Time{}),
+			}
+		}
+	}
+	return resp, nil
+}
+
+// Notarize adds a hash to the Merkle weave.
+func (s *Server) Notarize(ctx context.Context, req *servicepb.NotarizeRequest) (*servicepb.NotarizeResponse, error) {
+	r, err := s.w.Notarize(req.GetHash())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "hash: %v", err)
+	}
+	charge(ctx, Cost{Notarizations: 1})
+	resp := &servicepb.NotarizeResponse{
+		Hash:      r.Data,
+		Timestamp: timestamppb.New(r.Timestamp),
+	}
+	for _, p := range r.Positions {
+		resp.Positions = append(resp.Positions, &servicepb.Position{
+			Prefix: p.Prefix,
+			Index:  uint64(p.Index),
+		})
+	}
+	if s.signer != nil {
+		resp.Signature = s.signer.SignReceipt(r).Encode()
+	}
+	return resp, nil
+}
+
+// Entry returns an entry of the Merkle weave.
+func (s *Server) Entry(ctx context.Context, req *servicepb.EntryRequest) (*servicepb.EntryResponse, error) {
+	pos := req.GetPosition()
+	data, ts, err := s.w.Entry(pos.GetPrefix(), int(pos.GetIndex()))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	return &servicepb.EntryResponse{
+		Data:      data,
+		Timestamp: timestamppb.New(ts),
+	}, nil
+}
+
+// InclusionProof returns a proof that an entry is included in a tree of the
+// Merkle weave.
+func (s *Server) InclusionProof(ctx context.Context, req *servicepb.InclusionProofRequest) (*servicepb.InclusionProofResponse, error) {
+	pos := req.GetPosition()
+	p, err := s.w.ProveInclusion(pos.GetPrefix(), int(pos.GetIndex()), int(req.GetSize()))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return &servicepb.InclusionProofResponse{
+		Position:  pos,
+		Size:      req.GetSize(),
+		Children:  hashes(p.Children),
+		Path:      path(p.Path),
+		Peaks:     hashes(p.Peaks),
+		Algorithm: uint32(p.Alg),
+	}, nil
+}
+
+// ConsistencyProof returns a proof that a tree of the Merkle weave of one size
+// is a prefix of the same tree of a larger size.
+func (s *Server) ConsistencyProof(ctx context.Context, req *servicepb.ConsistencyProofRequest) (*servicepb.ConsistencyProofResponse, error) {
+	p, err := s.w.ProveConsistency(req.GetPrefix(), int(req.GetFrom()), int(req.GetTo()))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	resp := &servicepb.ConsistencyProofResponse{
+		Prefix:    req.GetPrefix(),
+		From:      req.GetFrom(),
+		To:        req.GetTo(),
+		OldPeaks:  hashes(p.OldPeaks),
+		NewPeaks:  hashes(p.NewPeaks),
+		Algorithm: uint32(p.Alg),
+	}
+	for _, steps := range p.Paths {
+		resp.Paths = append(resp.Paths, path(steps))
+	}
+	return resp, nil
+}
+
+// SummaryLogInclusionProof returns a proof that a summary is included in the
+// summary log.
+func (s *Server) SummaryLogInclusionProof(ctx context.Context, req *servicepb.SummaryLogInclusionProofRequest) (*servicepb.InclusionProofResponse, error) {
+	if s.log == nil {
+		return nil, status.Error(codes.Unimplemented, "no summary log")
+	}
+	p, err := s.log.ProveInclusion(int(req.GetIndex()), int(req.GetSize()))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return &servicepb.InclusionProofResponse{
+		Position:  &servicepb.Position{Index: req.GetIndex()},
+		Size:      req.GetSize(),
+		Children:  hashes(p.Children),
+		Path:      path(p.Path),
+		Peaks:     hashes(p.Peaks),
+		Algorithm: uint32(p.Alg),
+	}, nil
+}
+
+// SummaryLogConsistencyProof returns a proof that the summary log of one size
+// is a prefix of the summary log of a larger size.
+func (s *Server) SummaryLogConsistencyProof(ctx context.Context, req *servicepb.SummaryLogConsistencyProofRequest) (*servicepb.ConsistencyProofResponse, error) {
+	if s.log == nil {
+		return nil, status.Error(codes.Unimplemented, "no summary log")
+	}
+	p, err := s.log.ProveConsistency(int(req.GetFrom()), int(req.GetTo()))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	resp := &servicepb.ConsistencyProofResponse{
+		From:      req.GetFrom(),
+		To:        req.GetTo(),
+		OldPeaks:  hashes(p.OldPeaks),
+		NewPeaks:  hashes(p.NewPeaks),
+		Algorithm: uint32(p.Alg),
+	}
+	for _, steps := range p.Paths {
+		resp.Paths = append(resp.Paths, path(steps))
+	}
+	return resp, nil
+}
+
+// RFC6962TreeHead returns the RFC 6962 tree head of a tree of the Merkle
+// weave, signed if s signs summaries.
+func (s *Server) RFC6962TreeHead(ctx context.Context, req *servicepb.RFC6962TreeHeadRequest) (*servicepb.RFC6962TreeHeadResponse, error) {
+	h, err := s.w.RFC6962TreeHead(req.GetPrefix())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	resp := &servicepb.RFC6962TreeHeadResponse{
+		Prefix:         h.Prefix,
+		TreeSize:       uint64(h.Size),
+		Timestamp:      timestamppb.New(h.Timestamp),
+		Sha256RootHash: append([]byte(nil), h.Root[:]...),
+	}
+	if s.signer != nil {
+		resp.Signature = s.signer.SignRFC6962TreeHead(h).Encode()
+	}
+	return resp, nil
+}
+
+// RFC6962InclusionProof returns the RFC 6962 audit path of an entry of a tree
+// of the Merkle weave.
+func (s *Server) RFC6962InclusionProof(ctx context.Context, req *servicepb.RFC6962InclusionProofRequest) (*servicepb.RFC6962InclusionProofResponse, error) {
+	p, err := s.w.RFC6962InclusionProof(req.GetPrefix(), int(req.GetLeafIndex()), int(req.GetTreeSize()))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return &servicepb.RFC6962InclusionProofResponse{
+		Prefix:    req.GetPrefix(),
+		LeafIndex: req.GetLeafIndex(),
+		TreeSize:  req.GetTreeSize(),
+		AuditPath: sha256Hashes(p),
+	}, nil
+}
+
+// RFC6962ConsistencyProof returns the RFC 6962 consistency proof of a tree of
+// the Merkle weave between two sizes.
+func (s *Server) RFC6962ConsistencyProof(ctx context.Context, req *servicepb.RFC6962ConsistencyProofRequest) (*servicepb.RFC6962ConsistencyProofResponse, error) {
+	p, err := s.w.RFC6962ConsistencyProof(req.GetPrefix(), int(req.GetFirst()), int(req.GetSecond()))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return &servicepb.RFC6962ConsistencyProofResponse{
+		Prefix:      req.GetPrefix(),
+		First:       req.GetFirst(),
+		Second:      req.GetSecond(),
+		Consistency: sha256Hashes(p),
+	}, nil
+}
+
+// treeSummary returns the summary of a tree with peak summary p whose last
+// entry has timestamp last, if not zero.
+func treeSummary(p merkletree.PeakSummary, last time.Time) *servicepb.TreeSummaryResponse {
+	r := &servicepb.TreeSummaryResponse{
+		Size:      uint64(p.N),
+		Hashes:    hashes(p.Peaks),
+		Algorithm: uint32(p.Alg),
+	}
+	if !last.IsZero() {
+		r.Last = timestamppb.New(last)
+	}
+	return r
+}
+
+func hashes(hs [][merkletree.HashLength]byte) [][]byte {
+	r := make([][]byte, len(hs))
+	for i := range hs {
+		r[i] = append([]byte(nil), hs[i][:]...)
+	}
+	return r
+}
+
+func sha256Hashes(hs [][rfc6962.HashLength]byte) [][]byte {
+	r := make([][]byte, len(hs))
+	for i := range hs {
+		r[i] = append([]byte(nil), hs[i][:]...)
+	}
+	return r
+}
+
+func path(steps []merkletree.Step) *servicepb.ProofPath {
+	r := &servicepb.ProofPath{}
+	for _, s := range steps {
+		r.Steps = append(r.Steps, &servicepb.ProofStep{
+			Sibling: append([]byte(nil), s.Sibling[:]...),
+			Data:    s.Data,
+		})
+	}
+	return r
+}
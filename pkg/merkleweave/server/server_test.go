@@ -0,0 +1,193 @@
+package server_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"github.com/vsekhar/merkleweave/pkg/rfc6962"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestWeaveSummary(t *testing.T) {
+	ctx := context.Background()
+	s := server.New(merkleweave.New())
+	resp, err := s.WeaveSummary(ctx, &servicepb.WeaveSummaryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.GetTrees()) != len(merkleweave.Prefixes()) {
+		t.Errorf("expected %d trees, got %d", len(merkleweave.Prefixes()), len(resp.GetTrees()))
+	}
+
+	min := time.Now().Add(-time.Second)
+	resp, err = s.WeaveSummary(ctx, &servicepb.WeaveSummaryRequest{
+		MinTimestamp:     timestamppb.New(min),
+		PrefixesToReturn: [][]byte{{1}, {2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.GetTrees()) != 2 {
+		t.Fatalf("expected 2 trees, got %d", len(resp.GetTrees()))
+	}
+	for _, tr := range resp.GetTrees() {
+		if tr.GetSummary().GetSize() != 1 {
+			t.Errorf("expected sentinel in tree %x", tr.GetPrefix())
+		}
+		if !tr.GetSummary().GetLast().AsTime().After(min) {
+			t.Errorf("tree %x not advanced past %s", tr.GetPrefix(), min)
+		}
+		if len(tr.GetSummary().GetHashes()) != 1 {
+			t.Errorf("expected 1 peak, got %d", len(tr.GetSummary().GetHashes()))
+		}
+	}
+
+	// Prefixes with min timestamps that are not returned are ignored.
+	resp, err = s.WeaveSummary(ctx, &servicepb.WeaveSummaryRequest{
+		MinTimestamp:             timestamppb.New(min),
+		PrefixesWithMinTimestamp: [][]byte{{3}},
+		PrefixesToReturn:         [][]byte{{4}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := resp.GetTrees()[0].GetSummary().GetSize(); n != 0 {
+		t.Errorf("expected empty tree, got size %d", n)
+	}
+
+	_, err = s.WeaveSummary(ctx, &servicepb.WeaveSummaryRequest{
+		MinTimestamp: timestamppb.New(time.Now().Add(time.Hour)),
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for future timestamp, got %v", err)
+	}
+}
+
+func TestNotarizeAndProve(t *testing.T) {
+	ctx := context.Background()
+	s := server.New(merkleweave.New())
+	hash := []byte{1, 2, 3, 4}
+	nr, err := s.Notarize(ctx, &servicepb.NotarizeRequest{Hash: hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := s.Notarize(ctx, &servicepb.NotarizeRequest{Hash: []byte{1, 2, byte(i)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pos := nr.GetPositions()[0]
+	er, err := s.Entry(ctx, &servicepb.EntryRequest{Position: pos})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(er.GetData()) != string(hash) {
+		t.Errorf("expected %x, got %x", hash, er.GetData())
+	}
+	ip, err := s.InclusionProof(ctx, &servicepb.InclusionProofRequest{Position: pos, Size: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ip.GetPeaks()) != 3 {
+		t.Errorf("expected 3 peaks, got %d", len(ip.GetPeaks()))
+	}
+	cp, err := s.ConsistencyProof(ctx, &servicepb.ConsistencyProofRequest{Prefix: pos.GetPrefix(), From: 1, To: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cp.GetPaths()) != 1 {
+		t.Errorf("expected 1 path, got %d", len(cp.GetPaths()))
+	}
+
+	if _, err := s.Notarize(ctx, &servicepb.NotarizeRequest{Hash: []byte{1}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+	if _, err := s.Entry(ctx, &servicepb.EntryRequest{Position: &servicepb.Position{Prefix: []byte{9}, Index: 0}}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
+
+func TestRFC6962(t *testing.T) {
+	ctx := context.Background()
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := signing.NewSigner(key)
+	s := server.New(merkleweave.New(), server.WithSigner(signer))
+	hash := []byte{1, 2, 3, 4}
+	nr, err := s.Notarize(ctx, &servicepb.NotarizeRequest{Hash: hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if _, err := s.Notarize(ctx, &servicepb.NotarizeRequest{Hash: []byte{1, 2, byte(i)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pos := nr.GetPositions()[0]
+	hr, err := s.RFC6962TreeHead(ctx, &servicepb.RFC6962TreeHeadRequest{Prefix: pos.GetPrefix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hr.GetTreeSize() != 5 {
+		t.Fatalf("expected tree size 5, got %d", hr.GetTreeSize())
+	}
+	h := &merkleweave.RFC6962TreeHead{
+		Prefix:    hr.GetPrefix(),
+		Size:      int(hr.GetTreeSize()),
+		Timestamp: hr.GetTimestamp().AsTime(),
+	}
+	copy(h.Root[:], hr.GetSha256RootHash())
+	sig, err := signing.Decode(hr.GetSignature())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signing.NewKeyRing(signer.Public()).VerifyRFC6962TreeHead(h, sig); err != nil {
+		t.Error(err)
+	}
+
+	ip, err := s.RFC6962InclusionProof(ctx, &servicepb.RFC6962InclusionProofRequest{
+		Prefix:    pos.GetPrefix(),
+		LeafIndex: pos.GetIndex(),
+		TreeSize:  hr.GetTreeSize(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rfc6962.VerifyInclusion(int(pos.GetIndex()), h.Size, rfc6962.LeafHash(hash), h.Root, toHashes(ip.GetAuditPath())); err != nil {
+		t.Error(err)
+	}
+
+	cp, err := s.RFC6962ConsistencyProof(ctx, &servicepb.RFC6962ConsistencyProofRequest{
+		Prefix: pos.GetPrefix(),
+		First:  1,
+		Second: hr.GetTreeSize(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rfc6962.VerifyConsistency(1, h.Size, rfc6962.LeafHash(hash), h.Root, toHashes(cp.GetConsistency())); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := s.RFC6962InclusionProof(ctx, &servicepb.RFC6962InclusionProofRequest{Prefix: pos.GetPrefix(), TreeSize: 6}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func toHashes(bs [][]byte) [][rfc6962.HashLength]byte {
+	r := make([][rfc6962.HashLength]byte, len(bs))
+	for i := range bs {
+		copy(r[i][:], bs[i])
+	}
+	return r
+}
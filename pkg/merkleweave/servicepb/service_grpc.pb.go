@@ -18,6 +18,18 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type FabulaClient interface {
 	WeaveSummary(ctx context.Context, in *WeaveSummaryRequest, opts ...grpc.CallOption) (*WeaveSummaryResponse, error)
+	Notarize(ctx context.Context, in *NotarizeRequest, opts ...grpc.CallOption) (*NotarizeResponse, error)
+	Entry(ctx context.Context, in *EntryRequest, opts ...grpc.CallOption) (*EntryResponse, error)
+	InclusionProof(ctx context.Context, in *InclusionProofRequest, opts ...grpc.CallOption) (*InclusionProofResponse, error)
+	ConsistencyProof(ctx context.Context, in *ConsistencyProofRequest, opts ...grpc.CallOption) (*ConsistencyProofResponse, error)
+	// Proofs for the summary log. Prefixes are not set in the responses.
+	SummaryLogInclusionProof(ctx context.Context, in *SummaryLogInclusionProofRequest, opts ...grpc.CallOption) (*InclusionProofResponse, error)
+	SummaryLogConsistencyProof(ctx context.Context, in *SummaryLogConsistencyProofRequest, opts ...grpc.CallOption) (*ConsistencyProofResponse, error)
+	// RFC 6962 tree heads and proofs of individual trees, for verification
+	// with Certificate Transparency tooling.
+	RFC6962TreeHead(ctx context.Context, in *RFC6962TreeHeadRequest, opts ...grpc.CallOption) (*RFC6962TreeHeadResponse, error)
+	RFC6962InclusionProof(ctx context.Context, in *RFC6962InclusionProofRequest, opts ...grpc.CallOption) (*RFC6962InclusionProofResponse, error)
+	RFC6962ConsistencyProof(ctx context.Context, in *RFC6962ConsistencyProofRequest, opts ...grpc.CallOption) (*RFC6962ConsistencyProofResponse, error)
 }
 
 type fabulaClient struct {
@@ -41,12 +53,141 @@ func (c *fabulaClient) WeaveSummary(ctx context.Context, in *WeaveSummaryRequest
 	return out, nil
 }
 
+var fabulaNotarizeStreamDesc = &grpc.StreamDesc{
+	StreamName: "Notarize",
+}
+
+func (c *fabulaClient) Notarize(ctx context.Context, in *NotarizeRequest, opts ...grpc.CallOption) (*NotarizeResponse, error) {
+	out := new(NotarizeResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/Notarize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var fabulaEntryStreamDesc = &grpc.StreamDesc{
+	StreamName: "Entry",
+}
+
+func (c *fabulaClient) Entry(ctx context.Context, in *EntryRequest, opts ...grpc.CallOption) (*EntryResponse, error) {
+	out := new(EntryResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/Entry", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var fabulaInclusionProofStreamDesc = &grpc.StreamDesc{
+	StreamName: "InclusionProof",
+}
+
+func (c *fabulaClient) InclusionProof(ctx context.Context, in *InclusionProofRequest, opts ...grpc.CallOption) (*InclusionProofResponse, error) {
+	out := new(InclusionProofResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/InclusionProof", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var fabulaConsistencyProofStreamDesc = &grpc.StreamDesc{
+	StreamName: "ConsistencyProof",
+}
+
+func (c *fabulaClient) ConsistencyProof(ctx context.Context, in *ConsistencyProofRequest, opts ...grpc.CallOption) (*ConsistencyProofResponse, error) {
+	out := new(ConsistencyProofResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/ConsistencyProof", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var fabulaSummaryLogInclusionProofStreamDesc = &grpc.StreamDesc{
+	StreamName: "SummaryLogInclusionProof",
+}
+
+func (c *fabulaClient) SummaryLogInclusionProof(ctx context.Context, in *SummaryLogInclusionProofRequest, opts ...grpc.CallOption) (*InclusionProofResponse, error) {
+	out := new(InclusionProofResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/SummaryLogInclusionProof", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var fabulaSummaryLogConsistencyProofStreamDesc = &grpc.StreamDesc{
+	StreamName: "SummaryLogConsistencyProof",
+}
+
+func (c *fabulaClient) SummaryLogConsistencyProof(ctx context.Context, in *SummaryLogConsistencyProofRequest, opts ...grpc.CallOption) (*ConsistencyProofResponse, error) {
+	out := new(ConsistencyProofResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/SummaryLogConsistencyProof", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var fabulaRFC6962TreeHeadStreamDesc = &grpc.StreamDesc{
+	StreamName: "RFC6962TreeHead",
+}
+
+func (c *fabulaClient) RFC6962TreeHead(ctx context.Context, in *RFC6962TreeHeadRequest, opts ...grpc.CallOption) (*RFC6962TreeHeadResponse, error) {
+	out := new(RFC6962TreeHeadResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/RFC6962TreeHead", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var fabulaRFC6962InclusionProofStreamDesc = &grpc.StreamDesc{
+	StreamName: "RFC6962InclusionProof",
+}
+
+func (c *fabulaClient) RFC6962InclusionProof(ctx context.Context, in *RFC6962InclusionProofRequest, opts ...grpc.CallOption) (*RFC6962InclusionProofResponse, error) {
+	out := new(RFC6962InclusionProofResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/RFC6962InclusionProof", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var fabulaRFC6962ConsistencyProofStreamDesc = &grpc.StreamDesc{
+	StreamName: "RFC6962ConsistencyProof",
+}
+
+func (c *fabulaClient) RFC6962ConsistencyProof(ctx context.Context, in *RFC6962ConsistencyProofRequest, opts ...grpc.CallOption) (*RFC6962ConsistencyProofResponse, error) {
+	out := new(RFC6962ConsistencyProofResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Fabula/RFC6962ConsistencyProof", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FabulaService is the service API for Fabula service.
 // Fields should be assigned to their respective handler implementations only before
 // RegisterFabulaService is called.  Any unassigned fields will result in the
 // handler for that method returning an Unimplemented error.
 type FabulaService struct {
-	WeaveSummary func(context.Context, *WeaveSummaryRequest) (*WeaveSummaryResponse, error)
+	WeaveSummary     func(context.Context, *WeaveSummaryRequest) (*WeaveSummaryResponse, error)
+	Notarize         func(context.Context, *NotarizeRequest) (*NotarizeResponse, error)
+	Entry            func(context.Context, *EntryRequest) (*EntryResponse, error)
+	InclusionProof   func(context.Context, *InclusionProofRequest) (*InclusionProofResponse, error)
+	ConsistencyProof func(context.Context, *ConsistencyProofRequest) (*ConsistencyProofResponse, error)
+	// Proofs for the summary log. Prefixes are not set in the responses.
+	SummaryLogInclusionProof   func(context.Context, *SummaryLogInclusionProofRequest) (*InclusionProofResponse, error)
+	SummaryLogConsistencyProof func(context.Context, *SummaryLogConsistencyProofRequest) (*ConsistencyProofResponse, error)
+	// RFC 6962 tree heads and proofs of individual trees, for verification
+	// with Certificate Transparency tooling.
+	RFC6962TreeHead         func(context.Context, *RFC6962TreeHeadRequest) (*RFC6962TreeHeadResponse, error)
+	RFC6962InclusionProof   func(context.Context, *RFC6962InclusionProofRequest) (*RFC6962InclusionProofResponse, error)
+	RFC6962ConsistencyProof func(context.Context, *RFC6962ConsistencyProofRequest) (*RFC6962ConsistencyProofResponse, error)
 }
 
 func (s *FabulaService) weaveSummary(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
@@ -66,6 +207,159 @@ func (s *FabulaService) weaveSummary(_ interface{}, ctx context.Context, dec fun
 	}
 	return interceptor(ctx, in, info, handler)
 }
+func (s *FabulaService) notarize(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NotarizeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.Notarize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/Notarize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Notarize(ctx, req.(*NotarizeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *FabulaService) entry(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.Entry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/Entry",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Entry(ctx, req.(*EntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *FabulaService) inclusionProof(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InclusionProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.InclusionProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/InclusionProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.InclusionProof(ctx, req.(*InclusionProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *FabulaService) consistencyProof(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConsistencyProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.ConsistencyProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/ConsistencyProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.ConsistencyProof(ctx, req.(*ConsistencyProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *FabulaService) summaryLogInclusionProof(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SummaryLogInclusionProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.SummaryLogInclusionProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/SummaryLogInclusionProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.SummaryLogInclusionProof(ctx, req.(*SummaryLogInclusionProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *FabulaService) summaryLogConsistencyProof(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SummaryLogConsistencyProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.SummaryLogConsistencyProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/SummaryLogConsistencyProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.SummaryLogConsistencyProof(ctx, req.(*SummaryLogConsistencyProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *FabulaService) rFC6962TreeHead(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RFC6962TreeHeadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.RFC6962TreeHead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/RFC6962TreeHead",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.RFC6962TreeHead(ctx, req.(*RFC6962TreeHeadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *FabulaService) rFC6962InclusionProof(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RFC6962InclusionProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.RFC6962InclusionProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/RFC6962InclusionProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.RFC6962InclusionProof(ctx, req.(*RFC6962InclusionProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *FabulaService) rFC6962ConsistencyProof(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RFC6962ConsistencyProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.RFC6962ConsistencyProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Fabula/RFC6962ConsistencyProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.RFC6962ConsistencyProof(ctx, req.(*RFC6962ConsistencyProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
 
 // RegisterFabulaService registers a service implementation with a gRPC server.
 func RegisterFabulaService(s grpc.ServiceRegistrar, srv *FabulaService) {
@@ -75,6 +369,51 @@ func RegisterFabulaService(s grpc.ServiceRegistrar, srv *FabulaService) {
 			return nil, status.Errorf(codes.Unimplemented, "method WeaveSummary not implemented")
 		}
 	}
+	if srvCopy.Notarize == nil {
+		srvCopy.Notarize = func(context.Context, *NotarizeRequest) (*NotarizeResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method Notarize not implemented")
+		}
+	}
+	if srvCopy.Entry == nil {
+		srvCopy.Entry = func(context.Context, *EntryRequest) (*EntryResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method Entry not implemented")
+		}
+	}
+	if srvCopy.InclusionProof == nil {
+		srvCopy.InclusionProof = func(context.Context, *InclusionProofRequest) (*InclusionProofResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method InclusionProof not implemented")
+		}
+	}
+	if srvCopy.ConsistencyProof == nil {
+		srvCopy.ConsistencyProof = func(context.Context, *ConsistencyProofRequest) (*ConsistencyProofResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method ConsistencyProof not implemented")
+		}
+	}
+	if srvCopy.SummaryLogInclusionProof == nil {
+		srvCopy.SummaryLogInclusionProof = func(context.Context, *SummaryLogInclusionProofRequest) (*InclusionProofResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method SummaryLogInclusionProof not implemented")
+		}
+	}
+	if srvCopy.SummaryLogConsistencyProof == nil {
+		srvCopy.SummaryLogConsistencyProof = func(context.Context, *SummaryLogConsistencyProofRequest) (*ConsistencyProofResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method SummaryLogConsistencyProof not implemented")
+		}
+	}
+	if srvCopy.RFC6962TreeHead == nil {
+		srvCopy.RFC6962TreeHead = func(context.Context, *RFC6962TreeHeadRequest) (*RFC6962TreeHeadResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method RFC6962TreeHead not implemented")
+		}
+	}
+	if srvCopy.RFC6962InclusionProof == nil {
+		srvCopy.RFC6962InclusionProof = func(context.Context, *RFC6962InclusionProofRequest) (*RFC6962InclusionProofResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method RFC6962InclusionProof not implemented")
+		}
+	}
+	if srvCopy.RFC6962ConsistencyProof == nil {
+		srvCopy.RFC6962ConsistencyProof = func(context.Context, *RFC6962ConsistencyProofRequest) (*RFC6962ConsistencyProofResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method RFC6962ConsistencyProof not implemented")
+		}
+	}
 	sd := grpc.ServiceDesc{
 		ServiceName: "merkleweave.protobuf.Fabula",
 		Methods: []grpc.MethodDesc{
@@ -82,6 +421,42 @@ func RegisterFabulaService(s grpc.ServiceRegistrar, srv *FabulaService) {
 				MethodName: "WeaveSummary",
 				Handler:    srvCopy.weaveSummary,
 			},
+			{
+				MethodName: "Notarize",
+				Handler:    srvCopy.notarize,
+			},
+			{
+				MethodName: "Entry",
+				Handler:    srvCopy.entry,
+			},
+			{
+				MethodName: "InclusionProof",
+				Handler:    srvCopy.inclusionProof,
+			},
+			{
+				MethodName: "ConsistencyProof",
+				Handler:    srvCopy.consistencyProof,
+			},
+			{
+				MethodName: "SummaryLogInclusionProof",
+				Handler:    srvCopy.summaryLogInclusionProof,
+			},
+			{
+				MethodName: "SummaryLogConsistencyProof",
+				Handler:    srvCopy.summaryLogConsistencyProof,
+			},
+			{
+				MethodName: "RFC6962TreeHead",
+				Handler:    srvCopy.rFC6962TreeHead,
+			},
+			{
+				MethodName: "RFC6962InclusionProof",
+				Handler:    srvCopy.rFC6962InclusionProof,
+			},
+			{
+				MethodName: "RFC6962ConsistencyProof",
+				Handler:    srvCopy.rFC6962ConsistencyProof,
+			},
 		},
 		Streams:  []grpc.StreamDesc{},
 		Metadata: "service.proto",
@@ -0,0 +1,279 @@
+// Package signing signs and verifies summaries and receipts of a Merkle weave
+// with ed25519 keys.
+//
+// A signature covers the digest of the canonical encoding of a summary (see
+// merkleweave.Summary.MarshalBinary) or a receipt (see
+// merkleweave.Receipt.MarshalBinary), the ID of the signing key and the time
+// of signing. Operators sign summaries and receipts and witnesses cosign
+// summaries; each kind of signature is computed over distinct messages so that
+// none can be used as another.
+package signing
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// keyIDLen is the length of key IDs.
+const keyIDLen = 8
+
+// Contexts are prepended to signed messages to distinguish them from
+// messages signed for other purposes.
+const (
+	signatureContext        = "merkleweave summary signature v1\x00"
+	cosignatureContext      = "merkleweave summary cosignature v1\x00"
+	receiptSignatureContext = "merkleweave receipt signature v1\x00"
+	treeHeadContext         = "merkleweave rfc6962 tree head signature v1\x00"
+)
+
+var (
+	// ErrUnknownKey is returned when verifying a signature by a key that is
+	// not trusted.
+	ErrUnknownKey = errors.New("unknown key")
+
+	// ErrBadSignature is returned when a signature is invalid.
+	ErrBadSignature = errors.New("bad signature")
+
+	// ErrNoQuorum is returned when a summary is not cosigned by enough
+	// witnesses.
+	ErrNoQuorum = errors.New("no quorum")
+)
+
+// KeyID returns the ID of pub, the first 8 bytes of the SHA3-256 hash of pub.
+func KeyID(pub ed25519.PublicKey) []byte {
+	h := sha3.Sum256(pub)
+	return h[:keyIDLen]
+}
+
+// Signature is a signature of a summary or a receipt.
+type Signature struct {
+	KeyID     []byte
+	Time      time.Time
+	Signature []byte
+}
+
+// message returns the message signed in context for a summary or receipt with
+// digest d.
+func message(context string, d [merkletree.HashLength]byte, keyID []byte, t time.Time) []byte {
+	var b bytes.Buffer
+	b.WriteString(context)
+	b.Write(d[:])
+	b.WriteByte(byte(len(keyID)))
+	b.Write(keyID)
+	var ts [12]byte
+	binary.BigEndian.PutUint64(ts[:8], uint64(t.Unix()))
+	binary.BigEndian.PutUint32(ts[8:], uint32(t.Nanosecond()))
+	b.Write(ts[:])
+	return b.Bytes()
+}
+
+// Signer signs summaries and receipts.
+type Signer struct {
+	key ed25519.PrivateKey
+	id  []byte
+	now func() time.Time
+}
+
+// NewSigner returns a Signer that signs with key.
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{
+		key: key,
+		id:  KeyID(key.Public().(ed25519.PublicKey)),
+		now: time.Now,
+	}
+}
+
+// Public returns the public key of s.
+func (s *Signer) Public() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// KeyID returns the ID of the key of s.
+func (s *Signer) KeyID() []byte {
+	return s.id
+}
+
+func (s *Signer) sign(context string, d [merkletree.HashLength]byte) Signature {
+	t := s.now().UTC()
+	return Signature{
+		KeyID:     s.id,
+		Time:      t,
+		Signature: ed25519.Sign(s.key, message(context, d, s.id, t)),
+	}
+}
+
+// Sign returns a signature of sum, as by an operator.
+func (s *Signer) Sign(sum *merkleweave.Summary) Signature {
+	return s.sign(signatureContext, sum.Digest())
+}
+
+// Cosign returns a cosignature of sum, as by a witness.
+func (s *Signer) Cosign(sum *merkleweave.Summary) Signature {
+	return s.sign(cosignatureContext, sum.Digest())
+}
+
+// SignReceipt returns a signature of r, as by an operator.
+func (s *Signer) SignReceipt(r *merkleweave.Receipt) Signature {
+	return s.sign(receiptSignatureContext, r.Digest())
+}
+
+// SignRFC6962TreeHead returns a signature of h, as by an operator.
+func (s *Signer) SignRFC6962TreeHead(h *merkleweave.RFC6962TreeHead) Signature {
+	return s.sign(treeHeadContext, h.Digest())
+}
+
+// KeyRing is a set of trusted public keys, indexed by key ID.
+type KeyRing map[string]ed25519.PublicKey
+
+// NewKeyRing returns a KeyRing trusting keys.
+func NewKeyRing(keys ...ed25519.PublicKey) KeyRing {
+	k := make(KeyRing)
+	for _, pub := range keys {
+		k[string(KeyID(pub))] = pub
+	}
+	return k
+}
+
+func (k KeyRing) verify(context string, d [merkletree.HashLength]byte, sig Signature) error {
+	pub, ok := k[string(sig.KeyID)]
+	if !ok {
+		return fmt.Errorf("%w %x", ErrUnknownKey, sig.KeyID)
+	}
+	if !ed25519.Verify(pub, message(context, d, sig.KeyID, sig.Time), sig.Signature) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Verify verifies that sig is a valid signature of sum by a key in k.
+func (k KeyRing) Verify(sum *merkleweave.Summary, sig Signature) error {
+	return k.verify(signatureContext, sum.Digest(), sig)
+}
+
+// VerifyCosignature verifies that sig is a valid cosignature of sum by a key
+// in k.
+func (k KeyRing) VerifyCosignature(sum *merkleweave.Summary, sig Signature) error {
+	return k.verify(cosignatureContext, sum.Digest(), sig)
+}
+
+// VerifyReceipt verifies that sig is a valid signature of r by a key in k.
+func (k KeyRing) VerifyReceipt(r *merkleweave.Receipt, sig Signature) error {
+	return k.verify(receiptSignatureContext, r.Digest(), sig)
+}
+
+// VerifyRFC6962TreeHead verifies that sig is a valid signature of h by a key
+// in k.
+func (k KeyRing) VerifyRFC6962TreeHead(h *merkleweave.RFC6962TreeHead, sig Signature) error {
+	return k.verify(treeHeadContext, h.Digest(), sig)
+}
+
+// VerifyQuorum verifies that sigs include valid cosignatures of sum by at
+// least q distinct keys in k. Cosignatures by unknown keys are ignored.
+func (k KeyRing) VerifyQuorum(sum *merkleweave.Summary, sigs []Signature, q int) error {
+	valid := make(map[string]bool)
+	var errs []string
+	for _, sig := range sigs {
+		if err := k.VerifyCosignature(sum, sig); err != nil {
+			if !errors.Is(err, ErrUnknownKey) {
+				errs = append(errs, fmt.Sprintf("%x: %v", sig.KeyID, err))
+			}
+			continue
+		}
+		valid[string(sig.KeyID)] = true
+	}
+	if len(valid) < q {
+		err := fmt.Errorf("%w: %d of %d required cosignatures", ErrNoQuorum, len(valid), q)
+		if len(errs) > 0 {
+			err = fmt.Errorf("%w (%s)", err, strings.Join(errs, "; "))
+		}
+		return err
+	}
+	return nil
+}
+
+// Encode returns sig encoded for the wire.
+func (sig Signature) Encode() *servicepb.SummarySignature {
+	return &servicepb.SummarySignature{
+		KeyId:     sig.KeyID,
+		Time:      timestamppb.New(sig.Time),
+		Signature: sig.Signature,
+	}
+}
+
+// Decode decodes a signature from the wire.
+func Decode(p *servicepb.SummarySignature) (Signature, error) {
+	if err := p.GetTime().CheckValid(); err != nil {
+		return Signature{}, fmt.Errorf("time: %v", err)
+	}
+	if len(p.GetSignature()) != ed25519.SignatureSize {
+		return Signature{}, fmt.Errorf("expected signature of %d bytes, got %d", ed25519.SignatureSize, len(p.GetSignature()))
+	}
+	return Signature{KeyID: p.GetKeyId(), Time: p.GetTime().AsTime(), Signature: p.GetSignature()}, nil
+}
+
+// ParsePrivateKey parses a PEM-encoded PKCS #8 ed25519 private key, as
+// generated by `openssl genpkey -algorithm ed25519`.
+func ParsePrivateKey(b []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, errors.New("no PEM private key found")
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected ed25519 key, got %T", k)
+	}
+	return key, nil
+}
+
+// ParsePublicKey parses a PEM-encoded PKIX ed25519 public key, as generated
+// by `openssl pkey -pubout`.
+func ParsePublicKey(b []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("no PEM public key found")
+	}
+	k, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := k.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected ed25519 key, got %T", k)
+	}
+	return key, nil
+}
+
+// MarshalPrivateKey returns key PEM-encoded as a PKCS #8 private key.
+func MarshalPrivateKey(key ed25519.PrivateKey) ([]byte, error) {
+	b, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b}), nil
+}
+
+// MarshalPublicKey returns key PEM-encoded as a PKIX public key.
+func MarshalPublicKey(key ed25519.PublicKey) ([]byte, error) {
+	b, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b}), nil
+}
@@ -0,0 +1,250 @@
+package signing_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+)
+
+func TestSignVerify(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := merkleweave.New()
+	w.Append([]byte{1, 2, 3, 4})
+	sum := w.Summary()
+	s := signing.NewSigner(key)
+	sig := s.Sign(&sum)
+	if !bytes.Equal(sig.KeyID, signing.KeyID(pub)) {
+		t.Errorf("expected key ID %x, got %x", signing.KeyID(pub), sig.KeyID)
+	}
+	k := signing.NewKeyRing(pub)
+	if err := k.Verify(&sum, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// Round trip through the wire encoding.
+	sig2, err := signing.Decode(sig.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Verify(&sum, sig2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing time is covered.
+	sig2.Time = sig2.Time.Add(time.Nanosecond)
+	if err := k.Verify(&sum, sig2); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature for changed time, got %v", err)
+	}
+
+	// Summary is covered.
+	w.Append([]byte{5, 6, 7, 8})
+	sum2 := w.Summary()
+	if err := k.Verify(&sum2, sig); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature for other summary, got %v", err)
+	}
+
+	other, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signing.NewKeyRing(other).Verify(&sum, sig); !errors.Is(err, signing.ErrUnknownKey) {
+		t.Errorf("expected unknown key, got %v", err)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	ss := make([]merkletree.Summary, 256)
+	last := make([]time.Time, 256)
+	a, err := merkleweave.NewSummary(0, ss, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 8+256*(8+1+12+merkletree.HashLength) {
+		t.Errorf("unexpected encoding length %d", len(b))
+	}
+	last[3] = time.Unix(1, 0)
+	b2, err := merkleweave.NewSummary(0, ss, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Digest() == b2.Digest() {
+		t.Error("expected digests to differ")
+	}
+	// The algorithms of the trees are covered.
+	ss[5].Alg = merkletree.SHA256
+	b3, err := merkleweave.NewSummary(0, ss, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b2.Digest() == b3.Digest() {
+		t.Error("expected digests to differ for different algorithms")
+	}
+	ss[5].Alg = 0
+	if _, err := merkleweave.NewSummary(0, ss[1:], last); err == nil {
+		t.Error("expected error for too few trees")
+	}
+
+	// The number of entries is covered.
+	ss[3].N, ss[4].N = 2, 2
+	c1, err := merkleweave.NewSummary(1, ss, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := merkleweave.NewSummary(2, ss, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1.Digest() == c2.Digest() {
+		t.Error("expected digests to differ for different numbers of entries")
+	}
+	if _, err := merkleweave.NewSummary(3, ss, last); err == nil {
+		t.Error("expected error for more entries than fit in trees")
+	}
+}
+
+func TestPEM(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kb, err := signing.MarshalPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pb, err := signing.MarshalPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := signing.ParsePrivateKey(kb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.Equal(key2) {
+		t.Error("private key did not round trip")
+	}
+	pub2, err := signing.ParsePublicKey(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(pub2) {
+		t.Error("public key did not round trip")
+	}
+	if _, err := signing.ParsePublicKey(kb); err == nil {
+		t.Error("expected error parsing private key as public key")
+	}
+}
+
+func TestCosign(t *testing.T) {
+	w := merkleweave.New()
+	w.Append([]byte{1, 2, 3, 4})
+	sum := w.Summary()
+	var signers []*signing.Signer
+	var keys []ed25519.PublicKey
+	for i := 0; i < 3; i++ {
+		pub, key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers = append(signers, signing.NewSigner(key))
+		keys = append(keys, pub)
+	}
+	k := signing.NewKeyRing(keys...)
+
+	// Signatures and cosignatures are not interchangeable.
+	sig, cosig := signers[0].Sign(&sum), signers[0].Cosign(&sum)
+	if err := k.VerifyCosignature(&sum, sig); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad cosignature, got %v", err)
+	}
+	if err := k.Verify(&sum, cosig); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature, got %v", err)
+	}
+
+	// Duplicate cosignatures count once.
+	sigs := []signing.Signature{cosig, cosig, signers[1].Cosign(&sum)}
+	if err := k.VerifyQuorum(&sum, sigs, 2); err != nil {
+		t.Error(err)
+	}
+	if err := k.VerifyQuorum(&sum, sigs, 3); !errors.Is(err, signing.ErrNoQuorum) {
+		t.Errorf("expected no quorum, got %v", err)
+	}
+}
+
+func TestSignReceipt(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := merkleweave.New()
+	r, err := w.Notarize([]byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := signing.NewSigner(key)
+	k := signing.NewKeyRing(pub)
+	sig := s.SignReceipt(r)
+	if err := k.VerifyReceipt(r, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// Timestamp and positions are covered.
+	r2 := *r
+	r2.Timestamp = r.Timestamp.Add(-time.Nanosecond)
+	if err := k.VerifyReceipt(&r2, sig); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature for changed timestamp, got %v", err)
+	}
+	r2 = *r
+	r2.Positions = []merkleweave.Position{r.Positions[0], {Prefix: r.Positions[1].Prefix, Index: 1}}
+	if err := k.VerifyReceipt(&r2, sig); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature for changed position, got %v", err)
+	}
+
+	// Receipt signatures are not summary signatures.
+	sum := w.Summary()
+	if err := k.Verify(&sum, sig); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature, got %v", err)
+	}
+}
+
+func TestSignRFC6962TreeHead(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := merkleweave.New()
+	w.Append([]byte{1, 2, 3, 4})
+	h, err := w.RFC6962TreeHead([]byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := signing.NewSigner(key)
+	k := signing.NewKeyRing(pub)
+	sig := s.SignRFC6962TreeHead(h)
+	if err := k.VerifyRFC6962TreeHead(h, sig); err != nil {
+		t.Fatal(err)
+	}
+
+	// The prefix is covered, so heads of other trees cannot be forged.
+	h2 := *h
+	h2.Prefix = []byte{2}
+	if err := k.VerifyRFC6962TreeHead(&h2, sig); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature for changed prefix, got %v", err)
+	}
+	h2 = *h
+	h2.Size++
+	if err := k.VerifyRFC6962TreeHead(&h2, sig); !errors.Is(err, signing.ErrBadSignature) {
+		t.Errorf("expected bad signature for changed size, got %v", err)
+	}
+}
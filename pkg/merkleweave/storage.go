@@ -0,0 +1,252 @@
+package merkleweave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/vsekhar/merkleweave/driver"
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/storagepb"
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func writeStorageNode(w *bytes.Buffer, e *storagepb.StorageEntry) {
+	w.Write(e.GetNodeSha3256())
+	var b [12]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(e.GetTimestamp().GetSeconds()))
+	binary.BigEndian.PutUint32(b[8:], uint32(e.GetTimestamp().GetNanos()))
+	w.Write(b[:])
+}
+
+// StorageNodeHash returns the node hash of a StorageEntry as specified in
+// storage.proto:
+//
+//	node_sha3256 = hash(data_sha3256, prev.node_sha3256, prev.timestamp, left_child.node_sha3256, left_child.timestamp)
+//
+// Timestamps are hashed as big-endian seconds (8 bytes) and nanoseconds (4
+// bytes). prev is nil for the first entry of a tree and leftChild is nil for
+// leaves.
+func StorageNodeHash(data []byte, prev, leftChild *storagepb.StorageEntry) []byte {
+	var b bytes.Buffer
+	b.Write(data)
+	if prev != nil {
+		writeStorageNode(&b, prev)
+	}
+	if leftChild != nil {
+		writeStorageNode(&b, leftChild)
+	}
+	h := sha3.Sum256(b.Bytes())
+	return h[:]
+}
+
+// storageNode returns the node hash and timestamp of the entry at pos of t,
+// or nil if pos is out of range.
+func (t *tree) storageNode(pos int) *storagepb.StorageEntry {
+	if pos < 0 || pos >= len(t.sn) {
+		return nil
+	}
+	return &storagepb.StorageEntry{
+		NodeSha3256: t.sn[pos],
+		Timestamp:   timestamppb.New(t.ts[pos]),
+	}
+}
+
+// storageEntry returns the StorageEntry for the next entry of t. If pending is
+// not nil, it is an entry stored in t but not yet appended to it, and the
+// StorageEntry is for the entry after it.
+func (t *tree) storageEntry(data []byte, ts time.Time, pending *storagepb.StorageEntry) *storagepb.StorageEntry {
+	pos := t.t.Len()
+	prev := t.storageNode(pos - 1)
+	if pending != nil {
+		pos++
+		prev = pending
+	}
+	// The left child of a node is never the node just before it, so it is
+	// never pending.
+	return &storagepb.StorageEntry{
+		Timestamp:   timestamppb.New(ts),
+		DataSha3256: data,
+		NodeSha3256: StorageNodeHash(data, prev, t.storageNode(merkletree.LeftChild(pos))),
+	}
+}
+
+// store writes an entry to the tree of each prefix in ps and flushes those
+// trees, so that receipts and summaries only cover entries in stable
+// storage. It returns the node hashes of the entries, or nil hashes if the
+// Merkle weave has no driver.
+//
+// An entry is stored in all of its trees or none: if a write fails, the
+// entries already written are discarded. It must be called with the trees
+// locked.
+func (m *MerkleWeave) store(ps []prefix, data []byte, ts time.Time) ([][]byte, error) {
+	nodes := make([][]byte, len(ps))
+	if m.d == nil {
+		return nodes, nil
+	}
+	pending := make(map[prefix]*storagepb.StorageEntry)
+	err := func() error {
+		for i, p := range ps {
+			t := m.ts[p]
+			want := int64(t.t.Len())
+			if pending[p] != nil {
+				want++
+			}
+			e := t.storageEntry(data, ts, pending[p])
+			n, err := m.d.WriteNext(p[:], e)
+			if err != nil {
+				return err
+			}
+			if n != want {
+				return fmt.Errorf("tree %x: stored entry %d, expected %d", p, n, want)
+			}
+			pending[p] = e
+			nodes[i] = e.NodeSha3256
+		}
+		for p := range pending {
+			if err := m.d.FlushTree(p[:]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	if err == nil {
+		return nodes, nil
+	}
+	for _, p := range ps {
+		if uerr := m.d.Truncate(p[:], int64(m.ts[p].t.Len())); uerr != nil {
+			return nil, fmt.Errorf("%v (discarding stored entries: %v)", err, uerr)
+		}
+	}
+	return nil, err
+}
+
+// appendEntry appends an entry with sequence number seq and storage node hash
+// node to t. node is nil if the Merkle weave has no driver. It must be called
+// with t locked.
+func (t *tree) appendEntry(data []byte, ts time.Time, seq uint64, node []byte) {
+	if node != nil {
+		t.sn = append(t.sn, node)
+	}
+	t.t.Append(data)
+	t.r.Append(data)
+	t.ts = append(t.ts, ts)
+	t.seq = append(t.seq, seq)
+}
+
+// Open returns a MerkleWeave backed by d, loading the entries stored in d.
+// Entries appended to the MerkleWeave are written to d and flushed before they
+// are added to its trees. An entry that cannot be written to both of its cross
+// trees is discarded from d and not added.
+//
+// If a crash interrupted the storage of an entry, so that it is the last entry
+// of one of its cross trees but missing from the other, the entry was never
+// acknowledged and Open discards it from d.
+func Open(d driver.Interface) (*MerkleWeave, error) {
+	m := New()
+	es := make(map[prefix][]*storagepb.StorageEntry, len(m.ts))
+	for p := range m.ts {
+		n, err := d.Len(p[:])
+		if err != nil {
+			return nil, err
+		}
+		for i := int64(0); i < n; i++ {
+			e, err := d.Get(p[:], i)
+			if err != nil {
+				return nil, err
+			}
+			if err := e.GetTimestamp().CheckValid(); err != nil {
+				return nil, fmt.Errorf("entry %x:%d: %v", p, i, err)
+			}
+			var prev, left *storagepb.StorageEntry
+			if i > 0 {
+				prev = es[p][i-1]
+			}
+			if l := merkletree.LeftChild(int(i)); l >= 0 {
+				left = es[p][l]
+			}
+			if node := StorageNodeHash(e.GetDataSha3256(), prev, left); !bytes.Equal(node, e.GetNodeSha3256()) {
+				return nil, fmt.Errorf("entry %x:%d: node hash mismatch", p, i)
+			}
+			es[p] = append(es[p], e)
+		}
+	}
+	// Find all incomplete entries before discarding any. Node hashes were
+	// checked, so corrupt entries are not mistaken for incomplete ones.
+	var incomplete []prefix
+	for p := range es {
+		if lastIncomplete(es, p) {
+			incomplete = append(incomplete, p)
+		}
+	}
+	for _, p := range incomplete {
+		n := len(es[p]) - 1
+		if err := d.Truncate(p[:], int64(n)); err != nil {
+			return nil, fmt.Errorf("discarding incomplete entry %x:%d: %v", p, n, err)
+		}
+		es[p] = es[p][:n]
+	}
+
+	for p, t := range m.ts {
+		for i, e := range es[p] {
+			ts := e.GetTimestamp().AsTime()
+			if data := e.GetDataSha3256(); bytes.Equal(data, sentinel(p)) {
+				// A notarized entry equal to a sentinel is appended twice in
+				// a row with the same timestamp, while sentinels are appended
+				// alone.
+				if k := len(t.sentinels); k > 0 && t.sentinels[k-1] == i-1 && ts.Equal(t.ts[i-1]) {
+					t.sentinels = t.sentinels[:k-1]
+				} else {
+					t.sentinels = append(t.sentinels, i)
+				}
+			}
+			t.appendEntry(e.GetDataSha3256(), ts, 0, e.GetNodeSha3256())
+		}
+	}
+	m.d = d
+	return m, nil
+}
+
+// lastIncomplete returns true if the last entry of the tree with prefix p in
+// es is missing from one of its cross trees.
+//
+// Entries are stored in all their cross trees while the trees are locked, so
+// only the last entry of a tree can be incomplete, and a copy of it in another
+// tree has the same timestamp and is followed only by later entries.
+// Sentinels are stored in one tree and are never incomplete; a notarized entry
+// equal to a sentinel whose second copy is missing is kept as a sentinel.
+func lastIncomplete(es map[prefix][]*storagepb.StorageEntry, p prefix) bool {
+	n := len(es[p])
+	if n == 0 {
+		return false
+	}
+	last := es[p][n-1]
+	data := last.GetDataSha3256()
+	if len(data) < minDataLen || bytes.Equal(data, sentinel(p)) {
+		return false
+	}
+	ts := last.GetTimestamp().AsTime()
+	want := make(map[prefix]int)
+	for _, q := range prefixesOf(data) {
+		want[q]++
+	}
+	for q, k := range want {
+		found := 0
+		for i := len(es[q]) - 1; i >= 0; i-- {
+			e := es[q][i]
+			ets := e.GetTimestamp().AsTime()
+			if ets.Before(ts) {
+				break
+			}
+			if ets.Equal(ts) && bytes.Equal(e.GetDataSha3256(), data) {
+				found++
+			}
+		}
+		if found < k {
+			return true
+		}
+	}
+	return false
+}
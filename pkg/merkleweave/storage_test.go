@@ -0,0 +1,191 @@
+package merkleweave
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/driver"
+	"github.com/vsekhar/merkleweave/driver/filedriver"
+	"github.com/vsekhar/merkleweave/driver/memdriver"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/storagepb"
+)
+
+func TestOpen(t *testing.T) {
+	d := memdriver.New()
+	m, err := Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err := m.Notarize([]byte{byte(i), byte(i * 7), 3, 4}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.Advance([]byte{0xfe}, m.now().Add(-1)); err != nil {
+		t.Fatal(err)
+	}
+	// Notarized data equal to a sentinel is counted.
+	if _, err := m.Notarize(sentinel(fromHex("fe"))); err != nil {
+		t.Fatal(err)
+	}
+	s := m.Summary()
+	if s.Len() != 101 {
+		t.Errorf("expected 101 entries, got %d", s.Len())
+	}
+
+	m2, err := Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2 := m2.Summary()
+	if !s.Equals(&s2) {
+		t.Errorf("expected %s, got %s", s.ShortString(), s2.ShortString())
+	}
+	if s.last != s2.last {
+		t.Error("timestamps differ after reopening")
+	}
+
+	// Corrupt an entry.
+	p := []byte{5}
+	e, err := d.Get(p, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.DataSha3256[3]++
+	d2 := memdriver.New()
+	d2.WriteNext(p, e)
+	if _, err := Open(d2); err == nil {
+		t.Error("expected error opening corrupt storage")
+	}
+}
+
+func TestFlushBeforeReceipt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "merkleweave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	d, err := filedriver.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	m, err := Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := m.Notarize([]byte{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The entry is in the files without closing the driver.
+	ro, err := filedriver.OpenReadOnly(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ro.Close()
+	for _, p := range r.Positions {
+		if n, err := ro.Len(p.Prefix); err != nil || n != int64(p.Index+1) {
+			t.Errorf("tree %x: expected %d stored entries, got %d, %v", p.Prefix, p.Index+1, n, err)
+		}
+	}
+}
+
+// failingDriver fails writes once it has made writes writes, unless writes is
+// negative.
+type failingDriver struct {
+	driver.Interface
+	writes int
+}
+
+func (d *failingDriver) WriteNext(prefix []byte, e *storagepb.StorageEntry) (int64, error) {
+	if d.writes == 0 {
+		return 0, errors.New("write failed")
+	}
+	d.writes--
+	return d.Interface.WriteNext(prefix, e)
+}
+
+func TestStoreAllOrNothing(t *testing.T) {
+	d := &failingDriver{Interface: memdriver.New(), writes: -1}
+	m, err := Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Notarize([]byte{1, 2, 3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	d.writes = 1
+	if _, err := m.Notarize([]byte{1, 2, 5, 6}); err == nil {
+		t.Fatal("expected error")
+	}
+	for _, p := range []prefix{fromHex("01"), fromHex("02")} {
+		if n, err := d.Len(p[:]); err != nil || n != 1 {
+			t.Errorf("tree %x: expected 1 stored entry, got %d, %v", p, n, err)
+		}
+		if n := m.ts[p].t.Len(); n != 1 {
+			t.Errorf("tree %x: expected 1 entry, got %d", p, n)
+		}
+	}
+
+	d.writes = -1
+	if _, err := m.Notarize([]byte{1, 2, 7, 8}); err != nil {
+		t.Fatal(err)
+	}
+	m2, err := Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, s2 := m.Summary(), m2.Summary()
+	if !s.Equals(&s2) {
+		t.Errorf("expected %s, got %s", s.ShortString(), s2.ShortString())
+	}
+}
+
+func TestOpenIncomplete(t *testing.T) {
+	d := memdriver.New()
+	m, err := Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		m.Append([]byte{1, 2, byte(i), 4})
+	}
+	if _, err := m.Advance([]byte{3}, m.now().Add(-1)); err != nil {
+		t.Fatal(err)
+	}
+	s := m.Summary()
+
+	// Simulate crashes after storing entries in only one of their trees.
+	m.Append([]byte{1, 2, 99, 4})
+	m.Append([]byte{5, 5, 99, 4})
+	for _, p := range [][]byte{{2}, {5}} {
+		n, err := d.Len(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.Truncate(p, n-1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m2, err := Open(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2 := m2.Summary()
+	if !s.Equals(&s2) {
+		t.Errorf("expected %s, got %s", s.ShortString(), s2.ShortString())
+	}
+	for _, p := range []prefix{fromHex("01"), fromHex("02"), fromHex("05")} {
+		if n, err := d.Len(p[:]); err != nil || n != int64(m2.ts[p].t.Len()) {
+			t.Errorf("tree %x: expected incomplete entry to be discarded from storage, got %d entries, %v", p, n, err)
+		}
+	}
+	if n, _ := d.Len([]byte{3}); n != 1 {
+		t.Errorf("expected sentinel to be kept, got %d entries", n)
+	}
+}
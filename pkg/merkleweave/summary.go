@@ -0,0 +1,72 @@
+package merkleweave
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+	"golang.org/x/crypto/sha3"
+)
+
+// NewSummary returns the summary of a Merkle weave with n notarized entries
+// whose trees, in prefix order, have summaries ss and last entries at
+// timestamps last. Timestamps of empty trees are zero.
+func NewSummary(n int, ss []merkletree.Summary, last []time.Time) (*Summary, error) {
+	if len(ss) != numTrees || len(last) != numTrees {
+		return nil, fmt.Errorf("expected %d trees, got %d summaries and %d timestamps", numTrees, len(ss), len(last))
+	}
+	size := 0
+	for _, s := range ss {
+		size += s.N
+	}
+	if n < 0 || n*numCrossTrees > size {
+		return nil, fmt.Errorf("%d entries cannot be in trees of total size %d", n, size)
+	}
+	s := &Summary{n: n}
+	copy(s.ss[:], ss)
+	for i, t := range last {
+		if !t.IsZero() {
+			t = t.UTC()
+		}
+		s.last[i] = t
+	}
+	return s, nil
+}
+
+// MarshalBinary returns the canonical encoding of s. The encoding starts with
+// the number of notarized entries as a big-endian uint64. Then for each tree
+// in prefix order, it contains the size of the tree as a big-endian uint64,
+// the merkletree.Algorithm of the tree (1 byte), the timestamp of its last
+// entry as big-endian seconds (8 bytes) and nanoseconds (4 bytes) since the
+// Unix epoch, zero if the tree is empty, and the summary hash of the tree.
+func (s *Summary) MarshalBinary() ([]byte, error) {
+	var b bytes.Buffer
+	b.Grow(8 + numTrees*(8+1+12+merkletree.HashLength))
+	var buf [21]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(s.n))
+	b.Write(buf[:8])
+	for i := range s.ss {
+		binary.BigEndian.PutUint64(buf[:8], uint64(s.ss[i].N))
+		buf[8] = byte(s.ss[i].Alg)
+		var secs int64
+		var nanos int32
+		if !s.last[i].IsZero() {
+			secs, nanos = s.last[i].Unix(), int32(s.last[i].Nanosecond())
+		}
+		binary.BigEndian.PutUint64(buf[9:17], uint64(secs))
+		binary.BigEndian.PutUint32(buf[17:], uint32(nanos))
+		b.Write(buf[:])
+		b.Write(s.ss[i].Summary[:])
+	}
+	return b.Bytes(), nil
+}
+
+// Digest returns the SHAKE256 hash of the canonical encoding of s.
+func (s *Summary) Digest() [merkletree.HashLength]byte {
+	b, _ := s.MarshalBinary()
+	var d [merkletree.HashLength]byte
+	sha3.ShakeSum256(d[:], b)
+	return d
+}
@@ -0,0 +1,135 @@
+package merkleweave
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+)
+
+// SummaryLog is a Merkle tree of the digests of published summaries of a
+// Merkle weave.
+//
+// Each summary of a Merkle weave commits to the histories of all of its trees
+// separately. The head of a SummaryLog commits to the history of its published
+// summaries as a whole: any summary published before a head can be proven to
+// be included in it, and any two heads can be proven to be consistent, so that
+// clients need only keep the latest head.
+type SummaryLog struct {
+	mu sync.Mutex
+	t  *merkletree.MerkleTree
+	f  *os.File // nil if not stored
+}
+
+// NewSummaryLog returns a new empty SummaryLog kept in memory.
+func NewSummaryLog() *SummaryLog {
+	return &SummaryLog{t: merkletree.New()}
+}
+
+// OpenSummaryLog opens the SummaryLog stored in the file at path, creating it
+// if it does not exist. Digests are appended to the file as they are
+// published.
+func OpenSummaryLog(path string) (*SummaryLog, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l := &SummaryLog{t: merkletree.New(), f: f}
+	var d [merkletree.HashLength]byte
+	for {
+		_, err := io.ReadFull(f, d[:])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		l.t.Append(append([]byte(nil), d[:]...))
+	}
+	// Discard any partial record.
+	size := int64(l.t.Len()) * merkletree.HashLength
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Close closes the file the SummaryLog is stored in, if any.
+func (l *SummaryLog) Close() error {
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+// Len returns the number of summaries in the SummaryLog.
+func (l *SummaryLog) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.t.Len()
+}
+
+// Publish appends the digest of s to the SummaryLog, unless it is the digest
+// of the last published summary, so that each state of a Merkle weave is
+// published once. It returns the index of the digest, the head of the
+// SummaryLog including it and whether the digest was appended.
+func (l *SummaryLog) Publish(s *Summary) (index int, head merkletree.Summary, appended bool, err error) {
+	d := s.Digest()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n := l.t.Len(); n > 0 && string(l.t.At(n-1)) == string(d[:]) {
+		return n - 1, l.t.Summary(), false, nil
+	}
+	if l.f != nil {
+		if _, err := l.f.Write(d[:]); err != nil {
+			return 0, merkletree.Summary{}, false, err
+		}
+		if err := l.f.Sync(); err != nil {
+			return 0, merkletree.Summary{}, false, err
+		}
+	}
+	l.t.Append(d[:])
+	return l.t.Len() - 1, l.t.Summary(), true, nil
+}
+
+// Peaks returns the hashes of the peaks of the SummaryLog when it had n
+// summaries.
+func (l *SummaryLog) Peaks(n int) ([][merkletree.HashLength]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n < 0 || n > l.t.Len() {
+		return nil, fmt.Errorf("size %d out of range for summary log of length %d", n, l.t.Len())
+	}
+	return l.t.Peaks(n), nil
+}
+
+// ProveInclusion returns a proof that the summary at index is included in the
+// SummaryLog when it had n summaries.
+func (l *SummaryLog) ProveInclusion(index, n int) (*merkletree.InclusionProof, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.t.ProveInclusion(index, n)
+}
+
+// ProveConsistency returns a proof that the SummaryLog when it had from
+// summaries is a prefix of the SummaryLog when it had to summaries.
+func (l *SummaryLog) ProveConsistency(from, to int) (*merkletree.ConsistencyProof, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.t.ProveConsistency(from, to)
+}
+
+// VerifySummaryInclusion verifies that p proves that s is included in a
+// SummaryLog with head.
+func VerifySummaryInclusion(head merkletree.Summary, s *Summary, p *merkletree.InclusionProof) error {
+	d := s.Digest()
+	return merkletree.VerifyInclusion(head, d[:], p)
+}
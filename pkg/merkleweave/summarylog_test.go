@@ -0,0 +1,104 @@
+package merkleweave
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/pkg/merkletree"
+)
+
+func TestSummaryLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "summarylog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "summaries")
+	l, err := OpenSummaryLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := New()
+	var sums []Summary
+	var heads []merkletree.Summary
+	for i := 0; i < 10; i++ {
+		w.Append([]byte{byte(i), byte(i + 1), 3, 4})
+		s := w.Summary()
+		index, head, appended, err := l.Publish(&s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index != i || head.N != i+1 || !appended {
+			t.Fatalf("expected index %d of %d, got %d of %d", i, i+1, index, head.N)
+		}
+		sums, heads = append(sums, s), append(heads, head)
+	}
+
+	// Republishing the last summary does not grow the log.
+	last := sums[len(sums)-1]
+	if index, head, appended, err := l.Publish(&last); err != nil || index != 9 || !head.Equals(heads[9]) || appended {
+		t.Errorf("expected index 9 not appended, got %d, %v, %v", index, appended, err)
+	}
+
+	// Old summaries are included in new heads, and heads are consistent.
+	for i, s := range sums {
+		s := s
+		p, err := l.ProveInclusion(i, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := VerifySummaryInclusion(heads[9], &s, p); err != nil {
+			t.Errorf("summary %d: %v", i, err)
+		}
+		if i > 0 {
+			if err := VerifySummaryInclusion(heads[9], &sums[i-1], p); err == nil {
+				t.Errorf("summary %d: expected error for wrong summary", i)
+			}
+		}
+		cp, err := l.ProveConsistency(i+1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := merkletree.VerifyConsistency(heads[i], heads[9], cp); err != nil {
+			t.Errorf("head %d: %v", i, err)
+		}
+	}
+
+	// The log is restored from its file, discarding a partial record.
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte{1, 2, 3})
+	f.Close()
+	l, err = OpenSummaryLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	if l.Len() != 10 {
+		t.Fatalf("expected 10 summaries, got %d", l.Len())
+	}
+	w.Append([]byte{9, 9, 9, 9})
+	s := w.Summary()
+	index, head, _, err := l.Publish(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 10 {
+		t.Errorf("expected index 10, got %d", index)
+	}
+	cp, err := l.ProveConsistency(10, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := merkletree.VerifyConsistency(heads[9], head, cp); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package witness cosigns summaries of a Merkle weave.
+//
+// A Witness keeps the last summary it has cosigned. It cosigns a new summary
+// only if the summary is signed by the operator and is proven to be
+// consistent with its last summary, and then keeps the new summary in turn.
+// Since a witness never cosigns two summaries that are inconsistent with each
+// other, an operator that shows different clients different views of the
+// Merkle weave cannot obtain cosignatures for both views from the same
+// witness. Clients that require cosignatures from a quorum of independent
+// witnesses (see client.WithWitnesses) are protected from such split views
+// unless the quorum colludes with the operator.
+package witness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/witnesspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Store stores the last summary cosigned by a witness.
+type Store interface {
+	// Load returns the stored summary, or nil if none has been stored.
+	Load() (*client.Summary, error)
+
+	// Save stores s, replacing any previously stored summary.
+	Save(s *client.Summary) error
+}
+
+// FileStore stores summaries as JSON in the file at its path.
+type FileStore string
+
+// Load implements Store.
+func (f FileStore) Load() (*client.Summary, error) {
+	b, err := ioutil.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := new(client.Summary)
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Save implements Store. The file is replaced atomically.
+func (f FileStore) Save(s *client.Summary) error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(string(f)), filepath.Base(string(f))+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), string(f))
+}
+
+// Witness cosigns summaries that are consistent with every summary it has
+// cosigned before.
+type Witness struct {
+	operator signing.KeyRing
+	signer   *signing.Signer
+	store    Store
+
+	mu     sync.Mutex
+	latest *client.Summary
+}
+
+// New returns a Witness that cosigns summaries signed by a key in operator
+// with signer, resuming from the summary in store.
+func New(operator signing.KeyRing, signer *signing.Signer, store Store) (*Witness, error) {
+	latest, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil {
+		latest = client.EmptySummary()
+	}
+	return &Witness{operator: operator, signer: signer, store: store, latest: latest}, nil
+}
+
+// Service returns the Witness service implemented by w, suitable for
+// registering with a gRPC server.
+func (w *Witness) Service() *witnesspb.WitnessService {
+	return &witnesspb.WitnessService{
+		Latest: w.Latest,
+		Cosign: w.Cosign,
+	}
+}
+
+// Latest returns the last summary cosigned by w.
+func (w *Witness) Latest(ctx context.Context, req *witnesspb.LatestRequest) (*witnesspb.LatestResponse, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return &witnesspb.LatestResponse{Summary: w.latest.Encode()}, nil
+}
+
+// request serves a summary and consistency proofs from a CosignRequest to a
+// client.Client, which verifies them. Other methods of the embedded
+// FabulaClient are not implemented.
+type request struct {
+	servicepb.FabulaClient
+	req *witnesspb.CosignRequest
+}
+
+// WeaveSummary returns the summary of the request without its summary log
+// entry, which witnesses do not check.
+func (r request) WeaveSummary(ctx context.Context, in *servicepb.WeaveSummaryRequest, opts ...grpc.CallOption) (*servicepb.WeaveSummaryResponse, error) {
+	s := r.req.GetSummary()
+	return &servicepb.WeaveSummaryResponse{Trees: s.GetTrees(), Entries: s.GetEntries(), Signature: s.GetSignature()}, nil
+}
+
+func (r request) ConsistencyProof(ctx context.Context, in *servicepb.ConsistencyProofRequest, opts ...grpc.CallOption) (*servicepb.ConsistencyProofResponse, error) {
+	for _, p := range r.req.GetProofs() {
+		if bytes.Equal(p.GetPrefix(), in.GetPrefix()) && p.GetFrom() == in.GetFrom() && p.GetTo() == in.GetTo() {
+			return p, nil
+		}
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "missing consistency proof for tree %x from %d to %d", in.GetPrefix(), in.GetFrom(), in.GetTo())
+}
+
+// Cosign verifies that the summary of req is signed by the operator and is
+// consistent with the last summary cosigned by w, and if so cosigns it. It
+// returns FailedPrecondition if any tree of the summary is smaller than in
+// the last summary, in which case the caller should retry with a newer
+// summary.
+func (w *Witness) Cosign(ctx context.Context, req *witnesspb.CosignRequest) (*witnesspb.CosignResponse, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	c := client.NewWithSummary(request{req: req}, w.latest, client.WithKeyRing(w.operator))
+	s, err := c.Summary(ctx, time.Time{})
+	if err != nil {
+		var ie *client.InconsistencyError
+		if errors.As(err, &ie) && ie.New.Summary.N < ie.Old.Summary.N {
+			return nil, status.Errorf(codes.FailedPrecondition, "summary is older than the last cosigned summary: %v", err)
+		}
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sum, err := s.Weave()
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := w.store.Save(s); err != nil {
+		return nil, status.Errorf(codes.Internal, "saving summary: %v", err)
+	}
+	w.latest = s
+	return &witnesspb.CosignResponse{Cosignature: w.signer.Cosign(sum).Encode()}, nil
+}
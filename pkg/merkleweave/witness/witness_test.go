@@ -0,0 +1,211 @@
+package witness_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vsekhar/merkleweave/pkg/merkleweave"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/client"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/server"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/servicepb"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/signing"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/witness"
+	"github.com/vsekhar/merkleweave/pkg/merkleweave/witnesspb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serve registers services with a server over an in-memory connection and
+// returns a connection to it.
+func serve(t *testing.T, register func(*grpc.Server)) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	register(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func dial(t *testing.T, w *merkleweave.MerkleWeave, signer *signing.Signer) servicepb.FabulaClient {
+	conn := serve(t, func(s *grpc.Server) {
+		servicepb.RegisterFabulaService(s, server.New(w, server.WithSigner(signer)).Service())
+	})
+	return servicepb.NewFabulaClient(conn)
+}
+
+func newSigner(t *testing.T) *signing.Signer {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signing.NewSigner(key)
+}
+
+func newWitness(t *testing.T, operator *signing.Signer, store witness.Store) (*witness.Witness, client.Witness) {
+	signer := newSigner(t)
+	w, err := witness.New(signing.NewKeyRing(operator.Public()), signer, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := serve(t, func(s *grpc.Server) { witnesspb.RegisterWitnessService(s, w.Service()) })
+	return w, client.Witness{Client: witnesspb.NewWitnessClient(conn), Key: signer.Public()}
+}
+
+// cosignRequest returns a request to cosign the summary of mw signed by
+// signer, if not nil, with proofs of consistency with old.
+func cosignRequest(t *testing.T, mw *merkleweave.MerkleWeave, signer *signing.Signer, old merkleweave.Summary) *witnesspb.CosignRequest {
+	ctx := context.Background()
+	var opts []server.Option
+	if signer != nil {
+		opts = append(opts, server.WithSigner(signer))
+	}
+	s := server.New(mw, opts...)
+	resp, err := s.WeaveSummary(ctx, &servicepb.WeaveSummaryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &witnesspb.CosignRequest{Summary: resp}
+	for _, tr := range resp.GetTrees() {
+		from, _, err := old.Tree(tr.GetPrefix())
+		if err != nil {
+			t.Fatal(err)
+		}
+		to := tr.GetSummary().GetSize()
+		if uint64(from.N) == to {
+			continue
+		}
+		p, err := s.ConsistencyProof(ctx, &servicepb.ConsistencyProofRequest{Prefix: tr.GetPrefix(), From: uint64(from.N), To: to})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Proofs = append(req.Proofs, p)
+	}
+	return req
+}
+
+func TestCosign(t *testing.T) {
+	dir, err := ioutil.TempDir("", "witness")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	operator := newSigner(t)
+	_, w1 := newWitness(t, operator, witness.FileStore(filepath.Join(dir, "w1.json")))
+	_, w2 := newWitness(t, operator, witness.FileStore(filepath.Join(dir, "w2.json")))
+	_, w3 := newWitness(t, operator, witness.FileStore(filepath.Join(dir, "w3.json")))
+	witnesses := signing.NewKeyRing(w1.Key, w2.Key, w3.Key)
+
+	mw := merkleweave.New()
+	fc := dial(t, mw, operator)
+	c := client.New(fc, client.WithWitnesses(2, w1, w2, w3))
+	for i := 0; i < 3; i++ {
+		mw.Append([]byte{byte(i), 2, 3, 4})
+		s, err := c.Summary(ctx, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s.Cosignatures) != 3 {
+			t.Errorf("expected 3 cosignatures, got %d", len(s.Cosignatures))
+		}
+		if err := s.VerifyCosignatures(witnesses, 3); err != nil {
+			t.Error(err)
+		}
+		// Operator signatures are not cosignatures.
+		if err := s.VerifyCosignatures(signing.NewKeyRing(operator.Public()), 1); !errors.Is(err, signing.ErrNoQuorum) {
+			t.Errorf("expected no quorum, got %v", err)
+		}
+	}
+
+	// A witness resumes from its store.
+	_, w1r := newWitness(t, operator, witness.FileStore(filepath.Join(dir, "w1.json")))
+	latest, err := w1r.Client.Latest(ctx, &witnesspb.LatestRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tr := range latest.GetSummary().GetTrees() {
+		if got, want := tr.GetSummary().GetSize(), uint64(c.Pinned().Trees[i].Summary.N); got != want {
+			t.Errorf("expected restarted witness at size %d for tree %x, got %d", want, tr.GetPrefix(), got)
+		}
+	}
+
+	// A forked weave cannot be cosigned by the same witnesses.
+	fork := merkleweave.New()
+	fork.Append([]byte{9, 9, 9, 9})
+	fork.Append([]byte{0, 9, 9, 9})
+	fork.Append([]byte{1, 9, 9, 9})
+	fork.Append([]byte{2, 9, 9, 9})
+	c2 := client.New(dial(t, fork, operator), client.WithWitnesses(1, w1, w2, w3))
+	if _, err := c2.Summary(ctx, time.Time{}); !errors.Is(err, signing.ErrNoQuorum) {
+		t.Errorf("expected no quorum for forked weave, got %v", err)
+	}
+}
+
+func TestCosignRejected(t *testing.T) {
+	dir, err := ioutil.TempDir("", "witness")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	operator := newSigner(t)
+	w, _ := newWitness(t, operator, witness.FileStore(filepath.Join(dir, "w.json")))
+
+	mw := merkleweave.New()
+	empty := mw.Summary()
+	mw.Append([]byte{1, 2, 3, 4})
+	first := cosignRequest(t, mw, operator, empty)
+	if _, err := w.Cosign(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Cosign(ctx, first); err != nil {
+		t.Fatalf("cosigning the same summary again: %v", err)
+	}
+
+	// Unsigned.
+	unsigned := cosignRequest(t, mw, nil, empty)
+	if _, err := w.Cosign(ctx, unsigned); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for unsigned summary, got %v", err)
+	}
+
+	// Signed by another key.
+	other := cosignRequest(t, mw, newSigner(t), empty)
+	if _, err := w.Cosign(ctx, other); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for unknown key, got %v", err)
+	}
+
+	// Missing consistency proofs.
+	old := mw.Summary()
+	mw.Append([]byte{1, 2, 3, 5})
+	grown := cosignRequest(t, mw, operator, old)
+	proofs := grown.Proofs
+	grown.Proofs = nil
+	if _, err := w.Cosign(ctx, grown); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for missing proofs, got %v", err)
+	}
+	grown.Proofs = proofs
+	if _, err := w.Cosign(ctx, grown); err != nil {
+		t.Fatal(err)
+	}
+
+	// Older than the last cosigned summary.
+	if _, err := w.Cosign(ctx, first); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("expected FailedPrecondition for old summary, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package witnesspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion7
+
+// WitnessClient is the client API for Witness service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WitnessClient interface {
+	Latest(ctx context.Context, in *LatestRequest, opts ...grpc.CallOption) (*LatestResponse, error)
+	Cosign(ctx context.Context, in *CosignRequest, opts ...grpc.CallOption) (*CosignResponse, error)
+}
+
+type witnessClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWitnessClient(cc grpc.ClientConnInterface) WitnessClient {
+	return &witnessClient{cc}
+}
+
+var witnessLatestStreamDesc = &grpc.StreamDesc{
+	StreamName: "Latest",
+}
+
+func (c *witnessClient) Latest(ctx context.Context, in *LatestRequest, opts ...grpc.CallOption) (*LatestResponse, error) {
+	out := new(LatestResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Witness/Latest", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var witnessCosignStreamDesc = &grpc.StreamDesc{
+	StreamName: "Cosign",
+}
+
+func (c *witnessClient) Cosign(ctx context.Context, in *CosignRequest, opts ...grpc.CallOption) (*CosignResponse, error) {
+	out := new(CosignResponse)
+	err := c.cc.Invoke(ctx, "/merkleweave.protobuf.Witness/Cosign", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WitnessService is the service API for Witness service.
+// Fields should be assigned to their respective handler implementations only before
+// RegisterWitnessService is called.  Any unassigned fields will result in the
+// handler for that method returning an Unimplemented error.
+type WitnessService struct {
+	Latest func(context.Context, *LatestRequest) (*LatestResponse, error)
+	Cosign func(context.Context, *CosignRequest) (*CosignResponse, error)
+}
+
+func (s *WitnessService) latest(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LatestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.Latest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Witness/Latest",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Latest(ctx, req.(*LatestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+func (s *WitnessService) cosign(_ interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CosignRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return s.Cosign(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     s,
+		FullMethod: "/merkleweave.protobuf.Witness/Cosign",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return s.Cosign(ctx, req.(*CosignRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RegisterWitnessService registers a service implementation with a gRPC server.
+func RegisterWitnessService(s grpc.ServiceRegistrar, srv *WitnessService) {
+	srvCopy := *srv
+	if srvCopy.Latest == nil {
+		srvCopy.Latest = func(context.Context, *LatestRequest) (*LatestResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method Latest not implemented")
+		}
+	}
+	if srvCopy.Cosign == nil {
+		srvCopy.Cosign = func(context.Context, *CosignRequest) (*CosignResponse, error) {
+			return nil, status.Errorf(codes.Unimplemented, "method Cosign not implemented")
+		}
+	}
+	sd := grpc.ServiceDesc{
+		ServiceName: "merkleweave.protobuf.Witness",
+		Methods: []grpc.MethodDesc{
+			{
+				MethodName: "Latest",
+				Handler:    srvCopy.latest,
+			},
+			{
+				MethodName: "Cosign",
+				Handler:    srvCopy.cosign,
+			},
+		},
+		Streams:  []grpc.StreamDesc{},
+		Metadata: "witness.proto",
+	}
+
+	s.RegisterService(&sd, nil)
+}
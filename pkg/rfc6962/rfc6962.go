@@ -0,0 +1,244 @@
+// Package rfc6962 provides Merkle trees with the hashing and proofs of RFC 6962
+// (Certificate Transparency), so that individual trees of a Merkle weave can
+// be verified with existing Certificate Transparency verifiers.
+//
+// Leaves are hashed as SHA-256(0x00 || data) and interior nodes as
+// SHA-256(0x01 || left || right). Proofs are lists of node hashes laid out and
+// verified as described in RFC 6962 section 2.1 and RFC 9162 section 2.1.
+package rfc6962
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// HashLength is the number of bytes of a hash.
+const HashLength = sha256.Size
+
+// ErrInvalidProof is returned (possibly wrapped) when a proof fails to verify.
+var ErrInvalidProof = errors.New("invalid proof")
+
+// LeafHash returns the hash of a leaf with data.
+func LeafHash(data []byte) [HashLength]byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(data)
+	var r [HashLength]byte
+	copy(r[:], h.Sum(nil))
+	return r
+}
+
+// NodeHash returns the hash of an interior node with children left and right.
+func NodeHash(left, right [HashLength]byte) [HashLength]byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left[:])
+	h.Write(right[:])
+	var r [HashLength]byte
+	copy(r[:], h.Sum(nil))
+	return r
+}
+
+// EmptyRoot is the root of an empty tree, the SHA-256 of the empty string.
+var EmptyRoot [HashLength]byte = sha256.Sum256(nil)
+
+// Tree is an RFC 6962 Merkle tree.
+type Tree struct {
+	// levels[h][i] is the hash of the complete subtree of 2^h leaves
+	// starting at leaf i*2^h.
+	levels [][][HashLength]byte
+}
+
+// New returns a new empty Tree.
+func New() *Tree {
+	return &Tree{levels: [][][HashLength]byte{nil}}
+}
+
+// Len returns the number of leaves in the Tree.
+func (t *Tree) Len() int {
+	return len(t.levels[0])
+}
+
+// Append adds a leaf with data to the Tree.
+func (t *Tree) Append(data []byte) {
+	t.levels[0] = append(t.levels[0], LeafHash(data))
+	i := t.Len() - 1
+	for h := 0; i%2 == 1; h++ {
+		if h+1 == len(t.levels) {
+			t.levels = append(t.levels, nil)
+		}
+		t.levels[h+1] = append(t.levels[h+1], NodeHash(t.levels[h][i-1], t.levels[h][i]))
+		i /= 2
+	}
+}
+
+// split returns the largest power of two less than n, for n > 1.
+func split(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
+
+// hash returns the Merkle tree hash of the leaves from lo to hi.
+func (t *Tree) hash(lo, hi int) [HashLength]byte {
+	n := hi - lo
+	switch {
+	case n == 0:
+		return EmptyRoot
+	case n&(n-1) == 0:
+		h := bits.TrailingZeros(uint(n))
+		return t.levels[h][lo>>h]
+	}
+	k := split(n)
+	return NodeHash(t.hash(lo, lo+k), t.hash(lo+k, hi))
+}
+
+// Root returns the root hash of the Tree when it had n leaves.
+func (t *Tree) Root(n int) ([HashLength]byte, error) {
+	if n < 0 || n > t.Len() {
+		return [HashLength]byte{}, fmt.Errorf("size %d out of range for tree of length %d", n, t.Len())
+	}
+	return t.hash(0, n), nil
+}
+
+// path returns the audit path of leaf m in the subtree of leaves lo to hi.
+func (t *Tree) path(m, lo, hi int) [][HashLength]byte {
+	n := hi - lo
+	if n <= 1 {
+		return nil
+	}
+	k := split(n)
+	if m < k {
+		return append(t.path(m, lo, lo+k), t.hash(lo+k, hi))
+	}
+	return append(t.path(m-k, lo+k, hi), t.hash(lo, lo+k))
+}
+
+// ProveInclusion returns the audit path proving that the leaf at index is
+// included in the Tree when it had n leaves.
+func (t *Tree) ProveInclusion(index, n int) ([][HashLength]byte, error) {
+	if index < 0 || index >= n || n > t.Len() {
+		return nil, fmt.Errorf("cannot prove leaf %d in tree of size %d (length %d)", index, n, t.Len())
+	}
+	return t.path(index, 0, n), nil
+}
+
+// subproof returns the consistency proof of the first m leaves of the subtree
+// of leaves lo to hi. complete is true if the first m leaves are a complete
+// subtree whose hash the verifier has.
+func (t *Tree) subproof(m, lo, hi int, complete bool) [][HashLength]byte {
+	n := hi - lo
+	if m == n {
+		if complete {
+			return nil
+		}
+		return [][HashLength]byte{t.hash(lo, hi)}
+	}
+	k := split(n)
+	if m <= k {
+		return append(t.subproof(m, lo, lo+k, complete), t.hash(lo+k, hi))
+	}
+	return append(t.subproof(m-k, lo+k, hi, false), t.hash(lo, lo+k))
+}
+
+// ProveConsistency returns the proof that the Tree when it had m leaves is a
+// prefix of the Tree when it had n leaves.
+func (t *Tree) ProveConsistency(m, n int) ([][HashLength]byte, error) {
+	if m < 0 || m > n || n > t.Len() {
+		return nil, fmt.Errorf("cannot prove size %d consistent with size %d (length %d)", m, n, t.Len())
+	}
+	if m == 0 || m == n {
+		return nil, nil
+	}
+	return t.subproof(m, 0, n, true), nil
+}
+
+// VerifyInclusion verifies that proof proves that the leaf with leafHash is at
+// index in a tree of size n with root.
+func VerifyInclusion(index, n int, leafHash, root [HashLength]byte, proof [][HashLength]byte) error {
+	if index < 0 || index >= n {
+		return fmt.Errorf("%w: index %d out of range for size %d", ErrInvalidProof, index, n)
+	}
+	fn, sn := index, n-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return fmt.Errorf("%w: path too long", ErrInvalidProof)
+		}
+		if fn&1 == 1 || fn == sn {
+			r = NodeHash(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = NodeHash(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return fmt.Errorf("%w: path too short", ErrInvalidProof)
+	}
+	if r != root {
+		return fmt.Errorf("%w: leaf does not match root", ErrInvalidProof)
+	}
+	return nil
+}
+
+// VerifyConsistency verifies that proof proves that a tree of size m with
+// oldRoot is a prefix of a tree of size n with newRoot.
+func VerifyConsistency(m, n int, oldRoot, newRoot [HashLength]byte, proof [][HashLength]byte) error {
+	switch {
+	case m < 0 || m > n:
+		return fmt.Errorf("%w: cannot prove size %d consistent with size %d", ErrInvalidProof, m, n)
+	case m == n:
+		if len(proof) != 0 || oldRoot != newRoot {
+			return fmt.Errorf("%w: same size, different root", ErrInvalidProof)
+		}
+		return nil
+	case m == 0:
+		if len(proof) != 0 {
+			return fmt.Errorf("%w: expected empty proof from empty tree", ErrInvalidProof)
+		}
+		return nil
+	case len(proof) == 0:
+		return fmt.Errorf("%w: empty proof", ErrInvalidProof)
+	}
+	if m&(m-1) == 0 {
+		proof = append([][HashLength]byte{oldRoot}, proof...)
+	}
+	fn, sn := m-1, n-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return fmt.Errorf("%w: proof too long", ErrInvalidProof)
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = NodeHash(c, fr)
+			sr = NodeHash(c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = NodeHash(sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 {
+		return fmt.Errorf("%w: proof too short", ErrInvalidProof)
+	}
+	if fr != oldRoot {
+		return fmt.Errorf("%w: old root does not match", ErrInvalidProof)
+	}
+	if sr != newRoot {
+		return fmt.Errorf("%w: new root does not match", ErrInvalidProof)
+	}
+	return nil
+}
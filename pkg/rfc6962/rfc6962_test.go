@@ -0,0 +1,185 @@
+package rfc6962_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/vsekhar/merkleweave/pkg/rfc6962"
+)
+
+// Test vectors from the Certificate Transparency reference implementation.
+var (
+	leaves = []string{"", "00", "10", "2021", "3031", "40414243", "5051525354555657", "606162636465666768696a6b6c6d6e6f"}
+	roots  = []string{
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+	inclusion = []struct {
+		index, n int
+		path     []string
+	}{
+		{0, 8, []string{
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+		}},
+		{0, 1, nil},
+		{5, 8, []string{
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		}},
+		{2, 3, []string{
+			"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		}},
+		{1, 5, []string{
+			"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		}},
+	}
+	consistency = []struct {
+		m, n  int
+		proof []string
+	}{
+		{1, 8, []string{
+			"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+		}},
+		{6, 8, []string{
+			"0ebc5d3437fbe2db158b9f126a1d118e308181031d0a949f8dededebc558ef6a",
+			"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+			"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		}},
+		{1, 1, nil},
+		{2, 5, []string{
+			"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+			"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+		}},
+	}
+)
+
+func fromHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func hashes(t *testing.T, ss []string) [][rfc6962.HashLength]byte {
+	r := make([][rfc6962.HashLength]byte, len(ss))
+	for i, s := range ss {
+		copy(r[i][:], fromHex(t, s))
+	}
+	return r
+}
+
+func newTestTree(t *testing.T) *rfc6962.Tree {
+	tr := rfc6962.New()
+	for _, l := range leaves {
+		tr.Append(fromHex(t, l))
+	}
+	return tr
+}
+
+func TestRoots(t *testing.T) {
+	tr := newTestTree(t)
+	for n, want := range roots {
+		got, err := tr.Root(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(got[:]) != want {
+			t.Errorf("root of size %d: expected %s, got %x", n, want, got)
+		}
+	}
+}
+
+func TestVectors(t *testing.T) {
+	tr := newTestTree(t)
+	for _, v := range inclusion {
+		p, err := tr.ProveInclusion(v.index, v.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := hashes(t, v.path)
+		if len(p) != len(want) {
+			t.Fatalf("inclusion(%d, %d): expected %d hashes, got %d", v.index, v.n, len(want), len(p))
+		}
+		for i := range p {
+			if p[i] != want[i] {
+				t.Errorf("inclusion(%d, %d)[%d]: expected %x, got %x", v.index, v.n, i, want[i], p[i])
+			}
+		}
+	}
+	for _, v := range consistency {
+		p, err := tr.ProveConsistency(v.m, v.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := hashes(t, v.proof)
+		if len(p) != len(want) {
+			t.Fatalf("consistency(%d, %d): expected %d hashes, got %d", v.m, v.n, len(want), len(p))
+		}
+		for i := range p {
+			if p[i] != want[i] {
+				t.Errorf("consistency(%d, %d)[%d]: expected %x, got %x", v.m, v.n, i, want[i], p[i])
+			}
+		}
+	}
+}
+
+const proofTreeSize = 40
+
+func TestProofs(t *testing.T) {
+	tr := rfc6962.New()
+	var data [][]byte
+	for i := 0; i < proofTreeSize; i++ {
+		b := []byte{byte(i), byte(i >> 8), 0xff}
+		tr.Append(b)
+		data = append(data, b)
+	}
+	for n := 1; n <= proofTreeSize; n++ {
+		root, _ := tr.Root(n)
+		for i := 0; i < n; i++ {
+			p, err := tr.ProveInclusion(i, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rfc6962.VerifyInclusion(i, n, rfc6962.LeafHash(data[i]), root, p); err != nil {
+				t.Errorf("VerifyInclusion(%d, %d): %v", i, n, err)
+			}
+			if err := rfc6962.VerifyInclusion(i, n, rfc6962.LeafHash([]byte("bad")), root, p); !errors.Is(err, rfc6962.ErrInvalidProof) {
+				t.Errorf("VerifyInclusion(%d, %d) of bad data: %v", i, n, err)
+			}
+		}
+	}
+	for m := 0; m <= proofTreeSize; m++ {
+		oldRoot, _ := tr.Root(m)
+		for n := m; n <= proofTreeSize; n++ {
+			newRoot, _ := tr.Root(n)
+			p, err := tr.ProveConsistency(m, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rfc6962.VerifyConsistency(m, n, oldRoot, newRoot, p); err != nil {
+				t.Errorf("VerifyConsistency(%d, %d): %v", m, n, err)
+			}
+			if m > 0 && m < n {
+				if err := rfc6962.VerifyConsistency(m, n, newRoot, oldRoot, p); !errors.Is(err, rfc6962.ErrInvalidProof) {
+					t.Errorf("VerifyConsistency(%d, %d) with swapped roots: %v", m, n, err)
+				}
+			}
+		}
+	}
+}